@@ -0,0 +1,426 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	volumetypes "github.com/docker/docker/api/types/volume"
+	"github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/client/transport"
+	"github.com/docker/engine-api/client/transport/cancellable"
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/filters"
+	timetypes "github.com/docker/engine-api/types/time"
+	"github.com/docker/engine-api/types/versions"
+	"github.com/docker/go-connections/sockets"
+	"golang.org/x/net/context"
+)
+
+// APIClient is the client of the daemon API. It adds the endpoints of this
+// daemon that the vendored engine-api client doesn't have to it.
+type APIClient interface {
+	client.APIClient
+	ContainerLogsWithFilters(ctx context.Context, container string, options ContainerLogsOptions) (io.ReadCloser, error)
+	VolumeCreateFromSnapshot(ctx context.Context, options volumetypes.CreateRequest) (types.Volume, error)
+	VolumeExport(ctx context.Context, volumeID string, compress bool) (io.ReadCloser, error)
+	VolumeImport(ctx context.Context, source io.Reader, options volumetypes.ImportOptions) (types.Volume, error)
+	VolumeListWithSize(ctx context.Context, filter filters.Args) (volumetypes.ListResponse, error)
+	VolumeResize(ctx context.Context, volumeID string, options volumetypes.ResizeRequest) error
+	VolumeRotateKey(ctx context.Context, volumeID string) error
+	VolumeSnapshotCreate(ctx context.Context, volumeID string, options volumetypes.SnapshotCreateRequest) (volumetypes.Snapshot, error)
+	VolumeSnapshotList(ctx context.Context, volumeID string) ([]volumetypes.Snapshot, error)
+	VolumeSnapshotRemove(ctx context.Context, volumeID, snapshotID string) error
+	VolumeSnapshotRollback(ctx context.Context, volumeID, snapshotID string) error
+	VolumeUnlock(ctx context.Context, volumeID string, force bool) error
+	VolumesPrune(ctx context.Context, filter filters.Args, dryRun bool) (volumetypes.PruneReport, error)
+}
+
+// ContainerLogsOptions is a wrapper around types.ContainerLogsOptions that
+// also holds the filters of the logs.
+type ContainerLogsOptions struct {
+	types.ContainerLogsOptions
+	Until string
+	Grep  string
+}
+
+// apiClient sends the requests of the endpoints missing from the engine-api
+// client with the same host, version, transport and headers.
+type apiClient struct {
+	*client.Client
+	// proto holds the client protocol i.e. unix.
+	proto string
+	// addr holds the client address.
+	addr string
+	// basePath holds the path to prepend to the requests.
+	basePath string
+	// transport is the interface to send request with.
+	transport transport.Client
+	// custom http headers configured by users.
+	customHTTPHeaders map[string]string
+}
+
+func newAPIClient(host string, version string, httpClient *http.Client, httpHeaders map[string]string) (*apiClient, error) {
+	proto, addr, basePath, err := client.ParseHost(host)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient == nil {
+		// share the connections of the engine-api client
+		tr := new(http.Transport)
+		sockets.ConfigureTransport(tr, proto, addr)
+		httpClient = &http.Client{
+			Transport: tr,
+		}
+	}
+
+	engineClient, err := client.NewClient(host, version, httpClient, httpHeaders)
+	if err != nil {
+		return nil, err
+	}
+	apiTransport, err := transport.NewTransportWithHTTP(proto, addr, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	return &apiClient{
+		Client:            engineClient,
+		proto:             proto,
+		addr:              addr,
+		basePath:          basePath,
+		transport:         apiTransport,
+		customHTTPHeaders: httpHeaders,
+	}, nil
+}
+
+// ContainerLogsWithFilters returns the logs generated by a container in an
+// io.ReadCloser, filtered by the daemon. It's up to the caller to close the
+// stream.
+func (cli *apiClient) ContainerLogsWithFilters(ctx context.Context, container string, options ContainerLogsOptions) (io.ReadCloser, error) {
+	query := url.Values{}
+	if options.ShowStdout {
+		query.Set("stdout", "1")
+	}
+
+	if options.ShowStderr {
+		query.Set("stderr", "1")
+	}
+
+	now := time.Now()
+	if options.Since != "" {
+		ts, err := timetypes.GetTimestamp(options.Since, now)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("since", ts)
+	}
+
+	if options.Until != "" {
+		ts, err := timetypes.GetTimestamp(options.Until, now)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("until", ts)
+	}
+
+	if options.Grep != "" {
+		query.Set("grep", options.Grep)
+	}
+
+	if options.Timestamps {
+		query.Set("timestamps", "1")
+	}
+
+	if options.Details {
+		query.Set("details", "1")
+	}
+
+	if options.Follow {
+		query.Set("follow", "1")
+	}
+	query.Set("tail", options.Tail)
+
+	return cli.sendRequest(ctx, "GET", "/containers/"+container+"/logs", query, nil)
+}
+
+// VolumeCreateFromSnapshot creates a volume in the docker host from the
+// snapshot of another volume.
+func (cli *apiClient) VolumeCreateFromSnapshot(ctx context.Context, options volumetypes.CreateRequest) (types.Volume, error) {
+	var volume types.Volume
+	body, err := cli.sendRequest(ctx, "POST", "/volumes/create", nil, options)
+	if err != nil {
+		return volume, err
+	}
+	err = json.NewDecoder(body).Decode(&volume)
+	ensureBodyClosed(body)
+	return volume, err
+}
+
+// VolumeExport retrieves the contents of a volume in the docker host as a
+// tar archive, gzip compressed if compress is set. It's up to the caller to
+// store the archive and close the stream.
+func (cli *apiClient) VolumeExport(ctx context.Context, volumeID string, compress bool) (io.ReadCloser, error) {
+	query := url.Values{}
+	if compress {
+		query.Set("compress", "1")
+	}
+	return cli.sendRequest(ctx, "GET", "/volumes/"+volumeID+"/export", query, nil)
+}
+
+// VolumeImport creates a volume in the docker host with the contents of the
+// tar archive read from source.
+func (cli *apiClient) VolumeImport(ctx context.Context, source io.Reader, options volumetypes.ImportOptions) (types.Volume, error) {
+	var volume types.Volume
+	query := url.Values{}
+	query.Set("name", options.Name)
+	query.Set("driver", options.Driver)
+	if len(options.DriverOpts) > 0 {
+		opts, err := json.Marshal(options.DriverOpts)
+		if err != nil {
+			return volume, err
+		}
+		query.Set("driverOpts", string(opts))
+	}
+	if len(options.Labels) > 0 {
+		labels, err := json.Marshal(options.Labels)
+		if err != nil {
+			return volume, err
+		}
+		query.Set("labels", string(labels))
+	}
+
+	body, err := cli.sendClientRequest(ctx, "POST", "/volumes/import", query, source, nil)
+	if err != nil {
+		return volume, err
+	}
+	err = json.NewDecoder(body).Decode(&volume)
+	ensureBodyClosed(body)
+	return volume, err
+}
+
+// VolumeListWithSize returns the volumes configured in the docker host,
+// with the space used by those whose driver can report it.
+func (cli *apiClient) VolumeListWithSize(ctx context.Context, filter filters.Args) (volumetypes.ListResponse, error) {
+	var volumes volumetypes.ListResponse
+	query := url.Values{}
+	query.Set("size", "1")
+	if filter.Len() > 0 {
+		filterJSON, err := filters.ToParamWithVersion(cli.ClientVersion(), filter)
+		if err != nil {
+			return volumes, err
+		}
+		query.Set("filters", filterJSON)
+	}
+	body, err := cli.sendRequest(ctx, "GET", "/volumes", query, nil)
+	if err != nil {
+		return volumes, err
+	}
+	err = json.NewDecoder(body).Decode(&volumes)
+	ensureBodyClosed(body)
+	return volumes, err
+}
+
+// VolumeResize changes the size of a volume in the docker host.
+func (cli *apiClient) VolumeResize(ctx context.Context, volumeID string, options volumetypes.ResizeRequest) error {
+	body, err := cli.sendRequest(ctx, "POST", "/volumes/"+volumeID+"/resize", nil, options)
+	ensureBodyClosed(body)
+	return err
+}
+
+// VolumeRotateKey replaces the encryption key of a volume in the docker host.
+func (cli *apiClient) VolumeRotateKey(ctx context.Context, volumeID string) error {
+	body, err := cli.sendRequest(ctx, "POST", "/volumes/"+volumeID+"/rotate-key", nil, nil)
+	ensureBodyClosed(body)
+	return err
+}
+
+// VolumeSnapshotCreate takes a snapshot of a volume in the docker host.
+func (cli *apiClient) VolumeSnapshotCreate(ctx context.Context, volumeID string, options volumetypes.SnapshotCreateRequest) (volumetypes.Snapshot, error) {
+	var snapshot volumetypes.Snapshot
+	body, err := cli.sendRequest(ctx, "POST", "/volumes/"+volumeID+"/snapshots", nil, options)
+	if err != nil {
+		return snapshot, err
+	}
+	err = json.NewDecoder(body).Decode(&snapshot)
+	ensureBodyClosed(body)
+	return snapshot, err
+}
+
+// VolumeSnapshotList returns the snapshots of a volume in the docker host.
+func (cli *apiClient) VolumeSnapshotList(ctx context.Context, volumeID string) ([]volumetypes.Snapshot, error) {
+	var snapshots []volumetypes.Snapshot
+	body, err := cli.sendRequest(ctx, "GET", "/volumes/"+volumeID+"/snapshots", nil, nil)
+	if err != nil {
+		return snapshots, err
+	}
+	err = json.NewDecoder(body).Decode(&snapshots)
+	ensureBodyClosed(body)
+	return snapshots, err
+}
+
+// VolumeSnapshotRemove removes a snapshot of a volume from the docker host.
+func (cli *apiClient) VolumeSnapshotRemove(ctx context.Context, volumeID, snapshotID string) error {
+	body, err := cli.sendRequest(ctx, "DELETE", "/volumes/"+volumeID+"/snapshots/"+snapshotID, nil, nil)
+	ensureBodyClosed(body)
+	return err
+}
+
+// VolumeSnapshotRollback reverts a volume in the docker host to one of its
+// snapshots.
+func (cli *apiClient) VolumeSnapshotRollback(ctx context.Context, volumeID, snapshotID string) error {
+	body, err := cli.sendRequest(ctx, "POST", "/volumes/"+volumeID+"/snapshots/"+snapshotID+"/rollback", nil, nil)
+	ensureBodyClosed(body)
+	return err
+}
+
+// VolumeUnlock breaks the lock held on a volume in the docker host by the
+// host that mounted it. Locks that are still in use are only broken if
+// force is set.
+func (cli *apiClient) VolumeUnlock(ctx context.Context, volumeID string, force bool) error {
+	query := url.Values{}
+	if force {
+		query.Set("force", "1")
+	}
+	body, err := cli.sendRequest(ctx, "POST", "/volumes/"+volumeID+"/unlock", query, nil)
+	ensureBodyClosed(body)
+	return err
+}
+
+// VolumesPrune removes the volumes that are not used by any container and
+// match the filter. If dryRun is set, the volumes that would be removed are
+// reported, and left alone.
+func (cli *apiClient) VolumesPrune(ctx context.Context, filter filters.Args, dryRun bool) (volumetypes.PruneReport, error) {
+	var report volumetypes.PruneReport
+	query := url.Values{}
+	if filter.Len() > 0 {
+		filterJSON, err := filters.ToParamWithVersion(cli.ClientVersion(), filter)
+		if err != nil {
+			return report, err
+		}
+		query.Set("filters", filterJSON)
+	}
+	if dryRun {
+		query.Set("dry-run", "1")
+	}
+	body, err := cli.sendRequest(ctx, "POST", "/volumes/prune", query, nil)
+	if err != nil {
+		return report, err
+	}
+	err = json.NewDecoder(body).Decode(&report)
+	ensureBodyClosed(body)
+	return report, err
+}
+
+// sendRequest sends a request with the JSON encoding of obj, if not nil, as
+// its body, and returns the body of the response. The caller closes it.
+func (cli *apiClient) sendRequest(ctx context.Context, method, path string, query url.Values, obj interface{}) (io.ReadCloser, error) {
+	var (
+		body    io.Reader
+		headers map[string][]string
+	)
+	if obj != nil {
+		buf := bytes.NewBuffer(nil)
+		if err := json.NewEncoder(buf).Encode(obj); err != nil {
+			return nil, err
+		}
+		body = buf
+		headers = map[string][]string{"Content-Type": {"application/json"}}
+	}
+	return cli.sendClientRequest(ctx, method, path, query, body, headers)
+}
+
+// sendClientRequest sends a request with the given body, and returns the
+// body of the response. The caller closes it. Error responses are returned
+// as errors, like the engine-api client does.
+func (cli *apiClient) sendClientRequest(ctx context.Context, method, path string, query url.Values, body io.Reader, headers map[string][]string) (io.ReadCloser, error) {
+	expectedPayload := (method == "POST" || method == "PUT")
+	if expectedPayload && body == nil {
+		body = bytes.NewReader([]byte{})
+	}
+
+	req, err := http.NewRequest(method, cli.getAPIPath(path, query), body)
+	if err != nil {
+		return nil, err
+	}
+	// Add CLI Config's HTTP Headers BEFORE we set the Docker headers
+	// then the user can't change OUR headers
+	for k, v := range cli.customHTTPHeaders {
+		req.Header.Set(k, v)
+	}
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	if cli.proto == "unix" || cli.proto == "npipe" {
+		// For local communications, it doesn't matter what the host is. We just
+		// need a valid and meaningful host name. (See #189)
+		req.Host = "docker"
+	}
+	req.URL.Host = cli.addr
+	req.URL.Scheme = cli.transport.Scheme()
+	if expectedPayload && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "text/plain")
+	}
+
+	resp, err := cancellable.Do(ctx, cli.transport, req)
+	if err != nil {
+		if strings.Contains(err.Error(), "connection refused") || strings.Contains(err.Error(), "dial unix") {
+			return nil, client.ErrConnectionFailed
+		}
+		return nil, fmt.Errorf("An error occurred trying to connect: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(body) == 0 {
+			return nil, fmt.Errorf("Error: request returned %s for API route and version %s, check if the server supports the requested API version", http.StatusText(resp.StatusCode), req.URL)
+		}
+
+		var errorMessage string
+		version := cli.ClientVersion()
+		if (version == "" || versions.GreaterThan(version, "1.23")) &&
+			resp.Header.Get("Content-Type") == "application/json" {
+			var errorResponse types.ErrorResponse
+			if err := json.Unmarshal(body, &errorResponse); err != nil {
+				return nil, fmt.Errorf("Error reading JSON: %v", err)
+			}
+			errorMessage = errorResponse.Message
+		} else {
+			errorMessage = string(body)
+		}
+		return nil, fmt.Errorf("Error response from daemon: %s", strings.TrimSpace(errorMessage))
+	}
+	return resp.Body, nil
+}
+
+// getAPIPath returns the versioned request path to call the api.
+// It appends the query parameters to the path if they are not empty.
+func (cli *apiClient) getAPIPath(p string, query url.Values) string {
+	apiPath := cli.basePath + p
+	if version := cli.ClientVersion(); version != "" {
+		apiPath = fmt.Sprintf("%s/v%s%s", cli.basePath, strings.TrimPrefix(version, "v"), p)
+	}
+	u := &url.URL{
+		Path: apiPath,
+	}
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+func ensureBodyClosed(body io.ReadCloser) {
+	if body != nil {
+		// Drain up to 512 bytes and close the body to let the Transport reuse the connection
+		io.CopyN(ioutil.Discard, body, 512)
+		body.Close()
+	}
+}
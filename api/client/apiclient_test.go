@@ -0,0 +1,255 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	volumetypes "github.com/docker/docker/api/types/volume"
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/filters"
+	"golang.org/x/net/context"
+)
+
+func newTestAPIClient(t *testing.T, handler http.HandlerFunc) (*apiClient, func()) {
+	server := httptest.NewServer(handler)
+	cli, err := newAPIClient("tcp://"+strings.TrimPrefix(server.URL, "http://"), "1.24", nil, map[string]string{"User-Agent": "test"})
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return cli, server.Close
+}
+
+func TestAPIClientContainerLogsWithFilters(t *testing.T) {
+	cli, closeServer := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/v1.24/containers/web/logs" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("stdout") != "1" || query.Get("stderr") != "" || query.Get("tail") != "10" {
+			t.Errorf("unexpected query %v", query)
+		}
+		if until := query.Get("until"); until != "1476664800" {
+			t.Errorf("expected the until timestamp, got %q", until)
+		}
+		if grep := query.Get("grep"); grep != "^error" {
+			t.Errorf("expected the grep pattern, got %q", grep)
+		}
+		w.Write([]byte("logs"))
+	})
+	defer closeServer()
+
+	body, err := cli.ContainerLogsWithFilters(context.Background(), "web", ContainerLogsOptions{
+		ContainerLogsOptions: types.ContainerLogsOptions{
+			ShowStdout: true,
+			Tail:       "10",
+		},
+		Until: "1476664800",
+		Grep:  "^error",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	logs, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(logs) != "logs" {
+		t.Fatalf("expected the logs, got %q", logs)
+	}
+}
+
+func TestAPIClientVolumeRotateKey(t *testing.T) {
+	cli, closeServer := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1.24/volumes/vol/rotate-key" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if ua := r.Header.Get("User-Agent"); ua != "test" {
+			t.Errorf("expected the custom headers to be sent, got User-Agent %q", ua)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer closeServer()
+
+	if err := cli.VolumeRotateKey(context.Background(), "vol"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAPIClientVolumeImport(t *testing.T) {
+	cli, closeServer := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1.24/volumes/import" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("name") != "vol" || query.Get("driver") != "ceph" || query.Get("driverOpts") != `{"size":"10G"}` {
+			t.Errorf("unexpected query %v", query)
+		}
+		archive, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if string(archive) != "archive" {
+			t.Errorf("expected the archive to be sent, got %q", archive)
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(types.Volume{Name: "vol", Driver: "ceph"})
+	})
+	defer closeServer()
+
+	vol, err := cli.VolumeImport(context.Background(), strings.NewReader("archive"), volumetypes.ImportOptions{
+		Name:       "vol",
+		Driver:     "ceph",
+		DriverOpts: map[string]string{"size": "10G"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vol.Name != "vol" {
+		t.Fatalf("expected volume vol, got %s", vol.Name)
+	}
+}
+
+func TestAPIClientVolumeListWithSize(t *testing.T) {
+	cli, closeServer := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/v1.24/volumes" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if size := r.URL.Query().Get("size"); size != "1" {
+			t.Errorf("expected size=1, got %q", size)
+		}
+		w.Write([]byte(`{"Volumes":[{"Name":"vol","Driver":"ceph","UsageData":{"Size":1024,"Capacity":4096}},{"Name":"other","Driver":"nfs"}]}`))
+	})
+	defer closeServer()
+
+	resp, err := cli.VolumeListWithSize(context.Background(), filters.NewArgs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Volumes) != 2 {
+		t.Fatalf("expected 2 volumes, got %d", len(resp.Volumes))
+	}
+	if v := resp.Volumes[0]; v.Name != "vol" || v.Driver != "ceph" || v.UsageData == nil || v.UsageData.Size != 1024 || v.UsageData.Capacity != 4096 {
+		t.Fatalf("unexpected volume %+v", v)
+	}
+	if v := resp.Volumes[1]; v.Name != "other" || v.UsageData != nil {
+		t.Fatalf("unexpected volume %+v", v)
+	}
+}
+
+func TestAPIClientVolumeResize(t *testing.T) {
+	cli, closeServer := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1.24/volumes/vol/resize" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected a JSON request, got %q", ct)
+		}
+		var req volumetypes.ResizeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if req.Size != 1<<30 || !req.Force {
+			t.Errorf("unexpected request %+v", req)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer closeServer()
+
+	if err := cli.VolumeResize(context.Background(), "vol", volumetypes.ResizeRequest{Size: 1 << 30, Force: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAPIClientVolumeCreateFromSnapshot(t *testing.T) {
+	cli, closeServer := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1.24/volumes/create" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		// the fields of the request are those of engine-api, and FromSnapshot
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if req["Name"] != "clone" || req["Driver"] != "ceph" || req["FromSnapshot"] != "vol@nightly" {
+			t.Errorf("unexpected request %v", req)
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(types.Volume{Name: "clone", Driver: "ceph"})
+	})
+	defer closeServer()
+
+	vol, err := cli.VolumeCreateFromSnapshot(context.Background(), volumetypes.CreateRequest{
+		VolumeCreateRequest: types.VolumeCreateRequest{Name: "clone", Driver: "ceph"},
+		FromSnapshot:        "vol@nightly",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vol.Name != "clone" {
+		t.Fatalf("expected volume clone, got %s", vol.Name)
+	}
+}
+
+func TestAPIClientVolumeUnlock(t *testing.T) {
+	cli, closeServer := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1.24/volumes/vol/unlock" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if force := r.URL.Query().Get("force"); force != "1" {
+			t.Errorf("expected force=1, got %q", force)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer closeServer()
+
+	if err := cli.VolumeUnlock(context.Background(), "vol", true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAPIClientVolumesPrune(t *testing.T) {
+	cli, closeServer := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1.24/volumes/prune" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		query := r.URL.Query()
+		if dryRun := query.Get("dry-run"); dryRun != "1" {
+			t.Errorf("expected dry-run=1, got %q", dryRun)
+		}
+		if f := query.Get("filters"); f != `{"driver":{"ceph":true}}` {
+			t.Errorf("unexpected filters %q", f)
+		}
+		w.Write([]byte(`{"VolumesDeleted":["vol"],"SpaceReclaimed":1024}`))
+	})
+	defer closeServer()
+
+	pruneFilters := filters.NewArgs()
+	pruneFilters.Add("driver", "ceph")
+	report, err := cli.VolumesPrune(context.Background(), pruneFilters, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.VolumesDeleted) != 1 || report.VolumesDeleted[0] != "vol" || report.SpaceReclaimed != 1024 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestAPIClientErrorResponse(t *testing.T) {
+	cli, closeServer := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"no such volume: vol"}`))
+	})
+	defer closeServer()
+
+	err := cli.VolumeRotateKey(context.Background(), "vol")
+	if err == nil || err.Error() != "Error response from daemon: no such volume: vol" {
+		t.Fatalf("expected the error of the daemon, got %v", err)
+	}
+}
@@ -46,7 +46,7 @@ type DockerCli struct {
 	// isTerminalOut indicates whether the client's STDOUT is a TTY
 	isTerminalOut bool
 	// client is the http client that performs all API operations
-	client client.APIClient
+	client APIClient
 	// state holds the terminal input state
 	inState *term.State
 	// outState holds the terminal output state
@@ -63,7 +63,7 @@ func (cli *DockerCli) Initialize() error {
 }
 
 // Client returns the APIClient
-func (cli *DockerCli) Client() client.APIClient {
+func (cli *DockerCli) Client() APIClient {
 	return cli.client
 }
 
@@ -212,10 +212,10 @@ func LoadDefaultConfigFile(err io.Writer) *configfile.ConfigFile {
 }
 
 // NewAPIClientFromFlags creates a new APIClient from command line flags
-func NewAPIClientFromFlags(clientFlags *cliflags.ClientFlags, configFile *configfile.ConfigFile) (client.APIClient, error) {
+func NewAPIClientFromFlags(clientFlags *cliflags.ClientFlags, configFile *configfile.ConfigFile) (APIClient, error) {
 	host, err := getServerHost(clientFlags.Common.Hosts, clientFlags.Common.TLSOptions)
 	if err != nil {
-		return &client.Client{}, err
+		return nil, err
 	}
 
 	customHeaders := configFile.HTTPHeaders
@@ -231,10 +231,10 @@ func NewAPIClientFromFlags(clientFlags *cliflags.ClientFlags, configFile *config
 
 	httpClient, err := newHTTPClient(host, clientFlags.Common.TLSOptions)
 	if err != nil {
-		return &client.Client{}, err
+		return nil, err
 	}
 
-	return client.NewClient(host, verStr, httpClient, customHeaders)
+	return newAPIClient(host, verStr, httpClient, customHeaders)
 }
 
 func getServerHost(hosts []string, tlsOptions *tlsconfig.Options) (host string, err error) {
@@ -13,17 +13,15 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var validDrivers = map[string]bool{
-	"json-file": true,
-	"journald":  true,
-}
-
 type logsOptions struct {
 	follow     bool
 	since      string
+	until      string
 	timestamps bool
 	details    bool
 	tail       string
+	grep       string
+	stream     string
 
 	container string
 }
@@ -45,34 +43,48 @@ func NewLogsCommand(dockerCli *client.DockerCli) *cobra.Command {
 	flags := cmd.Flags()
 	flags.BoolVarP(&opts.follow, "follow", "f", false, "Follow log output")
 	flags.StringVar(&opts.since, "since", "", "Show logs since timestamp")
+	flags.StringVar(&opts.until, "until", "", "Show logs before timestamp")
 	flags.BoolVarP(&opts.timestamps, "timestamps", "t", false, "Show timestamps")
 	flags.BoolVar(&opts.details, "details", false, "Show extra details provided to logs")
 	flags.StringVar(&opts.tail, "tail", "all", "Number of lines to show from the end of the logs")
+	flags.StringVar(&opts.grep, "grep", "", "Only show lines matching a regular expression")
+	flags.StringVar(&opts.stream, "stream", "", "Only show lines of a stream (stdout or stderr)")
 	return cmd
 }
 
 func runLogs(dockerCli *client.DockerCli, opts *logsOptions) error {
 	ctx := context.Background()
 
+	showStdout, showStderr := true, true
+	switch opts.stream {
+	case "":
+	case "stdout":
+		showStderr = false
+	case "stderr":
+		showStdout = false
+	default:
+		return fmt.Errorf("invalid stream %q: must be stdout or stderr", opts.stream)
+	}
+
 	c, err := dockerCli.Client().ContainerInspect(ctx, opts.container)
 	if err != nil {
 		return err
 	}
 
-	if !validDrivers[c.HostConfig.LogConfig.Type] {
-		return fmt.Errorf("\"logs\" command is supported only for \"json-file\" and \"journald\" logging drivers (got: %s)", c.HostConfig.LogConfig.Type)
-	}
-
-	options := types.ContainerLogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
-		Since:      opts.since,
-		Timestamps: opts.timestamps,
-		Follow:     opts.follow,
-		Tail:       opts.tail,
-		Details:    opts.details,
+	options := client.ContainerLogsOptions{
+		ContainerLogsOptions: types.ContainerLogsOptions{
+			ShowStdout: showStdout,
+			ShowStderr: showStderr,
+			Since:      opts.since,
+			Timestamps: opts.timestamps,
+			Follow:     opts.follow,
+			Tail:       opts.tail,
+			Details:    opts.details,
+		},
+		Until: opts.until,
+		Grep:  opts.grep,
 	}
-	responseBody, err := dockerCli.Client().ContainerLogs(ctx, opts.container, options)
+	responseBody, err := dockerCli.Client().ContainerLogsWithFilters(ctx, opts.container, options)
 	if err != nil {
 		return err
 	}
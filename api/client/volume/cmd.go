@@ -22,9 +22,17 @@ func NewVolumeCommand(dockerCli *client.DockerCli) *cobra.Command {
 	}
 	cmd.AddCommand(
 		newCreateCommand(dockerCli),
+		newDfCommand(dockerCli),
+		newExportCommand(dockerCli),
+		newImportCommand(dockerCli),
 		newInspectCommand(dockerCli),
 		newListCommand(dockerCli),
+		newPruneCommand(dockerCli),
 		newRemoveCommand(dockerCli),
+		newResizeCommand(dockerCli),
+		newRotateKeyCommand(dockerCli),
+		newSnapshotCommand(dockerCli),
+		newUnlockCommand(dockerCli),
 	)
 	return cmd
 }
@@ -6,6 +6,7 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/docker/docker/api/client"
+	volumetypes "github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/cli"
 	"github.com/docker/docker/opts"
 	runconfigopts "github.com/docker/docker/runconfig/opts"
@@ -14,10 +15,11 @@ import (
 )
 
 type createOptions struct {
-	name       string
-	driver     string
-	driverOpts opts.MapOpts
-	labels     []string
+	name         string
+	driver       string
+	driverOpts   opts.MapOpts
+	labels       []string
+	fromSnapshot string
 }
 
 func newCreateCommand(dockerCli *client.DockerCli) *cobra.Command {
@@ -31,6 +33,10 @@ func newCreateCommand(dockerCli *client.DockerCli) *cobra.Command {
 		Long:  createDescription,
 		Args:  cli.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.fromSnapshot != "" && !cmd.Flags().Changed("driver") {
+				// the volume is created by the driver of the snapshot
+				opts.driver = ""
+			}
 			return runCreate(dockerCli, opts)
 		},
 	}
@@ -39,6 +45,7 @@ func newCreateCommand(dockerCli *client.DockerCli) *cobra.Command {
 	flags.StringVar(&opts.name, "name", "", "Specify volume name")
 	flags.VarP(&opts.driverOpts, "opt", "o", "Set driver specific options")
 	flags.StringSliceVar(&opts.labels, "label", []string{}, "Set metadata for a volume")
+	flags.StringVar(&opts.fromSnapshot, "from-snapshot", "", "Create the volume from a snapshot (VOLUME@SNAPSHOT)")
 
 	return cmd
 }
@@ -53,7 +60,18 @@ func runCreate(dockerCli *client.DockerCli, opts createOptions) error {
 		Labels:     runconfigopts.ConvertKVStringsToMap(opts.labels),
 	}
 
-	vol, err := client.VolumeCreate(context.Background(), volReq)
+	var (
+		vol types.Volume
+		err error
+	)
+	if opts.fromSnapshot != "" {
+		vol, err = client.VolumeCreateFromSnapshot(context.Background(), volumetypes.CreateRequest{
+			VolumeCreateRequest: volReq,
+			FromSnapshot:        opts.fromSnapshot,
+		})
+	} else {
+		vol, err = client.VolumeCreate(context.Background(), volReq)
+	}
 	if err != nil {
 		return err
 	}
@@ -99,4 +117,11 @@ Another example:
 
     $ docker volume create --driver local --opt type=btrfs --opt device=/dev/sda2
 
+## Creating a volume from a snapshot
+
+Drivers that support snapshots can create a volume from a snapshot of one of
+their volumes, given as **volume@snapshot**:
+
+    $ docker volume create --from-snapshot data@nightly --name data-copy
+
 `
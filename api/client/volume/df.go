@@ -0,0 +1,174 @@
+package volume
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/client"
+	volumetypes "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/cli"
+	"github.com/docker/engine-api/types/filters"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+// volumeLess orders volumes by one of the columns of `docker volume df`.
+// Sizes sort largest first, volumes without usage last, and ties by name.
+var volumeLess = map[string]func(a, b *volumetypes.Volume) bool{
+	"name": func(a, b *volumetypes.Volume) bool {
+		return a.Name < b.Name
+	},
+	"driver": func(a, b *volumetypes.Volume) bool {
+		if a.Driver != b.Driver {
+			return a.Driver < b.Driver
+		}
+		return a.Name < b.Name
+	},
+	"size": usageLess(func(u *volumetypes.UsageData) float64 {
+		return float64(u.Size)
+	}),
+	"capacity": usageLess(func(u *volumetypes.UsageData) float64 {
+		return float64(u.Capacity)
+	}),
+	"use": usageLess(usePercent),
+	"inodes": usageLess(func(u *volumetypes.UsageData) float64 {
+		return float64(u.Inodes)
+	}),
+}
+
+func usageLess(value func(*volumetypes.UsageData) float64) func(a, b *volumetypes.Volume) bool {
+	return func(a, b *volumetypes.Volume) bool {
+		switch {
+		case a.UsageData == nil && b.UsageData == nil:
+			return a.Name < b.Name
+		case a.UsageData == nil || b.UsageData == nil:
+			return b.UsageData == nil
+		}
+		if va, vb := value(a.UsageData), value(b.UsageData); va != vb {
+			return va > vb
+		}
+		return a.Name < b.Name
+	}
+}
+
+// usePercent returns the percentage of the capacity of a volume it uses,
+// or -1 if the capacity is unknown.
+func usePercent(u *volumetypes.UsageData) float64 {
+	if u.Capacity <= 0 {
+		return -1
+	}
+	return float64(u.Size) * 100 / float64(u.Capacity)
+}
+
+type volumesBy struct {
+	volumes []*volumetypes.Volume
+	less    func(a, b *volumetypes.Volume) bool
+}
+
+func (v volumesBy) Len() int           { return len(v.volumes) }
+func (v volumesBy) Swap(i, j int)      { v.volumes[i], v.volumes[j] = v.volumes[j], v.volumes[i] }
+func (v volumesBy) Less(i, j int) bool { return v.less(v.volumes[i], v.volumes[j]) }
+
+type dfOptions struct {
+	sort    string
+	reverse bool
+	filter  []string
+}
+
+func newDfCommand(dockerCli *client.DockerCli) *cobra.Command {
+	var opts dfOptions
+
+	cmd := &cobra.Command{
+		Use:     "df [OPTIONS]",
+		Short:   "Show the space used by volumes",
+		Long:    dfDescription,
+		Example: dfExample,
+		Args:    cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDf(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.sort, "sort", "size", "Sort by name, driver, size, capacity, use or inodes")
+	flags.BoolVarP(&opts.reverse, "reverse", "r", false, "Reverse the sort order")
+	flags.StringSliceVarP(&opts.filter, "filter", "f", []string{}, "Provide filter values (i.e. 'driver=ceph')")
+
+	return cmd
+}
+
+func runDf(dockerCli *client.DockerCli, opts dfOptions) error {
+	less, ok := volumeLess[opts.sort]
+	if !ok {
+		keys := make([]string, 0, len(volumeLess))
+		for key := range volumeLess {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return fmt.Errorf("invalid sort key %q, expected one of %s", opts.sort, strings.Join(keys, ", "))
+	}
+
+	volFilterArgs := filters.NewArgs()
+	for _, f := range opts.filter {
+		var err error
+		volFilterArgs, err = filters.ParseFlag(f, volFilterArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	client := dockerCli.Client()
+	volumes, err := client.VolumeListWithSize(context.Background(), volFilterArgs)
+	if err != nil {
+		return err
+	}
+	for _, warn := range volumes.Warnings {
+		fmt.Fprintln(dockerCli.Err(), warn)
+	}
+
+	by := sort.Interface(volumesBy{volumes: volumes.Volumes, less: less})
+	if opts.reverse {
+		by = sort.Reverse(by)
+	}
+	sort.Sort(by)
+
+	w := tabwriter.NewWriter(dockerCli.Out(), 20, 1, 3, ' ', 0)
+	fmt.Fprintf(w, "VOLUME NAME\tDRIVER\tSIZE\tCAPACITY\tUSE%%\tINODES\n")
+	for _, vol := range volumes.Volumes {
+		size, capacity, use, inodes := "N/A", "N/A", "N/A", "N/A"
+		if u := vol.UsageData; u != nil {
+			size = units.HumanSize(float64(u.Size))
+			if u.Capacity > 0 {
+				capacity = units.HumanSize(float64(u.Capacity))
+				use = strconv.Itoa(int(usePercent(u)+0.5)) + "%"
+			}
+			if u.Inodes > 0 {
+				inodes = strconv.FormatInt(u.Inodes, 10)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", vol.Name, vol.Driver, size, capacity, use, inodes)
+	}
+	w.Flush()
+	return nil
+}
+
+var dfDescription = `
+Show the space used by volumes, the capacity of the storage they are on, and
+the number of inodes they use. Volumes whose driver can't report their usage,
+or can only report it while they are mounted, show N/A.
+
+Volumes are sorted by size, largest first, unless ` + "`--sort`" + ` is
+given. They can be filtered with the filters of ` + "`docker volume ls`" + `.
+`
+
+var dfExample = `
+$ docker volume df --filter driver=local
+VOLUME NAME   DRIVER   SIZE      CAPACITY   USE%   INODES
+pgdata        local    2.13 GB   105.6 GB   2%     1873
+cache         local    52.4 MB   105.6 GB   0%     212
+`
@@ -0,0 +1,71 @@
+package volume
+
+import (
+	"errors"
+	"io"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/client"
+	"github.com/docker/docker/cli"
+	"github.com/spf13/cobra"
+)
+
+type exportOptions struct {
+	name     string
+	output   string
+	compress bool
+}
+
+func newExportCommand(dockerCli *client.DockerCli) *cobra.Command {
+	var opts exportOptions
+
+	cmd := &cobra.Command{
+		Use:     "export [OPTIONS] VOLUME",
+		Short:   "Export the contents of a volume as a tar archive (streamed to STDOUT by default)",
+		Long:    exportDescription,
+		Example: exportExample,
+		Args:    cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.name = args[0]
+			return runExport(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.output, "output", "o", "", "Write to a file, instead of STDOUT")
+	flags.BoolVarP(&opts.compress, "compress", "z", false, "Compress the archive with gzip")
+
+	return cmd
+}
+
+func runExport(dockerCli *client.DockerCli, opts exportOptions) error {
+	if opts.output == "" && dockerCli.IsTerminalOut() {
+		return errors.New("Cowardly refusing to export to a terminal. Use the -o flag or redirect.")
+	}
+
+	responseBody, err := dockerCli.Client().VolumeExport(context.Background(), opts.name, opts.compress)
+	if err != nil {
+		return err
+	}
+	defer responseBody.Close()
+
+	if opts.output == "" {
+		_, err := io.Copy(dockerCli.Out(), responseBody)
+		return err
+	}
+
+	return client.CopyToFile(opts.output, responseBody)
+}
+
+var exportDescription = `
+Export the contents of a volume as a tar archive, with the ownership, extended
+attributes and hard links of its files. The volume is mounted on the daemon's
+host while it is exported. Import the archive with ` + "`docker volume import`" + `.
+`
+
+var exportExample = `
+$ docker volume export --compress -o pgdata.tar.gz pgdata
+$ docker -H tcp://other-host:2375 volume import --name pgdata pgdata.tar.gz
+pgdata
+`
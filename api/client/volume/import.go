@@ -0,0 +1,93 @@
+package volume
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/client"
+	volumetypes "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/cli"
+	"github.com/docker/docker/opts"
+	runconfigopts "github.com/docker/docker/runconfig/opts"
+	"github.com/spf13/cobra"
+)
+
+type importOptions struct {
+	source     string
+	name       string
+	driver     string
+	driverOpts opts.MapOpts
+	labels     []string
+}
+
+func newImportCommand(dockerCli *client.DockerCli) *cobra.Command {
+	opts := importOptions{
+		driverOpts: *opts.NewMapOpts(nil, nil),
+	}
+
+	cmd := &cobra.Command{
+		Use:     "import [OPTIONS] FILE|-",
+		Short:   "Create a volume from a tar archive",
+		Long:    importDescription,
+		Example: importExample,
+		Args:    cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.source = args[0]
+			return runImport(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.driver, "driver", "d", "local", "Specify volume driver name")
+	flags.StringVar(&opts.name, "name", "", "Specify volume name")
+	flags.VarP(&opts.driverOpts, "opt", "o", "Set driver specific options")
+	flags.StringSliceVar(&opts.labels, "label", []string{}, "Set metadata for a volume")
+
+	return cmd
+}
+
+func runImport(dockerCli *client.DockerCli, opts importOptions) error {
+	var in io.Reader = dockerCli.In()
+	if opts.source != "-" {
+		file, err := os.Open(opts.source)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	options := volumetypes.ImportOptions{
+		Name:       opts.name,
+		Driver:     opts.driver,
+		DriverOpts: opts.driverOpts.GetAll(),
+		Labels:     runconfigopts.ConvertKVStringsToMap(opts.labels),
+	}
+
+	vol, err := dockerCli.Client().VolumeImport(context.Background(), in, options)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(dockerCli.Out(), "%s\n", vol.Name)
+	return nil
+}
+
+var importDescription = `
+Create a volume and fill it with the contents of a tar archive, such as one
+written by ` + "`docker volume export`" + `. The archive may be compressed
+with gzip, bzip2 or xz. The ownership, extended attributes and hard links of
+the files are restored. The volume must not exist yet: it is created with the
+given driver and options, and removed again if the archive can't be
+extracted.
+`
+
+var importExample = `
+$ docker volume import --name pgdata --driver ceph -o size=20G pgdata.tar.gz
+pgdata
+$ gunzip -c pgdata.tar.gz | docker volume import --label env=staging -
+ee5f8fe4bd23bbbc4d8e49c0163e2a93c2e5d8b8bca8f9e10c2d8d4d7e5b6a4f
+`
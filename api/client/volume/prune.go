@@ -0,0 +1,116 @@
+package volume
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/client"
+	"github.com/docker/docker/cli"
+	"github.com/docker/engine-api/types/filters"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+type pruneOptions struct {
+	force  bool
+	dryRun bool
+	filter []string
+}
+
+func newPruneCommand(dockerCli *client.DockerCli) *cobra.Command {
+	var opts pruneOptions
+
+	cmd := &cobra.Command{
+		Use:     "prune [OPTIONS]",
+		Short:   "Remove all unused volumes",
+		Long:    pruneDescription,
+		Example: pruneExample,
+		Args:    cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPrune(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&opts.force, "force", "f", false, "Do not prompt for confirmation")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "Only list the volumes that would be removed")
+	flags.StringSliceVar(&opts.filter, "filter", []string{}, "Provide filter values (i.e. 'label=temp', 'driver=local', 'until=24h')")
+
+	return cmd
+}
+
+func runPrune(dockerCli *client.DockerCli, opts pruneOptions) error {
+	pruneFilters := filters.NewArgs()
+	for _, f := range opts.filter {
+		var err error
+		pruneFilters, err = filters.ParseFlag(f, pruneFilters)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !opts.force && !opts.dryRun {
+		fmt.Fprint(dockerCli.Out(), "WARNING! This will remove all volumes not used by at least one container")
+		if pruneFilters.Len() > 0 {
+			fmt.Fprint(dockerCli.Out(), " and matching the filters")
+		}
+		fmt.Fprint(dockerCli.Out(), ".\nAre you sure you want to continue? [y/N] ")
+		reader := bufio.NewReader(dockerCli.In())
+		line, _, err := reader.ReadLine()
+		if err != nil {
+			return err
+		}
+		if strings.ToLower(string(line)) != "y" {
+			return nil
+		}
+	}
+
+	client := dockerCli.Client()
+	report, err := client.VolumesPrune(context.Background(), pruneFilters, opts.dryRun)
+	if err != nil {
+		return err
+	}
+	for _, warn := range report.Warnings {
+		fmt.Fprintln(dockerCli.Err(), warn)
+	}
+
+	if len(report.VolumesDeleted) > 0 {
+		if opts.dryRun {
+			fmt.Fprintln(dockerCli.Out(), "Would delete volumes:")
+		} else {
+			fmt.Fprintln(dockerCli.Out(), "Deleted volumes:")
+		}
+		for _, name := range report.VolumesDeleted {
+			fmt.Fprintln(dockerCli.Out(), name)
+		}
+		fmt.Fprintln(dockerCli.Out())
+	}
+	if opts.dryRun {
+		fmt.Fprintf(dockerCli.Out(), "Reclaimable space: %s\n", units.HumanSize(float64(report.SpaceReclaimed)))
+	} else {
+		fmt.Fprintf(dockerCli.Out(), "Total reclaimed space: %s\n", units.HumanSize(float64(report.SpaceReclaimed)))
+	}
+	if len(report.Warnings) > 0 {
+		return cli.StatusError{StatusCode: 1}
+	}
+	return nil
+}
+
+var pruneDescription = `
+Remove all the volumes that are not used by any container, running or
+stopped. The volumes can be restricted by label, driver, and age with
+` + "`--filter`" + `. Use ` + "`--dry-run`" + ` to list the volumes that would
+be removed without removing them.
+`
+
+var pruneExample = `
+$ docker volume prune --force --filter driver=local --filter until=24h
+Deleted volumes:
+07c7bdf3e34ab76d921894c2b834f073721fccfbbcba792aa7648e3a7a664c2e
+my-vol
+
+Total reclaimed space: 36.4 MB
+`
@@ -0,0 +1,71 @@
+package volume
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/client"
+	volumetypes "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/cli"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+type resizeOptions struct {
+	name  string
+	size  string
+	force bool
+}
+
+func newResizeCommand(dockerCli *client.DockerCli) *cobra.Command {
+	var opts resizeOptions
+
+	cmd := &cobra.Command{
+		Use:     "resize [OPTIONS] VOLUME SIZE",
+		Short:   "Change the size of a volume",
+		Long:    resizeDescription,
+		Example: resizeExample,
+		Args:    cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.name = args[0]
+			opts.size = args[1]
+			return runResize(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&opts.force, "force", "f", false, "Allow shrinking the volume, which may destroy data")
+
+	return cmd
+}
+
+func runResize(dockerCli *client.DockerCli, opts resizeOptions) error {
+	size, err := units.RAMInBytes(opts.size)
+	if err != nil {
+		return fmt.Errorf("invalid size %q: %v", opts.size, err)
+	}
+
+	client := dockerCli.Client()
+	req := volumetypes.ResizeRequest{
+		Size:  size,
+		Force: opts.force,
+	}
+	if err := client.VolumeResize(context.Background(), opts.name, req); err != nil {
+		return err
+	}
+	fmt.Fprintf(dockerCli.Out(), "%s\n", opts.name)
+	return nil
+}
+
+var resizeDescription = `
+Change the size of a volume. The size is a number with an optional unit, such
+as ` + "`100G`" + `. Volumes in use are grown live, along with their filesystem.
+Shrinking a volume destroys the data past its new size, so it is only done if
+` + "`--force`" + ` is given, and only by drivers that support it.
+`
+
+var resizeExample = `
+$ docker volume resize data 100G
+data
+`
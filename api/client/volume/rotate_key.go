@@ -0,0 +1,55 @@
+package volume
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/client"
+	"github.com/docker/docker/cli"
+	"github.com/spf13/cobra"
+)
+
+func newRotateKeyCommand(dockerCli *client.DockerCli) *cobra.Command {
+	return &cobra.Command{
+		Use:     "rotate-key VOLUME [VOLUME...]",
+		Short:   "Replace the encryption key of one or more volumes",
+		Long:    rotateKeyDescription,
+		Example: rotateKeyExample,
+		Args:    cli.RequiresMinArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRotateKey(dockerCli, args)
+		},
+	}
+}
+
+func runRotateKey(dockerCli *client.DockerCli, volumes []string) error {
+	client := dockerCli.Client()
+	ctx := context.Background()
+	status := 0
+
+	for _, name := range volumes {
+		if err := client.VolumeRotateKey(ctx, name); err != nil {
+			fmt.Fprintf(dockerCli.Err(), "%s\n", err)
+			status = 1
+			continue
+		}
+		fmt.Fprintf(dockerCli.Out(), "%s\n", name)
+	}
+
+	if status != 0 {
+		return cli.StatusError{StatusCode: status}
+	}
+	return nil
+}
+
+var rotateKeyDescription = `
+Replace the encryption key of one or more volumes with a newly generated key.
+Only volumes of drivers that encrypt their data, such as ` + "`ceph`" + ` volumes created
+with ` + "`--opt encrypted=true`" + `, support this.
+`
+
+var rotateKeyExample = `
+$ docker volume rotate-key secrets
+secrets
+`
@@ -0,0 +1,37 @@
+package volume
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/docker/docker/api/client"
+	"github.com/docker/docker/cli"
+)
+
+// newSnapshotCommand returns a cobra command for `volume snapshot` subcommands
+func newSnapshotCommand(dockerCli *client.DockerCli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot COMMAND",
+		Short: "Manage volume snapshots",
+		Long:  snapshotDescription,
+		Args:  cli.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprint(dockerCli.Err(), "\n"+cmd.UsageString())
+		},
+	}
+	cmd.AddCommand(
+		newSnapshotCreateCommand(dockerCli),
+		newSnapshotListCommand(dockerCli),
+		newSnapshotRemoveCommand(dockerCli),
+		newSnapshotRollbackCommand(dockerCli),
+	)
+	return cmd
+}
+
+var snapshotDescription = `
+The **docker volume snapshot** command has subcommands for managing the
+snapshots of volumes whose driver supports them. A snapshot is a point in time
+copy of a volume that the volume can be rolled back to, and that new volumes
+can be created from with **docker volume create --from-snapshot**.
+`
@@ -0,0 +1,66 @@
+package volume
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/client"
+	volumetypes "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/cli"
+	"github.com/spf13/cobra"
+)
+
+type snapshotCreateOptions struct {
+	volume   string
+	name     string
+	fsfreeze bool
+}
+
+func newSnapshotCreateCommand(dockerCli *client.DockerCli) *cobra.Command {
+	var opts snapshotCreateOptions
+
+	cmd := &cobra.Command{
+		Use:     "create [OPTIONS] VOLUME SNAPSHOT",
+		Short:   "Take a snapshot of a volume",
+		Long:    snapshotCreateDescription,
+		Example: snapshotCreateExample,
+		Args:    cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.volume = args[0]
+			opts.name = args[1]
+			return runSnapshotCreate(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.fsfreeze, "fsfreeze", false, "Freeze the filesystem of a volume in use while the snapshot is taken")
+
+	return cmd
+}
+
+func runSnapshotCreate(dockerCli *client.DockerCli, opts snapshotCreateOptions) error {
+	client := dockerCli.Client()
+	req := volumetypes.SnapshotCreateRequest{
+		Name:   opts.name,
+		Freeze: opts.fsfreeze,
+	}
+	snapshot, err := client.VolumeSnapshotCreate(context.Background(), opts.volume, req)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(dockerCli.Out(), "%s\n", snapshot.Name)
+	return nil
+}
+
+var snapshotCreateDescription = `
+Take a snapshot of a volume. A volume mounted read-write by a running container
+is only snapshotted if ` + "`--fsfreeze`" + ` is given; its filesystem is then
+frozen, suspending writes, while the snapshot is taken so that the snapshot is
+consistent.
+`
+
+var snapshotCreateExample = `
+$ docker volume snapshot create --fsfreeze data nightly
+nightly
+`
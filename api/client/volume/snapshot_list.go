@@ -0,0 +1,66 @@
+package volume
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/client"
+	"github.com/docker/docker/cli"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+type snapshotListOptions struct {
+	volume string
+	quiet  bool
+}
+
+func newSnapshotListCommand(dockerCli *client.DockerCli) *cobra.Command {
+	var opts snapshotListOptions
+
+	cmd := &cobra.Command{
+		Use:     "ls [OPTIONS] VOLUME",
+		Aliases: []string{"list"},
+		Short:   "List the snapshots of a volume",
+		Args:    cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.volume = args[0]
+			return runSnapshotList(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "Only display snapshot names")
+
+	return cmd
+}
+
+func runSnapshotList(dockerCli *client.DockerCli, opts snapshotListOptions) error {
+	client := dockerCli.Client()
+
+	snapshots, err := client.VolumeSnapshotList(context.Background(), opts.volume)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(dockerCli.Out(), 20, 1, 3, ' ', 0)
+	if !opts.quiet {
+		fmt.Fprintf(w, "SNAPSHOT NAME\tSIZE\tCREATED\n")
+	}
+	for _, s := range snapshots {
+		if opts.quiet {
+			fmt.Fprintln(w, s.Name)
+			continue
+		}
+		created := ""
+		if t, err := time.Parse(time.RFC3339, s.Created); err == nil {
+			created = units.HumanDuration(time.Now().UTC().Sub(t)) + " ago"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, units.HumanSize(float64(s.Size)), created)
+	}
+	w.Flush()
+	return nil
+}
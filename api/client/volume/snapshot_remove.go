@@ -0,0 +1,43 @@
+package volume
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/client"
+	"github.com/docker/docker/cli"
+	"github.com/spf13/cobra"
+)
+
+func newSnapshotRemoveCommand(dockerCli *client.DockerCli) *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm VOLUME SNAPSHOT [SNAPSHOT...]",
+		Aliases: []string{"remove"},
+		Short:   "Remove one or more snapshots of a volume",
+		Args:    cli.RequiresMinArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSnapshotRemove(dockerCli, args[0], args[1:])
+		},
+	}
+}
+
+func runSnapshotRemove(dockerCli *client.DockerCli, volume string, snapshots []string) error {
+	client := dockerCli.Client()
+	ctx := context.Background()
+	status := 0
+
+	for _, name := range snapshots {
+		if err := client.VolumeSnapshotRemove(ctx, volume, name); err != nil {
+			fmt.Fprintf(dockerCli.Err(), "%s\n", err)
+			status = 1
+			continue
+		}
+		fmt.Fprintf(dockerCli.Out(), "%s\n", name)
+	}
+
+	if status != 0 {
+		return cli.StatusError{StatusCode: status}
+	}
+	return nil
+}
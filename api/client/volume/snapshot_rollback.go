@@ -0,0 +1,38 @@
+package volume
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/client"
+	"github.com/docker/docker/cli"
+	"github.com/spf13/cobra"
+)
+
+func newSnapshotRollbackCommand(dockerCli *client.DockerCli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rollback VOLUME SNAPSHOT",
+		Short: "Revert a volume to one of its snapshots",
+		Long:  snapshotRollbackDescription,
+		Args:  cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSnapshotRollback(dockerCli, args[0], args[1])
+		},
+	}
+}
+
+func runSnapshotRollback(dockerCli *client.DockerCli, volume, snapshot string) error {
+	client := dockerCli.Client()
+	if err := client.VolumeSnapshotRollback(context.Background(), volume, snapshot); err != nil {
+		return err
+	}
+	fmt.Fprintf(dockerCli.Out(), "%s\n", volume)
+	return nil
+}
+
+var snapshotRollbackDescription = `
+Revert a volume to one of its snapshots. The changes made to the volume since
+the snapshot was taken are lost. A volume can't be rolled back while it is in
+use by a running container.
+`
@@ -0,0 +1,65 @@
+package volume
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/client"
+	"github.com/docker/docker/cli"
+	"github.com/spf13/cobra"
+)
+
+type unlockOptions struct {
+	name  string
+	force bool
+}
+
+func newUnlockCommand(dockerCli *client.DockerCli) *cobra.Command {
+	var opts unlockOptions
+
+	cmd := &cobra.Command{
+		Use:     "unlock [OPTIONS] VOLUME",
+		Short:   "Break the lock held on a volume by another host",
+		Long:    unlockDescription,
+		Example: unlockExample,
+		Args:    cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.name = args[0]
+			return runUnlock(dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&opts.force, "force", "f", false, "Break the lock even if its holder still appears to use the volume")
+
+	return cmd
+}
+
+func runUnlock(dockerCli *client.DockerCli, opts unlockOptions) error {
+	client := dockerCli.Client()
+	if err := client.VolumeUnlock(context.Background(), opts.name, opts.force); err != nil {
+		return err
+	}
+	fmt.Fprintf(dockerCli.Out(), "%s\n", opts.name)
+	return nil
+}
+
+var unlockDescription = `
+Break the lock held on a volume. Drivers for volumes on shared storage, such as
+` + "`ceph`" + `, lock a volume while it is mounted so that no other host
+mounts it at the same time. The lock of a host that died while the volume was
+mounted is left behind, and keeps other hosts from mounting the volume until it
+is broken.
+
+Locks are only broken if their holder no longer uses the volume. Use
+` + "`--force`" + ` to break the lock of a host that still appears to use it;
+that host is fenced off the volume.
+`
+
+var unlockExample = `
+$ docker volume inspect --format '{{ .Status.Lock.Host }}' data
+node-3
+$ docker volume unlock data
+data
+`
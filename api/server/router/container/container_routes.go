@@ -101,6 +101,8 @@ func (s *containerRouter) getContainersLogs(ctx context.Context, w http.Response
 			ShowStderr: stderr,
 			Details:    httputils.BoolValue(r, "details"),
 		},
+		Until:     r.Form.Get("until"),
+		Grep:      r.Form.Get("grep"),
 		OutStream: w,
 	}
 
@@ -1,6 +1,9 @@
 package volume
 
 import (
+	"io"
+
+	volumetypes "github.com/docker/docker/api/types/volume"
 	// TODO return types need to be refactored into pkg
 	"github.com/docker/engine-api/types"
 )
@@ -8,8 +11,19 @@ import (
 // Backend is the methods that need to be implemented to provide
 // volume specific functionality
 type Backend interface {
-	Volumes(filter string) ([]*types.Volume, []string, error)
+	Volumes(filter string, size bool) ([]*volumetypes.Volume, []string, error)
 	VolumeInspect(name string) (*types.Volume, error)
 	VolumeCreate(name, driverName string, opts, labels map[string]string) (*types.Volume, error)
 	VolumeRm(name string) error
+	VolumesPrune(filter string, dryRun bool) (*volumetypes.PruneReport, error)
+	VolumeRotateKey(name string) error
+	VolumeResize(name string, size int64, force bool) error
+	VolumeUnlock(name string, force bool) error
+	VolumeCreateFromSnapshot(name, driverName, fromSnapshot string, opts, labels map[string]string) (*types.Volume, error)
+	VolumeSnapshotCreate(name, snapshot string, freeze bool) (*volumetypes.Snapshot, error)
+	VolumeSnapshots(name string) ([]*volumetypes.Snapshot, error)
+	VolumeSnapshotRemove(name, snapshot string) error
+	VolumeSnapshotRollback(name, snapshot string) error
+	VolumeExport(name string, compress bool, out io.Writer) error
+	VolumeImport(name, driverName string, opts, labels map[string]string, in io.Reader) (*types.Volume, error)
 }
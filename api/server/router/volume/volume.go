@@ -26,10 +26,20 @@ func (r *volumeRouter) initRoutes() {
 	r.routes = []router.Route{
 		// GET
 		router.NewGetRoute("/volumes", r.getVolumesList),
+		router.NewGetRoute("/volumes/{name:.*}/snapshots", r.getVolumeSnapshots),
+		router.NewGetRoute("/volumes/{name:.*}/export", r.getVolumeExport),
 		router.NewGetRoute("/volumes/{name:.*}", r.getVolumeByName),
 		// POST
 		router.NewPostRoute("/volumes/create", r.postVolumesCreate),
+		router.NewPostRoute("/volumes/prune", r.postVolumesPrune),
+		router.NewPostRoute("/volumes/import", r.postVolumesImport),
+		router.NewPostRoute("/volumes/{name:.*}/rotate-key", r.postVolumesRotateKey),
+		router.NewPostRoute("/volumes/{name:.*}/resize", r.postVolumesResize),
+		router.NewPostRoute("/volumes/{name:.*}/unlock", r.postVolumesUnlock),
+		router.NewPostRoute("/volumes/{name:.*}/snapshots", r.postVolumeSnapshots),
+		router.NewPostRoute("/volumes/{name:.*}/snapshots/{snapshot}/rollback", r.postVolumeSnapshotRollback),
 		// DELETE
+		router.NewDeleteRoute("/volumes/{name:.*}/snapshots/{snapshot}", r.deleteVolumeSnapshot),
 		router.NewDeleteRoute("/volumes/{name:.*}", r.deleteVolumes),
 	}
 }
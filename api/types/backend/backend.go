@@ -31,6 +31,8 @@ type ContainerAttachConfig struct {
 // for users of the backend to to pass it a logging configuration.
 type ContainerLogsConfig struct {
 	types.ContainerLogsOptions
+	Until     string
+	Grep      string
 	OutStream io.Writer
 }
 
@@ -75,6 +77,13 @@ type ContainerCommitConfig struct {
 	Changes []string
 }
 
+// ContainerJSON is a wrapper around types.ContainerJSON that also
+// reports the number of log messages of the container that were dropped.
+type ContainerJSON struct {
+	*types.ContainerJSON
+	LogMessagesDropped int64 `json:",omitempty"`
+}
+
 // ProgressWriter is an interface
 // to transport progress streams.
 type ProgressWriter struct {
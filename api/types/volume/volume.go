@@ -0,0 +1,76 @@
+// Package volume includes the types of the volume API of the daemon that are
+// not in the vendored engine-api.
+package volume
+
+import "github.com/docker/engine-api/types"
+
+// Volume is a wrapper around types.Volume that also holds the space used by
+// the volume.
+type Volume struct {
+	types.Volume
+	// UsageData is the space used by the volume, only returned by
+	// GET "/volumes?size=1" for volumes whose driver can report it.
+	UsageData *UsageData `json:",omitempty"`
+}
+
+// UsageData contains the space used by a volume
+type UsageData struct {
+	Size           int64 // Size is the number of bytes used by the volume
+	Capacity       int64 // Capacity is the size in bytes of the storage of the volume, 0 if unknown
+	Available      int64 // Available is the number of bytes left on the storage of the volume
+	Inodes         int64 // Inodes is the number of inodes used by the volume
+	InodesCapacity int64 // InodesCapacity is the number of inodes of the storage of the volume, 0 if unknown
+}
+
+// ListResponse contains the response for the remote API:
+// GET "/volumes"
+type ListResponse struct {
+	Volumes  []*Volume // Volumes is the list of volumes being returned
+	Warnings []string  // Warnings is a list of warnings that occurred when getting the list from the volume drivers
+}
+
+// ImportOptions holds the parameters of the volume created by VolumeImport.
+type ImportOptions struct {
+	Name       string            // Name is the name of the volume, generated if empty
+	Driver     string            // Driver is the volume driver creating the volume
+	DriverOpts map[string]string // DriverOpts are the options of the volume driver
+	Labels     map[string]string // Labels are the labels of the volume
+}
+
+// PruneReport contains the response for the remote API:
+// POST "/volumes/prune"
+type PruneReport struct {
+	VolumesDeleted []string // VolumesDeleted are the names of the volumes removed, or that would be removed by a dry run
+	SpaceReclaimed uint64   // SpaceReclaimed is the number of bytes freed by removing the volumes
+	Warnings       []string // Warnings are the volumes that could not be removed and why
+}
+
+// ResizeRequest contains the request for the remote API:
+// POST "/volumes/{name}/resize"
+type ResizeRequest struct {
+	Size  int64 // Size is the requested size of the volume in bytes
+	Force bool  // Force allows the volume to be shrunk, which may destroy data
+}
+
+// CreateRequest is a wrapper around types.VolumeCreateRequest that also
+// holds the snapshot to create the volume from.
+type CreateRequest struct {
+	types.VolumeCreateRequest
+	// FromSnapshot is the snapshot, as "volume@snapshot", to create the volume from.
+	FromSnapshot string `json:",omitempty"`
+}
+
+// Snapshot represents a snapshot of a volume for the remote API
+type Snapshot struct {
+	Name    string // Name is the name of the snapshot
+	Volume  string // Volume is the name of the volume the snapshot was taken of
+	Created string `json:",omitempty"` // Created is when the snapshot was taken in RFC 3339 format, if the driver reports it
+	Size    int64  // Size is the size of the volume in bytes when the snapshot was taken
+}
+
+// SnapshotCreateRequest contains the request for the remote API:
+// POST "/volumes/{name}/snapshots"
+type SnapshotCreateRequest struct {
+	Name   string // Name is the requested name of the snapshot
+	Freeze bool   // Freeze allows snapshotting a volume mounted read-write by freezing its filesystem
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/docker/docker/daemon/exec"
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/logger/jsonfilelog"
+	"github.com/docker/docker/daemon/logger/loggerutils/cache"
 	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
@@ -36,6 +37,7 @@ import (
 	containertypes "github.com/docker/engine-api/types/container"
 	networktypes "github.com/docker/engine-api/types/network"
 	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
 	"github.com/docker/libnetwork"
 	"github.com/docker/libnetwork/netlabel"
 	"github.com/docker/libnetwork/options"
@@ -90,6 +92,7 @@ type CommonContainer struct {
 	// logDriver for closing
 	LogDriver      logger.Logger  `json:"-"`
 	LogCopier      *logger.Copier `json:"-"`
+	logDropped     int64
 	restartManager restartmanager.RestartManager
 	attachContext  *attachContext
 }
@@ -336,7 +339,58 @@ func (container *Container) StartLogger(cfg containertypes.LogConfig) (logger.Lo
 			return nil, err
 		}
 	}
-	return c(ctx)
+	l, err := c(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep a local copy of the logs of drivers that can't read them
+	if _, ok := l.(logger.LogReader); ok || !logger.CacheEnabled(cfg.Config) {
+		return l, nil
+	}
+	ctx.LogPath, err = container.GetRootResourcePath(fmt.Sprintf("%s-cache.log", container.ID))
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	cl, err := cache.WithLocalCache(l, ctx)
+	if err != nil {
+		l.Close()
+		return nil, fmt.Errorf("Failed to initialize the log cache: %v", err)
+	}
+	return cl, nil
+}
+
+// StartLogReader returns a logger reading the logs of the container while
+// it isn't running. The logs of the drivers that can't read them are read
+// from the local cache, if it is enabled, without starting the driver.
+func (container *Container) StartLogReader(cfg containertypes.LogConfig) (logger.Logger, error) {
+	if !logger.CacheEnabled(cfg.Config) {
+		return container.StartLogger(cfg)
+	}
+	reads, err := logger.ReadsLogs(cfg.Type)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get logging factory: %v", err)
+	}
+	if reads {
+		return container.StartLogger(cfg)
+	}
+	logPath, err := container.GetRootResourcePath(fmt.Sprintf("%s-cache.log", container.ID))
+	if err != nil {
+		return nil, err
+	}
+	l, err := cache.NewReader(cfg.Type, logger.Context{
+		Config:          cfg.Config,
+		ContainerID:     container.ID,
+		ContainerName:   container.Name,
+		ContainerEnv:    container.Config.Env,
+		ContainerLabels: container.Config.Labels,
+		LogPath:         logPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the log cache: %v", err)
+	}
+	return l, nil
 }
 
 // GetProcessLabel returns the process label for the container.
@@ -1023,24 +1077,58 @@ func (container *Container) startLogging() error {
 		return nil // do not start logging routines
 	}
 
-	l, err := container.StartLogger(container.HostConfig.LogConfig)
+	cfg := container.HostConfig.LogConfig
+	multiline, err := logger.ParseMultilineConfig(cfg.Config)
+	if err != nil {
+		return fmt.Errorf("Failed to initialize logging driver: %v", err)
+	}
+	format, err := logger.ParseFormatConfig(cfg.Config)
+	if err != nil {
+		return fmt.Errorf("Failed to initialize logging driver: %v", err)
+	}
+	l, err := container.StartLogger(cfg)
 	if err != nil {
 		return fmt.Errorf("Failed to initialize logging driver: %v", err)
 	}
-
-	copier := logger.NewCopier(map[string]io.Reader{"stdout": container.StdoutPipe(), "stderr": container.StderrPipe()}, l)
-	container.LogCopier = copier
-	copier.Run()
-	container.LogDriver = l
 
 	// set LogPath field only for json-file logdriver
 	if jl, ok := l.(*jsonfilelog.JSONFileLogger); ok {
 		container.LogPath = jl.LogPath()
 	}
 
+	if format != nil {
+		l = logger.NewJSONFormatLogger(l, format)
+	}
+
+	if cfg.Config[logger.ModeOpt] == logger.NonBlockingMode {
+		var bufferSize int64
+		if s, ok := cfg.Config[logger.BufferSizeOpt]; ok {
+			if bufferSize, err = units.RAMInBytes(s); err != nil {
+				l.Close()
+				return fmt.Errorf("Failed to initialize logging driver: invalid %s: %v", logger.BufferSizeOpt, err)
+			}
+		}
+		l = logger.NewRingLogger(l, bufferSize)
+	}
+	container.logDropped = 0
+
+	copier := logger.NewMultilineCopier(map[string]io.Reader{"stdout": container.StdoutPipe(), "stderr": container.StderrPipe()}, l, multiline)
+	container.LogCopier = copier
+	copier.Run()
+	container.LogDriver = l
+
 	return nil
 }
 
+// LogDroppedMessages returns the number of log messages dropped by the
+// non-blocking log mode since the container last started.
+func (container *Container) LogDroppedMessages() int64 {
+	if dc, ok := container.LogDriver.(logger.DropCounter); ok {
+		return dc.Dropped()
+	}
+	return container.logDropped
+}
+
 // StdinPipe gets the stdin stream of the container
 func (container *Container) StdinPipe() io.WriteCloser {
 	return container.StreamConfig.StdinPipe()
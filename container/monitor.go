@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/logger"
 )
 
 const (
@@ -40,6 +41,9 @@ func (container *Container) Reset(lock bool) {
 			}
 		}
 		container.LogDriver.Close()
+		if dc, ok := container.LogDriver.(logger.DropCounter); ok {
+			container.logDropped = dc.Dropped()
+		}
 		container.LogCopier = nil
 		container.LogDriver = nil
 	}
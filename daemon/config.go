@@ -8,12 +8,14 @@ import (
 	"io/ioutil"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/discovery"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/registry"
+	"github.com/docker/engine-api/types/filters"
 	"github.com/imdario/mergo"
 )
 
@@ -44,6 +46,7 @@ var flatOptions = map[string]bool{
 	"cluster-store-opts": true,
 	"log-opts":           true,
 	"runtimes":           true,
+	"volume-opts":        true,
 }
 
 // LogConfig represents the default log configuration.
@@ -96,6 +99,16 @@ type CommonConfig struct {
 	EnableCors           bool                `json:"api-enable-cors,omitempty"`
 	LiveRestore          bool                `json:"live-restore,omitempty"`
 
+	// VolumeOpts holds options for the built-in volume drivers. Keys are
+	// prefixed by the driver they apply to, e.g. `ceph.luks-key-provider`.
+	VolumeOpts map[string]string `json:"volume-opts,omitempty"`
+
+	// VolumePruneInterval is how often unused volumes matching
+	// VolumePruneFilters are removed, as a duration such as "6h". Volumes
+	// are not pruned periodically if it is not set.
+	VolumePruneInterval string   `json:"volume-prune-interval,omitempty"`
+	VolumePruneFilters  []string `json:"volume-prune-filters,omitempty"`
+
 	// ClusterStore is the storage backend used for the cluster information. It is used by both
 	// multihost networking (to store networks and endpoints information) and by the node discovery
 	// mechanism.
@@ -170,6 +183,9 @@ func (config *Config) InstallCommonFlags(cmd *flag.FlagSet, usageFn func(string)
 	cmd.StringVar(&config.ClusterAdvertise, []string{"-cluster-advertise"}, "", usageFn("Address or interface name to advertise"))
 	cmd.StringVar(&config.ClusterStore, []string{"-cluster-store"}, "", usageFn("URL of the distributed storage backend"))
 	cmd.Var(opts.NewNamedMapOpts("cluster-store-opts", config.ClusterOpts, nil), []string{"-cluster-store-opt"}, usageFn("Set cluster store options"))
+	cmd.Var(opts.NewNamedMapOpts("volume-opts", config.VolumeOpts, nil), []string{"-volume-opt"}, usageFn("Set built-in volume driver options"))
+	cmd.StringVar(&config.VolumePruneInterval, []string{"-volume-prune-interval"}, "", usageFn("Periodically remove unused volumes at this interval"))
+	cmd.Var(opts.NewNamedListOptsRef("volume-prune-filters", &config.VolumePruneFilters, nil), []string{"-volume-prune-filter"}, usageFn("Filter the volumes removed periodically"))
 	cmd.StringVar(&config.CorsHeaders, []string{"-api-cors-header"}, "", usageFn("Set CORS headers in the remote API"))
 	cmd.IntVar(&maxConcurrentDownloads, []string{"-max-concurrent-downloads"}, defaultMaxConcurrentDownloads, usageFn("Set the max concurrent downloads for each pull"))
 	cmd.IntVar(&maxConcurrentUploads, []string{"-max-concurrent-uploads"}, defaultMaxConcurrentUploads, usageFn("Set the max concurrent uploads for each push"))
@@ -400,6 +416,31 @@ func findConfigurationConflicts(config map[string]interface{}, flags *flag.FlagS
 	return nil
 }
 
+// volumePrunePolicy returns how often unused volumes are pruned, 0 if they
+// aren't, and the filters selecting the volumes to prune.
+func (config *Config) volumePrunePolicy() (time.Duration, filters.Args, error) {
+	pruneFilters := filters.NewArgs()
+	if config.VolumePruneInterval == "" {
+		if len(config.VolumePruneFilters) > 0 {
+			return 0, pruneFilters, fmt.Errorf("volume prune filters are set without a volume prune interval")
+		}
+		return 0, pruneFilters, nil
+	}
+	interval, err := time.ParseDuration(config.VolumePruneInterval)
+	if err != nil || interval <= 0 {
+		return 0, pruneFilters, fmt.Errorf("invalid volume prune interval %q, expected a positive duration such as 6h", config.VolumePruneInterval)
+	}
+	for _, f := range config.VolumePruneFilters {
+		if pruneFilters, err = filters.ParseFlag(f, pruneFilters); err != nil {
+			return 0, pruneFilters, fmt.Errorf("invalid volume prune filter: %v", err)
+		}
+	}
+	if err := pruneFilters.Validate(acceptedVolumePruneFilterTags); err != nil {
+		return 0, pruneFilters, fmt.Errorf("invalid volume prune filter: %v", err)
+	}
+	return interval, pruneFilters, nil
+}
+
 // ValidateConfiguration validates some specific configs.
 // such as config.DNS, config.Labels, config.DNSSearch,
 // as well as config.MaxConcurrentDownloads, config.MaxConcurrentUploads.
@@ -435,6 +476,10 @@ func ValidateConfiguration(config *Config) error {
 		return fmt.Errorf("invalid max concurrent uploads: %d", *config.MaxConcurrentUploads)
 	}
 
+	if _, _, err := config.volumePrunePolicy(); err != nil {
+		return err
+	}
+
 	// validate that "default" runtime is not reset
 	if runtimes := config.GetAllRuntimes(); len(runtimes) > 0 {
 		if _, ok := runtimes[stockRuntimeName]; ok {
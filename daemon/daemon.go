@@ -52,7 +52,6 @@ import (
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
 	volumedrivers "github.com/docker/docker/volume/drivers"
-	"github.com/docker/docker/volume/ceph"
 	"github.com/docker/docker/volume/local"
 	"github.com/docker/docker/volume/nfs"
 	"github.com/docker/docker/volume/store"
@@ -520,13 +519,16 @@ func NewDaemon(config *Config, registryService registry.Service, containerdRemot
 		return nil, err
 	}
 
-	// Configure the volumes driver
-	volStore, err := d.configureVolumes(rootUID, rootGID)
+	trustKey, err := api.LoadOrCreateTrustKey(config.TrustKeyPath)
 	if err != nil {
 		return nil, err
 	}
+	// the ID is known before the volume drivers are configured, as some of
+	// them lock volumes on behalf of the daemon
+	d.ID = trustKey.PublicKey().KeyID()
 
-	trustKey, err := api.LoadOrCreateTrustKey(config.TrustKeyPath)
+	// Configure the volumes driver
+	volStore, err := d.configureVolumes(rootUID, rootGID)
 	if err != nil {
 		return nil, err
 	}
@@ -572,7 +574,6 @@ func NewDaemon(config *Config, registryService registry.Service, containerdRemot
 		return nil, fmt.Errorf("Devices cgroup isn't mounted")
 	}
 
-	d.ID = trustKey.PublicKey().KeyID()
 	d.repository = daemonRepo
 	d.containers = container.NewMemoryStore()
 	d.execCommands = exec.NewStore()
@@ -613,6 +614,15 @@ func NewDaemon(config *Config, registryService registry.Service, containerdRemot
 		return nil, err
 	}
 
+	pruneInterval, pruneFilters, err := config.volumePrunePolicy()
+	if err != nil {
+		return nil, err
+	}
+	if pruneInterval > 0 {
+		go d.volumePruneLoop(pruneInterval, pruneFilters)
+	}
+	go d.logDropLoop(logDropEventInterval)
+
 	return d, nil
 }
 
@@ -856,10 +866,14 @@ func (daemon *Daemon) configureVolumes(rootUID, rootGID int) (*store.VolumeStore
 		return nil, fmt.Errorf("local volume driver could not be registered")
 	}
 	// add custom drivers
-	cephVolumesDriver := cephvolumedriver.New()
-	volumedrivers.Register(cephVolumesDriver, cephVolumesDriver.Name())
-	nfsVolumesDriver := nfsvolumedriver.New()
+	nfsVolumesDriver, err := nfsvolumedriver.New(daemon.configStore.Root, daemon.configStore.VolumeOpts)
+	if err != nil {
+		return nil, err
+	}
 	volumedrivers.Register(nfsVolumesDriver, nfsVolumesDriver.Name())
+	if err := registerBlockVolumeDrivers(daemon.configStore.Root, daemon.ID, daemon.configStore.VolumeOpts); err != nil {
+		return nil, err
+	}
 
 	return store.New(daemon.configStore.Root)
 }
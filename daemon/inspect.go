@@ -23,7 +23,27 @@ func (daemon *Daemon) ContainerInspect(name string, size bool, version string) (
 	case versions.Equal(version, "1.20"):
 		return daemon.containerInspect120(name)
 	}
-	return daemon.ContainerInspectCurrent(name, size)
+	return daemon.containerInspectCurrent(name, size)
+}
+
+// containerInspectCurrent returns the output of ContainerInspectCurrent,
+// with the fields that are not in the engine-api types.
+func (daemon *Daemon) containerInspectCurrent(name string, size bool) (*backend.ContainerJSON, error) {
+	json, err := daemon.ContainerInspectCurrent(name, size)
+	if err != nil {
+		return nil, err
+	}
+	container, err := daemon.GetContainer(json.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	container.Lock()
+	defer container.Unlock()
+	return &backend.ContainerJSON{
+		ContainerJSON:      json,
+		LogMessagesDropped: container.LogDroppedMessages(),
+	}, nil
 }
 
 // ContainerInspectCurrent returns low-level information about a
@@ -6,17 +6,24 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Sirupsen/logrus"
+	volumetypes "github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/volume"
+	volumestore "github.com/docker/docker/volume/store"
 	"github.com/docker/engine-api/types"
 	"github.com/docker/engine-api/types/filters"
 	networktypes "github.com/docker/engine-api/types/network"
 	"github.com/docker/go-connections/nat"
 )
 
+// maxConcurrentVolumeUsage is how many volumes have their usage computed at
+// the same time when listing volumes with their size
+const maxConcurrentVolumeUsage = 8
+
 var acceptedVolumeFilterTags = map[string]bool{
 	"dangling": true,
 	"name":     true,
@@ -532,10 +539,11 @@ func (daemon *Daemon) transformContainer(container *container.Container, ctx *li
 }
 
 // Volumes lists known volumes, using the filter to restrict the range
-// of volumes returned.
-func (daemon *Daemon) Volumes(filter string) ([]*types.Volume, []string, error) {
+// of volumes returned. If size is set, the space used by the volumes whose
+// driver can report it is returned as well.
+func (daemon *Daemon) Volumes(filter string, size bool) ([]*volumetypes.Volume, []string, error) {
 	var (
-		volumesOut []*types.Volume
+		volumesOut []*volumetypes.Volume
 	)
 	volFilters, err := filters.FromParam(filter)
 	if err != nil {
@@ -564,11 +572,47 @@ func (daemon *Daemon) Volumes(filter string) ([]*types.Volume, []string, error)
 		} else {
 			apiV.Mountpoint = v.Path()
 		}
-		volumesOut = append(volumesOut, apiV)
+		volumesOut = append(volumesOut, &volumetypes.Volume{Volume: *apiV})
+	}
+	if size {
+		daemon.volumesUsage(filterVolumes, volumesOut)
 	}
 	return volumesOut, warnings, nil
 }
 
+// volumesUsage sets the usage of the volumes whose driver can report it.
+// Usage may take a while to compute, so volumes are done concurrently, by
+// up to maxConcurrentVolumeUsage at a time.
+func (daemon *Daemon) volumesUsage(vols []volume.Volume, out []*volumetypes.Volume) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentVolumeUsage)
+	for i, v := range vols {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(v volume.Volume, apiV *volumetypes.Volume) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			usage, err := daemon.volumes.Usage(v)
+			if err != nil {
+				if !volumestore.IsNotSupported(err) {
+					logrus.Debugf("Unable to get the usage of volume %s: %v", v.Name(), err)
+				}
+				return
+			}
+			apiV.UsageData = &volumetypes.UsageData{
+				Size:           usage.Used,
+				Capacity:       usage.Size,
+				Available:      usage.Available,
+				Inodes:         usage.InodesUsed,
+				InodesCapacity: usage.Inodes,
+			}
+		}(v, out[i])
+	}
+	wg.Wait()
+}
+
 // filterVolumes filters volume list according to user specified filter
 // and returns user chosen volumes
 func (daemon *Daemon) filterVolumes(vols []volume.Volume, filter filters.Args) ([]volume.Volume, error) {
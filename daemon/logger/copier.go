@@ -16,6 +16,7 @@ type Copier struct {
 	// srcs is map of name -> reader pairs, for example "stdout", "stderr"
 	srcs      map[string]io.Reader
 	dst       Logger
+	multiline *MultilineConfig
 	copyJobs  sync.WaitGroup
 	closeOnce sync.Once
 	closed    chan struct{}
@@ -30,6 +31,14 @@ func NewCopier(srcs map[string]io.Reader, dst Logger) *Copier {
 	}
 }
 
+// NewMultilineCopier creates a new Copier merging the lines of multiline
+// messages as configured, before they are logged.
+func NewMultilineCopier(srcs map[string]io.Reader, dst Logger, multiline *MultilineConfig) *Copier {
+	c := NewCopier(srcs, dst)
+	c.multiline = multiline
+	return c
+}
+
 // Run starts logs copying
 func (c *Copier) Run() {
 	for src, w := range c.srcs {
@@ -42,6 +51,12 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 	defer c.copyJobs.Done()
 	reader := bufio.NewReader(src)
 
+	var multiline *multilineAggregator
+	if c.multiline != nil {
+		multiline = newMultilineAggregator(c.multiline, c.log)
+		defer multiline.close()
+	}
+
 	for {
 		select {
 		case <-c.closed:
@@ -53,8 +68,11 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 			// ReadBytes can return full or partial output even when it failed.
 			// e.g. it can return a full entry and EOF.
 			if err == nil || len(line) > 0 {
-				if logErr := c.dst.Log(&Message{Line: line, Source: name, Timestamp: time.Now().UTC()}); logErr != nil {
-					logrus.Errorf("Failed to log msg %q for logger %s: %s", line, c.dst.Name(), logErr)
+				msg := &Message{Line: line, Source: name, Timestamp: time.Now().UTC()}
+				if multiline != nil {
+					multiline.add(msg)
+				} else {
+					c.log(msg)
 				}
 			}
 
@@ -68,6 +86,12 @@ func (c *Copier) copySrc(name string, src io.Reader) {
 	}
 }
 
+func (c *Copier) log(msg *Message) {
+	if logErr := c.dst.Log(msg); logErr != nil {
+		logrus.Errorf("Failed to log msg %q for logger %s: %s", msg.Line, c.dst.Name(), logErr)
+	}
+}
+
 // Wait waits until all copying is done
 func (c *Copier) Wait() {
 	c.copyJobs.Wait()
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"reflect"
+	"regexp"
 	"sync"
 	"testing"
 	"time"
@@ -116,3 +118,111 @@ func TestCopierSlow(t *testing.T) {
 	case <-wait:
 	}
 }
+
+func TestCopierMultiline(t *testing.T) {
+	trace := "Exception in thread \"main\" java.lang.NullPointerException\n" +
+		"\tat com.example.Main.run(Main.java:14)\n" +
+		"\tat com.example.Main.main(Main.java:5)"
+	stdout := bytes.NewBufferString("Starting\n" + trace + "\nDone")
+	driver := &blockedLogger{release: make(chan struct{})}
+	close(driver.release)
+
+	c := NewMultilineCopier(map[string]io.Reader{"stdout": stdout}, driver, &MultilineConfig{
+		Pattern:       regexp.MustCompile(`^\S`),
+		FlushInterval: time.Hour,
+		MaxSize:       1024,
+	})
+	c.Run()
+	c.Wait()
+
+	var lines []string
+	for _, msg := range driver.messages {
+		lines = append(lines, string(msg.Line))
+	}
+	if expected := []string{"Starting", trace, "Done"}; !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("expected messages %q, got %q", expected, lines)
+	}
+}
+
+func TestCopierMultilineMaxSize(t *testing.T) {
+	stdout := bytes.NewBufferString("first\n 1234\n 5678\n 9\n")
+	driver := &blockedLogger{release: make(chan struct{})}
+	close(driver.release)
+
+	c := NewMultilineCopier(map[string]io.Reader{"stdout": stdout}, driver, &MultilineConfig{
+		Pattern:       regexp.MustCompile(`^\S`),
+		FlushInterval: time.Hour,
+		MaxSize:       12,
+	})
+	c.Run()
+	c.Wait()
+
+	var lines []string
+	for _, msg := range driver.messages {
+		lines = append(lines, string(msg.Line))
+	}
+	if expected := []string{"first\n 1234", " 5678\n 9"}; !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("expected messages %q, got %q", expected, lines)
+	}
+}
+
+func TestCopierMultilineFlushInterval(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	driver := &blockedLogger{release: make(chan struct{})}
+	close(driver.release)
+
+	c := NewMultilineCopier(map[string]io.Reader{"stdout": r}, driver, &MultilineConfig{
+		Pattern:       regexp.MustCompile(`^\S`),
+		FlushInterval: 50 * time.Millisecond,
+		MaxSize:       1024,
+	})
+	c.Run()
+	if _, err := w.Write([]byte("first\n second\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the message is logged once no line follows it
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		driver.mu.Lock()
+		n := len(driver.messages)
+		driver.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the message to be flushed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if line := string(driver.messages[0].Line); line != "first\n second" {
+		t.Fatalf("expected the lines to be merged, got %q", line)
+	}
+}
+
+func TestParseMultilineConfig(t *testing.T) {
+	config, err := ParseMultilineConfig(map[string]string{})
+	if err != nil || config != nil {
+		t.Fatalf("expected no multiline config, got %v, %v", config, err)
+	}
+	config, err = ParseMultilineConfig(map[string]string{MultilinePatternOpt: `^\d`, MultilineFlushIntervalOpt: "5s", MultilineMaxSizeOpt: "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.FlushInterval != 5*time.Second || config.MaxSize != 1<<20 {
+		t.Fatalf("unexpected multiline config %+v", config)
+	}
+	invalid := []map[string]string{
+		{MultilineFlushIntervalOpt: "5s"},
+		{MultilinePatternOpt: "("},
+		{MultilinePatternOpt: `^\d`, MultilineFlushIntervalOpt: "soon"},
+		{MultilinePatternOpt: `^\d`, MultilineFlushIntervalOpt: "0s"},
+		{MultilinePatternOpt: `^\d`, MultilineMaxSizeOpt: "0"},
+	}
+	for _, cfg := range invalid {
+		if _, err := ParseMultilineConfig(cfg); err == nil {
+			t.Fatalf("expected %v to be invalid", cfg)
+		}
+	}
+}
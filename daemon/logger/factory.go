@@ -2,7 +2,10 @@ package logger
 
 import (
 	"fmt"
+	"strconv"
 	"sync"
+
+	"github.com/docker/go-units"
 )
 
 // Creator builds a logging driver instance with given context.
@@ -14,6 +17,7 @@ type LogOptValidator func(cfg map[string]string) error
 
 type logdriverFactory struct {
 	registry     map[string]Creator
+	readers      map[string]bool // the drivers implementing LogReader
 	optValidator map[string]LogOptValidator
 	m            sync.Mutex
 }
@@ -29,6 +33,24 @@ func (lf *logdriverFactory) register(name string, c Creator) error {
 	return nil
 }
 
+func (lf *logdriverFactory) registerReader(name string, c Creator) error {
+	if err := lf.register(name, c); err != nil {
+		return err
+	}
+
+	lf.m.Lock()
+	lf.readers[name] = true
+	lf.m.Unlock()
+	return nil
+}
+
+func (lf *logdriverFactory) readsLogs(name string) bool {
+	lf.m.Lock()
+	defer lf.m.Unlock()
+
+	return lf.readers[name]
+}
+
 func (lf *logdriverFactory) driverRegistered(name string) bool {
 	lf.m.Lock()
 	_, ok := lf.registry[name]
@@ -66,7 +88,7 @@ func (lf *logdriverFactory) getLogOptValidator(name string) LogOptValidator {
 	return c
 }
 
-var factory = &logdriverFactory{registry: make(map[string]Creator), optValidator: make(map[string]LogOptValidator)} // global factory instance
+var factory = &logdriverFactory{registry: make(map[string]Creator), readers: make(map[string]bool), optValidator: make(map[string]LogOptValidator)} // global factory instance
 
 // RegisterLogDriver registers the given logging driver builder with given logging
 // driver name.
@@ -74,6 +96,12 @@ func RegisterLogDriver(name string, c Creator) error {
 	return factory.register(name, c)
 }
 
+// RegisterLogReaderDriver registers the given builder of a logging driver
+// implementing LogReader with given logging driver name.
+func RegisterLogReaderDriver(name string, c Creator) error {
+	return factory.registerReader(name, c)
+}
+
 // RegisterLogOptValidator registers the logging option validator with
 // the given logging driver name.
 func RegisterLogOptValidator(name string, l LogOptValidator) error {
@@ -81,19 +109,66 @@ func RegisterLogOptValidator(name string, l LogOptValidator) error {
 }
 
 // GetLogDriver provides the logging driver builder for a logging driver name.
+// Drivers that are not registered are looked up among the log driver
+// plugins.
 func GetLogDriver(name string) (Creator, error) {
-	return factory.get(name)
+	c, err := factory.get(name)
+	if err == nil {
+		return c, nil
+	}
+	if pc, pluginErr := getPlugin(name); pluginErr == nil {
+		return pc, nil
+	}
+	return nil, err
+}
+
+// ReadsLogs returns whether the logging driver with the given name reads
+// back the logs it is sent, without creating an instance of the driver.
+func ReadsLogs(name string) (bool, error) {
+	if factory.driverRegistered(name) {
+		return factory.readsLogs(name), nil
+	}
+	if reads, err := pluginReadsLogs(name); err == nil {
+		return reads, nil
+	}
+	return false, fmt.Errorf("logger: no log driver named '%s' is registered", name)
 }
 
 // ValidateLogOpts checks the options for the given log driver. The
-// options supported are specific to the LogDriver implementation.
+// options supported are specific to the LogDriver implementation, except for
+// the delivery mode, cache, multiline and format options, which all drivers
+// support.
 func ValidateLogOpts(name string, cfg map[string]string) error {
 	if name == "none" {
 		return nil
 	}
 
+	if err := validateModeOpts(cfg); err != nil {
+		return err
+	}
+	if err := validateCacheOpts(cfg); err != nil {
+		return err
+	}
+	if _, err := ParseMultilineConfig(cfg); err != nil {
+		return err
+	}
+	if _, err := ParseFormatConfig(cfg); err != nil {
+		return err
+	}
+	driverCfg := make(map[string]string, len(cfg))
+	for k, v := range cfg {
+		if !commonOpts[k] {
+			driverCfg[k] = v
+		}
+	}
+	cfg = driverCfg
+
 	if !factory.driverRegistered(name) {
-		return fmt.Errorf("logger: no log driver named '%s' is registered", name)
+		if _, err := getPlugin(name); err != nil {
+			return fmt.Errorf("logger: no log driver named '%s' is registered", name)
+		}
+		// plugins validate their options when they start logging
+		return nil
 	}
 
 	validator := factory.getLogOptValidator(name)
@@ -102,3 +177,87 @@ func ValidateLogOpts(name string, cfg map[string]string) error {
 	}
 	return nil
 }
+
+const (
+	// CacheOpt enables a local copy of the logs of the drivers that can't
+	// read them, so that they can be read with `docker logs`.
+	CacheOpt = "cache"
+	// CacheMaxSizeOpt is the size of the files of the local copy before
+	// they are rotated.
+	CacheMaxSizeOpt = "cache-max-size"
+	// CacheMaxFileOpt is the number of files of the local copy kept.
+	CacheMaxFileOpt = "cache-max-file"
+)
+
+// commonOpts are the log options all drivers support.
+var commonOpts = map[string]bool{
+	ModeOpt:         true,
+	BufferSizeOpt:   true,
+	CacheOpt:        true,
+	CacheMaxSizeOpt: true,
+	CacheMaxFileOpt: true,
+
+	MultilinePatternOpt:       true,
+	MultilineFlushIntervalOpt: true,
+	MultilineMaxSizeOpt:       true,
+
+	FormatOpt:         true,
+	FormatTimeKeyOpt:  true,
+	FormatLevelKeyOpt: true,
+}
+
+func validateModeOpts(cfg map[string]string) error {
+	mode := cfg[ModeOpt]
+	switch mode {
+	case "", BlockingMode, NonBlockingMode:
+	default:
+		return fmt.Errorf("logger: invalid log mode %q, expected %s or %s", mode, BlockingMode, NonBlockingMode)
+	}
+	if s, ok := cfg[BufferSizeOpt]; ok {
+		if mode != NonBlockingMode {
+			return fmt.Errorf("logger: %s is only supported with %s=%s", BufferSizeOpt, ModeOpt, NonBlockingMode)
+		}
+		size, err := units.RAMInBytes(s)
+		if err != nil {
+			return fmt.Errorf("logger: invalid %s %q: %v", BufferSizeOpt, s, err)
+		}
+		if size <= 0 {
+			return fmt.Errorf("logger: %s must be positive", BufferSizeOpt)
+		}
+	}
+	return nil
+}
+
+func validateCacheOpts(cfg map[string]string) error {
+	if s, ok := cfg[CacheOpt]; ok {
+		if _, err := strconv.ParseBool(s); err != nil {
+			return fmt.Errorf("logger: invalid %s %q: %v", CacheOpt, s, err)
+		}
+	}
+	if s, ok := cfg[CacheMaxSizeOpt]; ok {
+		size, err := units.FromHumanSize(s)
+		if err != nil {
+			return fmt.Errorf("logger: invalid %s %q: %v", CacheMaxSizeOpt, s, err)
+		}
+		if size <= 0 {
+			return fmt.Errorf("logger: %s must be positive", CacheMaxSizeOpt)
+		}
+	}
+	if s, ok := cfg[CacheMaxFileOpt]; ok {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("logger: invalid %s %q: %v", CacheMaxFileOpt, s, err)
+		}
+		if n < 1 {
+			return fmt.Errorf("logger: %s cannot be less than 1", CacheMaxFileOpt)
+		}
+	}
+	return nil
+}
+
+// CacheEnabled returns whether the options enable the local cache of the
+// logs.
+func CacheEnabled(cfg map[string]string) bool {
+	enabled, _ := strconv.ParseBool(cfg[CacheOpt])
+	return enabled
+}
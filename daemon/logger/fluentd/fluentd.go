@@ -141,6 +141,12 @@ func (f *fluentd) Log(msg *logger.Message) error {
 	for k, v := range f.extra {
 		data[k] = v
 	}
+	// the attributes of the message don't replace the keys above
+	for k, v := range msg.Attrs {
+		if _, ok := data[k]; !ok {
+			data[k] = v
+		}
+	}
 	// fluent-logger-golang buffers logs from failures and disconnections,
 	// and these are transferred again automatically.
 	return f.writer.PostWithTime(f.tag, msg.Timestamp, data)
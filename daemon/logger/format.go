@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// FormatOpt is the log option selecting how the lines of containers are
+	// parsed, TextFormat or JSONFormat.
+	FormatOpt = "format"
+	// FormatTimeKeyOpt is the log option naming the field of JSON lines
+	// holding the time of the message.
+	FormatTimeKeyOpt = "format-time-key"
+	// FormatLevelKeyOpt is the log option naming the field of JSON lines
+	// holding the severity of the message. Defaults to LevelAttr.
+	FormatLevelKeyOpt = "format-level-key"
+
+	// TextFormat logs lines as they are. It is the default.
+	TextFormat = "text"
+	// JSONFormat lifts the top-level fields of lines that are JSON objects
+	// into the attributes of their message.
+	JSONFormat = "json"
+
+	// LevelAttr is the attribute holding the severity of a message, which
+	// drivers map to their own, see ParseLevel.
+	LevelAttr = "level"
+)
+
+// FormatConfig configures the parsing of the lines of containers.
+type FormatConfig struct {
+	// TimeKey is the field holding the time of the message, if any. It
+	// is either a string in the RFC 3339 format, or a number of seconds
+	// since the Unix epoch.
+	TimeKey string
+	// LevelKey is the field holding the severity of the message, which is
+	// moved to the LevelAttr attribute.
+	LevelKey string
+}
+
+// ParseFormatConfig returns the format config set by the log options, or nil
+// if lines are logged as they are.
+func ParseFormatConfig(cfg map[string]string) (*FormatConfig, error) {
+	format := cfg[FormatOpt]
+	switch format {
+	case "", TextFormat:
+		for _, opt := range []string{FormatTimeKeyOpt, FormatLevelKeyOpt} {
+			if _, ok := cfg[opt]; ok {
+				return nil, fmt.Errorf("logger: %s is only supported with %s=%s", opt, FormatOpt, JSONFormat)
+			}
+		}
+		return nil, nil
+	case JSONFormat:
+	default:
+		return nil, fmt.Errorf("logger: invalid log format %q, expected %s or %s", format, TextFormat, JSONFormat)
+	}
+
+	config := &FormatConfig{
+		TimeKey:  cfg[FormatTimeKeyOpt],
+		LevelKey: LevelAttr,
+	}
+	if s, ok := cfg[FormatLevelKeyOpt]; ok {
+		if s == "" {
+			return nil, fmt.Errorf("logger: %s cannot be empty", FormatLevelKeyOpt)
+		}
+		config.LevelKey = s
+	}
+	return config, nil
+}
+
+// JSONFormatLogger is a Logger parsing the lines of messages as JSON
+// objects for another logger, whose top-level fields become the attributes
+// of the message. Lines that aren't JSON objects are logged as they are.
+type JSONFormatLogger struct {
+	l      Logger
+	config *FormatConfig
+}
+
+type jsonFormatWithReader struct {
+	*JSONFormatLogger
+}
+
+func (f *jsonFormatWithReader) ReadLogs(cfg ReadConfig) *LogWatcher {
+	return f.JSONFormatLogger.l.(LogReader).ReadLogs(cfg)
+}
+
+// NewJSONFormatLogger returns a logger parsing the lines of messages for
+// driver as configured. It can read logs if driver can.
+func NewJSONFormatLogger(driver Logger, config *FormatConfig) Logger {
+	f := &JSONFormatLogger{l: driver, config: config}
+	if _, ok := driver.(LogReader); ok {
+		return &jsonFormatWithReader{f}
+	}
+	return f
+}
+
+// Log parses the line of the message and logs it with the driver.
+func (f *JSONFormatLogger) Log(msg *Message) error {
+	f.parse(msg)
+	return f.l.Log(msg)
+}
+
+// Name returns the name of the driver.
+func (f *JSONFormatLogger) Name() string {
+	return f.l.Name()
+}
+
+// Close closes the driver.
+func (f *JSONFormatLogger) Close() error {
+	return f.l.Close()
+}
+
+func (f *JSONFormatLogger) parse(msg *Message) {
+	line := bytes.TrimSpace(msg.Line)
+	if len(line) == 0 || line[0] != '{' {
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return
+	}
+
+	attrs := make(LogAttributes, len(fields)+len(msg.Attrs))
+	for k, v := range msg.Attrs {
+		attrs[k] = v
+	}
+	for k, raw := range fields {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			// numbers, booleans, arrays and objects are kept as JSON
+			s = string(raw)
+		}
+		attrs[k] = s
+	}
+
+	if f.config.TimeKey != "" {
+		if t, ok := parseTime(attrs[f.config.TimeKey]); ok {
+			msg.Timestamp = t
+			delete(attrs, f.config.TimeKey)
+		}
+	}
+	if level, ok := attrs[f.config.LevelKey]; ok && f.config.LevelKey != LevelAttr {
+		delete(attrs, f.config.LevelKey)
+		attrs[LevelAttr] = level
+	}
+	if len(attrs) > 0 {
+		msg.Attrs = attrs
+	}
+}
+
+func parseTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.UTC(), true
+	}
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Unix(0, int64(secs*float64(time.Second))).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// syslog severities, from the most to the least severe
+var levels = map[string]int{
+	"emerg":     0,
+	"emergency": 0,
+	"panic":     0,
+	"alert":     1,
+	"crit":      2,
+	"critical":  2,
+	"fatal":     2,
+	"err":       3,
+	"error":     3,
+	"warn":      4,
+	"warning":   4,
+	"notice":    5,
+	"info":      6,
+	"debug":     7,
+	"trace":     7,
+}
+
+// ParseLevel returns the syslog severity, from 0 (emergency) to 7 (debug),
+// of the LevelAttr attribute of a message. It accepts the names of the
+// syslog severities and of common aliases, in any case, or their number.
+func ParseLevel(attrs LogAttributes) (int, bool) {
+	s, ok := attrs[LevelAttr]
+	if !ok {
+		return 0, false
+	}
+	if level, ok := levels[strings.ToLower(s)]; ok {
+		return level, true
+	}
+	if level, err := strconv.Atoi(s); err == nil && level >= 0 && level <= 7 {
+		return level, true
+	}
+	return 0, false
+}
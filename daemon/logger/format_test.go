@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestJSONFormatLogger(t *testing.T) {
+	driver := &blockedLogger{release: make(chan struct{})}
+	close(driver.release)
+	l := NewJSONFormatLogger(driver, &FormatConfig{TimeKey: "ts", LevelKey: "severity"})
+	if _, ok := l.(LogReader); ok {
+		t.Fatal("expected the format logger not to read logs of a driver that can't")
+	}
+
+	now := time.Now().UTC()
+	lines := []string{
+		`{"ts":"2016-10-17T09:30:00.5Z","severity":"ERROR","msg":"boom","code":500,"user":{"id":1}}`,
+		`{"ts":"1476696600","msg":"unix time"}`,
+		`{"ts":"yesterday","msg":"invalid time"}`,
+		`not json`,
+		`["not", "an", "object"]`,
+	}
+	for _, line := range lines {
+		if err := l.Log(&Message{Line: []byte(line), Source: "stdout", Timestamp: now}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []struct {
+		timestamp time.Time
+		attrs     LogAttributes
+	}{
+		{time.Date(2016, 10, 17, 9, 30, 0, 5e8, time.UTC), LogAttributes{LevelAttr: "ERROR", "msg": "boom", "code": "500", "user": `{"id":1}`}},
+		{time.Unix(1476696600, 0).UTC(), LogAttributes{"msg": "unix time"}},
+		{now, LogAttributes{"ts": "yesterday", "msg": "invalid time"}},
+		{now, nil},
+		{now, nil},
+	}
+	for i, msg := range driver.messages {
+		if string(msg.Line) != lines[i] {
+			t.Fatalf("expected line %q to be kept, got %q", lines[i], msg.Line)
+		}
+		if !msg.Timestamp.Equal(expected[i].timestamp) {
+			t.Fatalf("expected line %q to be logged at %v, got %v", lines[i], expected[i].timestamp, msg.Timestamp)
+		}
+		if !reflect.DeepEqual(msg.Attrs, expected[i].attrs) {
+			t.Fatalf("expected line %q to have attributes %v, got %v", lines[i], expected[i].attrs, msg.Attrs)
+		}
+	}
+}
+
+func TestParseFormatConfig(t *testing.T) {
+	for _, cfg := range []map[string]string{{}, {FormatOpt: TextFormat}} {
+		if config, err := ParseFormatConfig(cfg); err != nil || config != nil {
+			t.Fatalf("expected no format config for %v, got %v, %v", cfg, config, err)
+		}
+	}
+	config, err := ParseFormatConfig(map[string]string{FormatOpt: JSONFormat})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.TimeKey != "" || config.LevelKey != LevelAttr {
+		t.Fatalf("unexpected format config %+v", config)
+	}
+	invalid := []map[string]string{
+		{FormatOpt: "xml"},
+		{FormatTimeKeyOpt: "time"},
+		{FormatOpt: TextFormat, FormatLevelKeyOpt: "severity"},
+		{FormatOpt: JSONFormat, FormatLevelKeyOpt: ""},
+	}
+	for _, cfg := range invalid {
+		if _, err := ParseFormatConfig(cfg); err == nil {
+			t.Fatalf("expected %v to be invalid", cfg)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, expected := range map[string]int{"emerg": 0, "FATAL": 2, "Error": 3, "warn": 4, "info": 6, "debug": 7, "5": 5} {
+		if level, ok := ParseLevel(LogAttributes{LevelAttr: s}); !ok || level != expected {
+			t.Fatalf("expected level %q to be %d, got %d, %v", s, expected, level, ok)
+		}
+	}
+	for _, attrs := range []LogAttributes{nil, {LevelAttr: "loud"}, {LevelAttr: "8"}} {
+		if _, ok := ParseLevel(attrs); ok {
+			t.Fatalf("expected no level for %v", attrs)
+		}
+	}
+}
@@ -27,6 +27,7 @@ type gelfLogger struct {
 	writer   *gelf.Writer
 	ctx      logger.Context
 	hostname string
+	extra    map[string]interface{}
 	rawExtra json.RawMessage
 }
 
@@ -119,6 +120,7 @@ func New(ctx logger.Context) (logger.Logger, error) {
 		writer:   gelfWriter,
 		ctx:      ctx,
 		hostname: hostname,
+		extra:    extra,
 		rawExtra: rawExtra,
 	}, nil
 }
@@ -128,6 +130,9 @@ func (s *gelfLogger) Log(msg *logger.Message) error {
 	if msg.Source == "stderr" {
 		level = gelf.LOG_ERR
 	}
+	if l, ok := logger.ParseLevel(msg.Attrs); ok {
+		level = int32(l)
+	}
 
 	m := gelf.Message{
 		Version:  "1.1",
@@ -135,6 +140,7 @@ func (s *gelfLogger) Log(msg *logger.Message) error {
 		Short:    string(msg.Line),
 		TimeUnix: float64(msg.Timestamp.UnixNano()/int64(time.Millisecond)) / 1000.0,
 		Level:    level,
+		Extra:    s.messageExtra(msg.Attrs),
 		RawExtra: s.rawExtra,
 	}
 
@@ -148,6 +154,38 @@ func (s *gelfLogger) Close() error {
 	return s.writer.Close()
 }
 
+// messageExtra returns the additional fields of the attributes of a
+// message. Those already set for all messages of the container are skipped.
+func (s *gelfLogger) messageExtra(attrs logger.LogAttributes) map[string]interface{} {
+	if len(attrs) == 0 {
+		return nil
+	}
+	extra := make(map[string]interface{}, len(attrs))
+	for k, v := range attrs {
+		field := gelfField(k)
+		if _, ok := s.extra[field]; ok || field == "_id" || field == "_" {
+			continue
+		}
+		extra[field] = v
+	}
+	return extra
+}
+
+// gelfField returns the name of the additional field of key, prefixed with
+// an underscore and made of word characters, dots and dashes only.
+func gelfField(key string) string {
+	field := []byte(key)
+	for i, c := range field {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-') {
+			field[i] = '_'
+		}
+	}
+	if len(field) > 0 && field[0] == '_' {
+		return string(field)
+	}
+	return "_" + string(field)
+}
+
 func (s *gelfLogger) Name() string {
 	return name
 }
@@ -28,7 +28,7 @@ type readerList struct {
 }
 
 func init() {
-	if err := logger.RegisterLogDriver(name, New); err != nil {
+	if err := logger.RegisterLogReaderDriver(name, New); err != nil {
 		logrus.Fatal(err)
 	}
 	if err := logger.RegisterLogOptValidator(name, validateLogOpt); err != nil {
@@ -84,10 +84,40 @@ func validateLogOpt(cfg map[string]string) error {
 }
 
 func (s *journald) Log(msg *logger.Message) error {
+	vars := s.vars
+	if len(msg.Attrs) > 0 {
+		vars = make(map[string]string, len(s.vars)+len(msg.Attrs))
+		for k, v := range s.vars {
+			vars[k] = v
+		}
+		// the attributes of the message don't replace the fields above
+		for k, v := range msg.Attrs {
+			field := journalField(k)
+			if _, ok := vars[field]; ok || field == "" || field == "MESSAGE" || field == "PRIORITY" {
+				continue
+			}
+			vars[field] = v
+		}
+	}
+	// the priority tells the streams apart when reading the journal, so
+	// it isn't set from the level of the message
 	if msg.Source == "stderr" {
-		return journal.Send(string(msg.Line), journal.PriErr, s.vars)
+		return journal.Send(string(msg.Line), journal.PriErr, vars)
+	}
+	return journal.Send(string(msg.Line), journal.PriInfo, vars)
+}
+
+// journalField returns the name of the journal field of key, in uppercase,
+// made of letters, digits and underscores, and not starting with an
+// underscore.
+func journalField(key string) string {
+	field := []byte(strings.ToUpper(key))
+	for i, c := range field {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			field[i] = '_'
+		}
 	}
-	return journal.Send(string(msg.Line), journal.PriInfo, s.vars)
+	return strings.TrimLeft(string(field), "_")
 }
 
 func (s *journald) Name() string {
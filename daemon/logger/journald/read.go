@@ -163,12 +163,60 @@ func (s *journald) Close() error {
 	return nil
 }
 
-func (s *journald) drainJournal(logWatcher *logger.LogWatcher, config logger.ReadConfig, j *C.sd_journal, oldCursor string) string {
-	var msg, data, cursor *C.char
+// readEntry returns the message of the current entry of the journal, or nil
+// if the entry has none. It returns false if the time of the entry can't be
+// read.
+func readEntry(j *C.sd_journal) (*logger.Message, bool) {
+	var msg, data *C.char
 	var length C.size_t
 	var stamp C.uint64_t
 	var priority C.int
 
+	i := C.get_message(j, &msg, &length)
+	if i == -C.ENOENT || i == -C.EADDRNOTAVAIL {
+		return nil, true
+	}
+	// Read the entry's timestamp.
+	if C.sd_journal_get_realtime_usec(j, &stamp) != 0 {
+		return nil, false
+	}
+	// Set up the time and text of the entry.
+	timestamp := time.Unix(int64(stamp)/1000000, (int64(stamp)%1000000)*1000)
+	line := append(C.GoBytes(unsafe.Pointer(msg), C.int(length)), "\n"...)
+	// Recover the stream name by mapping
+	// from the journal priority back to
+	// the stream that we would have
+	// assigned that value.
+	source := ""
+	if C.get_priority(j, &priority) != 0 {
+		source = ""
+	} else if priority == C.int(journal.PriErr) {
+		source = "stderr"
+	} else if priority == C.int(journal.PriInfo) {
+		source = "stdout"
+	}
+	// Retrieve the values of any variables we're adding to the journal.
+	attrs := make(map[string]string)
+	C.sd_journal_restart_data(j)
+	for C.get_attribute_field(j, &data, &length) > C.int(0) {
+		kv := strings.SplitN(C.GoStringN(data, C.int(length)), "=", 2)
+		attrs[kv[0]] = kv[1]
+	}
+	if len(attrs) == 0 {
+		attrs = nil
+	}
+	return &logger.Message{
+		Line:      line,
+		Source:    source,
+		Timestamp: timestamp.In(time.UTC),
+		Attrs:     attrs,
+	}, true
+}
+
+func (s *journald) drainJournal(logWatcher *logger.LogWatcher, config logger.ReadConfig, j *C.sd_journal, oldCursor string) (string, bool) {
+	var cursor *C.char
+	var pastUntil bool
+
 	// Walk the journal from here forward until we run out of new entries.
 drain:
 	for {
@@ -183,43 +231,19 @@ drain:
 			}
 		}
 		// Read and send the logged message, if there is one to read.
-		i := C.get_message(j, &msg, &length)
-		if i != -C.ENOENT && i != -C.EADDRNOTAVAIL {
-			// Read the entry's timestamp.
-			if C.sd_journal_get_realtime_usec(j, &stamp) != 0 {
+		m, ok := readEntry(j)
+		if !ok {
+			break
+		}
+		if m != nil {
+			// Stop at the first entry logged after the until time.
+			if config.PastUntil(m) {
+				pastUntil = true
 				break
 			}
-			// Set up the time and text of the entry.
-			timestamp := time.Unix(int64(stamp)/1000000, (int64(stamp)%1000000)*1000)
-			line := append(C.GoBytes(unsafe.Pointer(msg), C.int(length)), "\n"...)
-			// Recover the stream name by mapping
-			// from the journal priority back to
-			// the stream that we would have
-			// assigned that value.
-			source := ""
-			if C.get_priority(j, &priority) != 0 {
-				source = ""
-			} else if priority == C.int(journal.PriErr) {
-				source = "stderr"
-			} else if priority == C.int(journal.PriInfo) {
-				source = "stdout"
-			}
-			// Retrieve the values of any variables we're adding to the journal.
-			attrs := make(map[string]string)
-			C.sd_journal_restart_data(j)
-			for C.get_attribute_field(j, &data, &length) > C.int(0) {
-				kv := strings.SplitN(C.GoStringN(data, C.int(length)), "=", 2)
-				attrs[kv[0]] = kv[1]
-			}
-			if len(attrs) == 0 {
-				attrs = nil
-			}
-			// Send the log message.
-			logWatcher.Msg <- &logger.Message{
-				Line:      line,
-				Source:    source,
-				Timestamp: timestamp.In(time.UTC),
-				Attrs:     attrs,
+			// Send the log message, if selected.
+			if config.Selects(m) {
+				logWatcher.Msg <- m
 			}
 		}
 		// If we're at the end of the journal, we're done (for now).
@@ -232,7 +256,7 @@ drain:
 		retCursor = C.GoString(cursor)
 		C.free(unsafe.Pointer(cursor))
 	}
-	return retCursor
+	return retCursor, pastUntil
 }
 
 func (s *journald) followJournal(logWatcher *logger.LogWatcher, config logger.ReadConfig, j *C.sd_journal, pfd [2]C.int, cursor string) {
@@ -244,7 +268,11 @@ func (s *journald) followJournal(logWatcher *logger.LogWatcher, config logger.Re
 		// or we hit an error.
 		status := C.wait_for_data_or_close(j, pfd[0])
 		for status == 1 {
-			cursor = s.drainJournal(logWatcher, config, j, cursor)
+			var pastUntil bool
+			cursor, pastUntil = s.drainJournal(logWatcher, config, j, cursor)
+			if pastUntil {
+				break
+			}
 			status = C.wait_for_data_or_close(j, pfd[0])
 		}
 		if status < 0 {
@@ -339,7 +367,12 @@ func (s *journald) readLogs(logWatcher *logger.LogWatcher, config logger.ReadCon
 					break
 				}
 			}
-			lines--
+			// Only count the entries that will be sent.
+			if !config.Filtered() {
+				lines--
+			} else if m, ok := readEntry(j); ok && m != nil && !config.PastUntil(m) && config.Selects(m) {
+				lines--
+			}
 			// If we're at the start of the journal, or
 			// don't need to back up past any more entries,
 			// stop.
@@ -363,8 +396,9 @@ func (s *journald) readLogs(logWatcher *logger.LogWatcher, config logger.ReadCon
 			return
 		}
 	}
-	cursor = s.drainJournal(logWatcher, config, j, "")
-	if config.Follow {
+	var pastUntil bool
+	cursor, pastUntil = s.drainJournal(logWatcher, config, j, "")
+	if config.Follow && !pastUntil {
 		// Allocate a descriptor for following the journal, if we'll
 		// need one.  Do it here so that we can report if it fails.
 		if fd := C.sd_journal_get_fd(j); fd < C.int(0) {
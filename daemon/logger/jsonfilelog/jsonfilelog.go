@@ -26,11 +26,12 @@ type JSONFileLogger struct {
 	writer  *loggerutils.RotateFileWriter
 	mu      sync.Mutex
 	readers map[*logger.LogWatcher]struct{} // stores the active log followers
+	attrs   map[string]string               // extra attributes
 	extra   []byte                          // json-encoded extra attributes
 }
 
 func init() {
-	if err := logger.RegisterLogDriver(Name, New); err != nil {
+	if err := logger.RegisterLogReaderDriver(Name, New); err != nil {
 		logrus.Fatal(err)
 	}
 	if err := logger.RegisterLogOptValidator(Name, ValidateLogOpt); err != nil {
@@ -60,14 +61,26 @@ func New(ctx logger.Context) (logger.Logger, error) {
 			return nil, fmt.Errorf("max-file cannot be less than 1")
 		}
 	}
+	var compress bool
+	if compressString, ok := ctx.Config["compress"]; ok {
+		var err error
+		compress, err = strconv.ParseBool(compressString)
+		if err != nil {
+			return nil, err
+		}
+		if compress && (maxFiles < 2 || capval == -1) {
+			return nil, fmt.Errorf("compress requires max-size to be set and max-file to be at least 2")
+		}
+	}
 
-	writer, err := loggerutils.NewRotateFileWriter(ctx.LogPath, capval, maxFiles)
+	writer, err := loggerutils.NewRotateFileWriter(ctx.LogPath, capval, maxFiles, compress)
 	if err != nil {
 		return nil, err
 	}
 
 	var extra []byte
-	if attrs := ctx.ExtraAttributes(nil); len(attrs) > 0 {
+	attrs := ctx.ExtraAttributes(nil)
+	if len(attrs) > 0 {
 		var err error
 		extra, err = json.Marshal(attrs)
 		if err != nil {
@@ -79,6 +92,7 @@ func New(ctx logger.Context) (logger.Logger, error) {
 		buf:     bytes.NewBuffer(nil),
 		writer:  writer,
 		readers: make(map[*logger.LogWatcher]struct{}),
+		attrs:   attrs,
 		extra:   extra,
 	}, nil
 }
@@ -89,12 +103,26 @@ func (l *JSONFileLogger) Log(msg *logger.Message) error {
 	if err != nil {
 		return err
 	}
+	extra := l.extra
+	if len(msg.Attrs) > 0 {
+		// the attributes of the message take precedence
+		attrs := make(map[string]string, len(l.attrs)+len(msg.Attrs))
+		for k, v := range l.attrs {
+			attrs[k] = v
+		}
+		for k, v := range msg.Attrs {
+			attrs[k] = v
+		}
+		if extra, err = json.Marshal(attrs); err != nil {
+			return err
+		}
+	}
 	l.mu.Lock()
 	err = (&jsonlog.JSONLogs{
 		Log:      append(msg.Line, '\n'),
 		Stream:   msg.Source,
 		Created:  timestamp,
-		RawAttrs: l.extra,
+		RawAttrs: extra,
 	}).MarshalJSONBuf(l.buf)
 	if err != nil {
 		l.mu.Unlock()
@@ -102,25 +130,36 @@ func (l *JSONFileLogger) Log(msg *logger.Message) error {
 	}
 
 	l.buf.WriteByte('\n')
-	_, err = l.writer.Write(l.buf.Bytes())
+	_, err = l.writer.WriteEntry(l.buf.Bytes(), msg.Timestamp)
 	l.buf.Reset()
 	l.mu.Unlock()
 
 	return err
 }
 
-// ValidateLogOpt looks for json specific log options max-file & max-size.
+// ValidateLogOpt looks for json specific log options max-file, max-size &
+// compress.
 func ValidateLogOpt(cfg map[string]string) error {
 	for key := range cfg {
 		switch key {
 		case "max-file":
 		case "max-size":
+		case "compress":
 		case "labels":
 		case "env":
 		default:
 			return fmt.Errorf("unknown log opt '%s' for json-file log driver", key)
 		}
 	}
+	if s, ok := cfg["compress"]; ok {
+		compress, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for log opt 'compress': %v", s, err)
+		}
+		if maxFiles, _ := strconv.Atoi(cfg["max-file"]); compress && (maxFiles < 2 || cfg["max-size"] == "") {
+			return fmt.Errorf("log opt 'compress' requires 'max-size' to be set and 'max-file' to be at least 2")
+		}
+	}
 	return nil
 }
 
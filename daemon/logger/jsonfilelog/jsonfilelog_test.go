@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strconv"
 	"testing"
 	"time"
@@ -152,6 +153,153 @@ func TestJSONFileLoggerWithOpts(t *testing.T) {
 
 }
 
+func TestJSONFileLoggerCompress(t *testing.T) {
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "container.log")
+	config := map[string]string{"max-file": "3", "max-size": "1k", "compress": "true"}
+	if err := ValidateLogOpt(config); err != nil {
+		t.Fatal(err)
+	}
+	l, err := New(logger.Context{
+		ContainerID: cid,
+		LogPath:     filename,
+		Config:      config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 40; i++ {
+		if err := l.Log(&logger.Message{Line: []byte("line" + strconv.Itoa(i)), Source: "src1", Timestamp: time.Unix(int64(1000+i), 0).UTC()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// wait for the rotated files to be compressed
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{".1", ".2"} {
+		if _, err := os.Stat(filename + name); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be compressed, got %v", filename+name, err)
+		}
+		if _, err := os.Stat(filename + name + ".gz"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l, err = New(logger.Context{
+		ContainerID: cid,
+		LogPath:     filename,
+		Config:      config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	read := func(config logger.ReadConfig) []string {
+		var lines []string
+		for msg := range l.(logger.LogReader).ReadLogs(config).Msg {
+			lines = append(lines, string(msg.Line))
+		}
+		return lines
+	}
+	expected := func(from, to int) []string {
+		var lines []string
+		for i := from; i < to; i++ {
+			lines = append(lines, "line"+strconv.Itoa(i)+"\n")
+		}
+		return lines
+	}
+
+	if lines := read(logger.ReadConfig{Tail: -1}); !reflect.DeepEqual(lines, expected(0, 40)) {
+		t.Fatalf("unexpected logs: %q", lines)
+	}
+	if lines := read(logger.ReadConfig{Tail: 20}); !reflect.DeepEqual(lines, expected(20, 40)) {
+		t.Fatalf("unexpected tail of the logs: %q", lines)
+	}
+	if lines := read(logger.ReadConfig{Tail: -1, Since: time.Unix(1020, 0)}); !reflect.DeepEqual(lines, expected(20, 40)) {
+		t.Fatalf("unexpected logs since line20: %q", lines)
+	}
+}
+
+func TestValidateLogOptCompress(t *testing.T) {
+	for _, config := range []map[string]string{
+		{"compress": "yes"},
+		{"compress": "true", "max-size": "1k"},
+		{"compress": "true", "max-size": "1k", "max-file": "1"},
+		{"compress": "true", "max-file": "2"},
+	} {
+		if err := ValidateLogOpt(config); err == nil {
+			t.Fatalf("expected %v to be invalid", config)
+		}
+	}
+}
+
+func TestJSONFileLoggerReadFilters(t *testing.T) {
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	l, err := New(logger.Context{
+		ContainerID: cid,
+		LogPath:     filepath.Join(tmp, "container.log"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for i := 0; i < 20; i++ {
+		source := "stdout"
+		if i%2 == 1 {
+			source = "stderr"
+		}
+		if err := l.Log(&logger.Message{Line: []byte("line" + strconv.Itoa(i)), Source: source, Timestamp: time.Unix(int64(1000+i), 0).UTC()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	read := func(config logger.ReadConfig) []string {
+		watcher := l.(logger.LogReader).ReadLogs(config)
+		defer watcher.Close()
+		var lines []string
+		for {
+			select {
+			case msg, ok := <-watcher.Msg:
+				if !ok {
+					return lines
+				}
+				lines = append(lines, string(msg.Line))
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timeout reading logs with %+v", config)
+			}
+		}
+	}
+
+	if lines := read(logger.ReadConfig{Tail: -1, Until: time.Unix(1002, 0)}); !reflect.DeepEqual(lines, []string{"line0\n", "line1\n", "line2\n"}) {
+		t.Fatalf("unexpected logs until line2: %q", lines)
+	}
+	// the logs are not followed past the until time
+	if lines := read(logger.ReadConfig{Tail: 2, Until: time.Unix(1018, 0), Follow: true}); !reflect.DeepEqual(lines, []string{"line17\n", "line18\n"}) {
+		t.Fatalf("unexpected tail of the logs until line18: %q", lines)
+	}
+	if lines := read(logger.ReadConfig{Tail: -1, Source: "stderr", Filter: regexp.MustCompile("^line1")}); !reflect.DeepEqual(lines, []string{"line1\n", "line11\n", "line13\n", "line15\n", "line17\n", "line19\n"}) {
+		t.Fatalf("unexpected filtered logs: %q", lines)
+	}
+	// the tail counts the selected messages
+	if lines := read(logger.ReadConfig{Tail: 3, Filter: regexp.MustCompile("^line1")}); !reflect.DeepEqual(lines, []string{"line17\n", "line18\n", "line19\n"}) {
+		t.Fatalf("unexpected tail of the filtered logs: %q", lines)
+	}
+	if lines := read(logger.ReadConfig{Tail: 2, Source: "stdout", Filter: regexp.MustCompile("^line[0-9]$")}); !reflect.DeepEqual(lines, []string{"line6\n", "line8\n"}) {
+		t.Fatalf("unexpected tail of the filtered stdout logs: %q", lines)
+	}
+}
+
 func TestJSONFileLoggerWithLabelsEnv(t *testing.T) {
 	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
 	tmp, err := ioutil.TempDir("", "docker-logger-")
@@ -200,6 +348,50 @@ func TestJSONFileLoggerWithLabelsEnv(t *testing.T) {
 	}
 }
 
+func TestJSONFileLoggerWithMessageAttrs(t *testing.T) {
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "container.log")
+	l, err := New(logger.Context{
+		ContainerID:     cid,
+		LogPath:         filename,
+		Config:          map[string]string{"labels": "rack,dc"},
+		ContainerLabels: map[string]string{"rack": "101", "dc": "lhr"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := l.Log(&logger.Message{Line: []byte("line"), Source: "src1", Attrs: logger.LogAttributes{"level": "error", "dc": "fra"}}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jsonLog jsonlog.JSONLogs
+	if err := json.Unmarshal(res, &jsonLog); err != nil {
+		t.Fatal(err)
+	}
+	extra := make(map[string]string)
+	if err := json.Unmarshal(jsonLog.RawAttrs, &extra); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"rack":  "101",
+		"dc":    "fra",
+		"level": "error",
+	}
+	if !reflect.DeepEqual(extra, expected) {
+		t.Fatalf("Wrong log attrs: %q, expected %q", extra, expected)
+	}
+}
+
 func BenchmarkJSONFileLoggerWithReader(b *testing.B) {
 	b.StopTimer()
 	b.ResetTimer()
@@ -1,23 +1,31 @@
 package jsonfilelog
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
 	"github.com/docker/docker/pkg/filenotify"
-	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/jsonlog"
 	"github.com/docker/docker/pkg/tailfile"
 )
 
 const maxJSONDecodeRetry = 20000
 
+// errPastUntil stops reading the files once a message logged after the Until
+// time of the read config is found.
+var errPastUntil = errors.New("message logged after the until time")
+
 func decodeLogLine(dec *json.Decoder, l *jsonlog.JSONLog) (*logger.Message, error) {
 	l.Reset()
 	if err := dec.Decode(l); err != nil {
@@ -45,9 +53,9 @@ func (l *JSONFileLogger) readLogs(logWatcher *logger.LogWatcher, config logger.R
 	defer close(logWatcher.Msg)
 
 	pth := l.writer.LogPath()
-	var files []io.ReadSeeker
+	var files []*logFile
 	for i := l.writer.MaxFiles(); i > 1; i-- {
-		f, err := os.Open(fmt.Sprintf("%s.%d", pth, i-1))
+		f, err := openLogFile(fmt.Sprintf("%s.%d", pth, i-1))
 		if err != nil {
 			if !os.IsNotExist(err) {
 				logWatcher.Err <- err
@@ -58,25 +66,38 @@ func (l *JSONFileLogger) readLogs(logWatcher *logger.LogWatcher, config logger.R
 		files = append(files, f)
 	}
 
-	latestFile, err := os.Open(pth)
+	latestFile, err := openLogFile(pth)
 	if err != nil {
+		for _, f := range files {
+			f.Close()
+		}
 		logWatcher.Err <- err
 		return
 	}
+	files = append(files, latestFile)
+	if !config.Since.IsZero() {
+		files = skipFilesBefore(files, config.Since)
+	}
 
+	var pastUntil bool
 	if config.Tail != 0 {
-		tailer := ioutils.MultiReadSeeker(append(files, latestFile)...)
-		tailFile(tailer, logWatcher, config.Tail, config.Since)
+		if err := tailFiles(files, logWatcher, config); err != nil {
+			if err == errPastUntil {
+				pastUntil = true
+			} else {
+				logWatcher.Err <- err
+			}
+		}
 	}
 
 	// close all the rotated files
-	for _, f := range files {
-		if err := f.(io.Closer).Close(); err != nil {
+	for _, f := range files[:len(files)-1] {
+		if err := f.Close(); err != nil {
 			logrus.WithField("logger", "json-file").Warnf("error closing tailed log file: %v", err)
 		}
 	}
 
-	if !config.Follow {
+	if !config.Follow || pastUntil {
 		if err := latestFile.Close(); err != nil {
 			logrus.Errorf("Error closing file: %v", err)
 		}
@@ -92,7 +113,7 @@ func (l *JSONFileLogger) readLogs(logWatcher *logger.LogWatcher, config logger.R
 	l.mu.Unlock()
 
 	notifyRotate := l.writer.NotifyRotate()
-	followLogs(latestFile, logWatcher, notifyRotate, config.Since)
+	followLogs(latestFile.File, logWatcher, notifyRotate, config)
 
 	l.mu.Lock()
 	delete(l.readers, logWatcher)
@@ -101,34 +122,180 @@ func (l *JSONFileLogger) readLogs(logWatcher *logger.LogWatcher, config logger.R
 	l.writer.NotifyRotateEvict(notifyRotate)
 }
 
-func tailFile(f io.ReadSeeker, logWatcher *logger.LogWatcher, tail int, since time.Time) {
-	var rdr io.Reader = f
-	if tail > 0 {
-		ls, err := tailfile.TailFile(f, tail)
+// logFile is a log file, rotated or not, and its time index. Rotated files
+// may be compressed.
+type logFile struct {
+	*os.File
+	compressed bool
+	index      []loggerutils.IndexEntry
+}
+
+func openLogFile(path string) (*logFile, error) {
+	var compressed bool
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		f, err = os.Open(path + loggerutils.CompressedSuffix)
+		compressed = true
+	}
+	if err != nil {
+		return nil, err
+	}
+	index, err := loggerutils.ReadIndex(path)
+	if err != nil {
+		logrus.WithField("logger", "json-file").Warnf("error reading the time index of log file %s: %v", path, err)
+	}
+	return &logFile{File: f, compressed: compressed, index: index}, nil
+}
+
+// readerFrom returns a reader of the uncompressed content of the file from
+// offset.
+func (f *logFile) readerFrom(offset int64) (io.Reader, error) {
+	if !f.compressed {
+		if _, err := f.Seek(offset, os.SEEK_SET); err != nil {
+			return nil, err
+		}
+		return f.File, nil
+	}
+	if _, err := f.Seek(0, os.SEEK_SET); err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(f.File)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(ioutil.Discard, gz, offset); err != nil {
+		return nil, err
+	}
+	return gz, nil
+}
+
+// tail returns the last n lines of the file.
+func (f *logFile) tail(n int) ([][]byte, error) {
+	if !f.compressed {
+		return tailfile.TailFile(f.File, n)
+	}
+	rdr, err := f.readerFrom(0)
+	if err != nil {
+		return nil, err
+	}
+	var lines [][]byte
+	br := bufio.NewReader(rdr)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			lines = append(lines, bytes.TrimSuffix(line, []byte{'\n'}))
+			if len(lines) > n {
+				lines = lines[1:]
+			}
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
 		if err != nil {
-			logWatcher.Err <- err
-			return
+			return nil, err
 		}
-		rdr = bytes.NewBuffer(bytes.Join(ls, []byte("\n")))
 	}
+}
+
+// skipFilesBefore closes the files, oldest first, whose messages were all
+// logged before since, according to the index of the file following them.
+// The latest file is always kept.
+func skipFilesBefore(files []*logFile, since time.Time) []*logFile {
+	for len(files) > 1 {
+		next := files[1].index
+		if len(next) == 0 || next[0].Offset != 0 || !next[0].Time.Before(since) {
+			break
+		}
+		files[0].Close()
+		files = files[1:]
+	}
+	return files
+}
+
+// tailFiles sends the last config.Tail messages of the files, all of them
+// if it is negative, skipping those logged before config.Since. It returns
+// errPastUntil if it stopped at a message logged after config.Until.
+func tailFiles(files []*logFile, logWatcher *logger.LogWatcher, config logger.ReadConfig) error {
+	send := func(msg *logger.Message) {
+		logWatcher.Msg <- msg
+	}
+	if config.Tail < 0 {
+		return readFiles(files, config, send)
+	}
+
+	if config.Filtered() {
+		// the selected messages can't be counted from the end of the
+		// files, so they are all read, keeping the last ones
+		tail := make([]*logger.Message, 0, config.Tail)
+		err := readFiles(files, config, func(msg *logger.Message) {
+			if len(tail) == config.Tail {
+				copy(tail, tail[1:])
+				tail = tail[:len(tail)-1]
+			}
+			tail = append(tail, msg)
+		})
+		for _, msg := range tail {
+			send(msg)
+		}
+		return err
+	}
+
+	var lines [][]byte
+	for i := len(files) - 1; i >= 0 && len(lines) < config.Tail; i-- {
+		ls, err := files[i].tail(config.Tail - len(lines))
+		if err != nil {
+			return err
+		}
+		lines = append(ls, lines...)
+	}
+	return decodeLogLines(bytes.NewBuffer(bytes.Join(lines, []byte("\n"))), config, send)
+}
+
+// readFiles passes the messages of the files selected by config to send,
+// skipping those logged before config.Since. It returns errPastUntil if it
+// stopped at a message logged after config.Until.
+func readFiles(files []*logFile, config logger.ReadConfig, send func(*logger.Message)) error {
+	for _, f := range files {
+		var offset int64
+		if !config.Since.IsZero() {
+			offset = loggerutils.SeekOffset(f.index, config.Since)
+		}
+		rdr, err := f.readerFrom(offset)
+		if err != nil {
+			return err
+		}
+		if err := decodeLogLines(rdr, config, send); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeLogLines(rdr io.Reader, config logger.ReadConfig, send func(*logger.Message)) error {
 	dec := json.NewDecoder(rdr)
 	l := &jsonlog.JSONLog{}
 	for {
 		msg, err := decodeLogLine(dec, l)
 		if err != nil {
 			if err != io.EOF {
-				logWatcher.Err <- err
+				return err
 			}
-			return
+			return nil
 		}
-		if !since.IsZero() && msg.Timestamp.Before(since) {
+		if !config.Since.IsZero() && msg.Timestamp.Before(config.Since) {
 			continue
 		}
-		logWatcher.Msg <- msg
+		if config.PastUntil(msg) {
+			return errPastUntil
+		}
+		if !config.Selects(msg) {
+			continue
+		}
+		send(msg)
 	}
 }
 
-func followLogs(f *os.File, logWatcher *logger.LogWatcher, notifyRotate chan interface{}, since time.Time) {
+func followLogs(f *os.File, logWatcher *logger.LogWatcher, notifyRotate chan interface{}, config logger.ReadConfig) {
 	dec := json.NewDecoder(f)
 	l := &jsonlog.JSONLog{}
 
@@ -217,7 +384,13 @@ func followLogs(f *os.File, logWatcher *logger.LogWatcher, notifyRotate chan int
 		}
 
 		retries = 0 // reset retries since we've succeeded
-		if !since.IsZero() && msg.Timestamp.Before(since) {
+		if !config.Since.IsZero() && msg.Timestamp.Before(config.Since) {
+			continue
+		}
+		if config.PastUntil(msg) {
+			return
+		}
+		if !config.Selects(msg) {
 			continue
 		}
 		select {
@@ -226,10 +399,10 @@ func followLogs(f *os.File, logWatcher *logger.LogWatcher, notifyRotate chan int
 			logWatcher.Msg <- msg
 			for {
 				msg, err := decodeLogLine(dec, l)
-				if err != nil {
+				if err != nil || config.PastUntil(msg) {
 					return
 				}
-				if !since.IsZero() && msg.Timestamp.Before(since) {
+				if !config.Since.IsZero() && msg.Timestamp.Before(config.Since) || !config.Selects(msg) {
 					continue
 				}
 				logWatcher.Msg <- msg
@@ -0,0 +1,374 @@
+// Package kafka provides the log driver for forwarding server logs to
+// Kafka brokers.
+package kafka
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+const (
+	name = "kafka"
+
+	defaultPort          = "9092"
+	defaultTopicTemplate = "docker"
+	defaultBatchTimeout  = time.Second
+
+	brokersKey       = "kafka-brokers"
+	topicKey         = "kafka-topic"
+	partitionKeyKey  = "kafka-partition-key"
+	requiredAcksKey  = "kafka-required-acks"
+	batchSizeKey     = "kafka-batch-size"
+	batchTimeoutKey  = "kafka-batch-timeout"
+	compressionKey   = "kafka-compression"
+	bufferSizeKey    = "kafka-buffer-size"
+	tlsKey           = "kafka-tls"
+	tlsCACertKey     = "kafka-tls-ca-cert"
+	tlsCertKey       = "kafka-tls-cert"
+	tlsKeyKey        = "kafka-tls-key"
+	tlsSkipVerifyKey = "kafka-tls-skip-verify"
+	saslUserKey      = "kafka-sasl-user"
+	saslPasswordKey  = "kafka-sasl-password"
+)
+
+var errClosed = errors.New("kafka: logger is closed")
+
+type kafkaLogger struct {
+	topic         string
+	key           sarama.Encoder // nil to spread messages over partitions
+	containerID   string
+	containerName string
+	tag           string
+	extra         map[string]string
+
+	producer sarama.AsyncProducer
+	errs     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func init() {
+	if err := logger.RegisterLogDriver(name, New); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := logger.RegisterLogOptValidator(name, ValidateLogOpt); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// New creates a kafka logger using the configuration passed in on the
+// context. The kafka-brokers configuration variable is required.
+func New(ctx logger.Context) (logger.Logger, error) {
+	brokers, err := parseBrokers(ctx.Config[brokersKey])
+	if err != nil {
+		return nil, err
+	}
+
+	topicTemplate := ctx.Config[topicKey]
+	if topicTemplate == "" {
+		topicTemplate = defaultTopicTemplate
+	}
+	topic, err := loggerutils.ParseLogTemplate(ctx, "kafka-topic", topicTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if topic == "" {
+		return nil, fmt.Errorf("kafka: topic %q is empty for container %s", topicTemplate, ctx.ContainerID)
+	}
+
+	tag, err := loggerutils.ParseLogTag(ctx, "{{.ID}}")
+	if err != nil {
+		return nil, err
+	}
+
+	containerName := strings.TrimPrefix(ctx.ContainerName, "/")
+	var key sarama.Encoder
+	switch ctx.Config[partitionKeyKey] {
+	case "", "container-id":
+		key = sarama.StringEncoder(ctx.ContainerID)
+	case "container-name":
+		key = sarama.StringEncoder(containerName)
+	case "tag":
+		key = sarama.StringEncoder(tag)
+	case "none":
+	}
+
+	config, err := parseConfig(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
+	producer, err := sarama.NewAsyncProducer(brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("kafka: cannot connect to brokers %s: %v", strings.Join(brokers, ","), err)
+	}
+
+	l := &kafkaLogger{
+		topic:         topic,
+		key:           key,
+		containerID:   ctx.ContainerID,
+		containerName: containerName,
+		tag:           tag,
+		extra:         ctx.ExtraAttributes(nil),
+		producer:      producer,
+	}
+	l.errs.Add(1)
+	go l.logErrors()
+	return l, nil
+}
+
+// parseConfig returns the configuration of the producer set by the log
+// options.
+func parseConfig(cfg map[string]string) (*sarama.Config, error) {
+	config := sarama.NewConfig()
+	config.ClientID = "docker"
+	config.Producer.Return.Successes = false
+	config.Producer.Return.Errors = true
+
+	switch cfg[requiredAcksKey] {
+	case "", "local":
+		config.Producer.RequiredAcks = sarama.WaitForLocal
+	case "none":
+		config.Producer.RequiredAcks = sarama.NoResponse
+	case "all":
+		config.Producer.RequiredAcks = sarama.WaitForAll
+	default:
+		return nil, fmt.Errorf("kafka: invalid %s %q, expected none, local or all", requiredAcksKey, cfg[requiredAcksKey])
+	}
+
+	if s, ok := cfg[batchSizeKey]; ok {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("kafka: invalid %s %q, expected a positive number of messages", batchSizeKey, s)
+		}
+		config.Producer.Flush.Messages = n
+	}
+	if s, ok := cfg[batchTimeoutKey]; ok {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("kafka: invalid %s %q, expected a positive duration", batchTimeoutKey, s)
+		}
+		config.Producer.Flush.Frequency = d
+	} else if config.Producer.Flush.Messages > 0 {
+		// the producer only sends full batches otherwise, and never
+		// closes with a partial one
+		config.Producer.Flush.Frequency = defaultBatchTimeout
+	}
+	if s, ok := cfg[bufferSizeKey]; ok {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("kafka: invalid %s %q, expected a positive number of messages", bufferSizeKey, s)
+		}
+		config.ChannelBufferSize = n
+	}
+
+	switch cfg[compressionKey] {
+	case "", "none":
+		config.Producer.Compression = sarama.CompressionNone
+	case "gzip":
+		config.Producer.Compression = sarama.CompressionGZIP
+	case "snappy":
+		config.Producer.Compression = sarama.CompressionSnappy
+	default:
+		return nil, fmt.Errorf("kafka: invalid %s %q, expected none, gzip or snappy", compressionKey, cfg[compressionKey])
+	}
+
+	if s, ok := cfg[tlsKey]; ok {
+		enabled, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: invalid %s %q: %v", tlsKey, s, err)
+		}
+		if enabled {
+			tlsConfig, err := parseTLSConfig(cfg)
+			if err != nil {
+				return nil, err
+			}
+			config.Net.TLS.Enable = true
+			config.Net.TLS.Config = tlsConfig
+		}
+	}
+	if !config.Net.TLS.Enable {
+		for _, key := range []string{tlsCACertKey, tlsCertKey, tlsKeyKey, tlsSkipVerifyKey} {
+			if _, ok := cfg[key]; ok {
+				return nil, fmt.Errorf("kafka: %s requires %s=true", key, tlsKey)
+			}
+		}
+	}
+
+	user, password := cfg[saslUserKey], cfg[saslPasswordKey]
+	if user != "" || password != "" {
+		if user == "" || password == "" {
+			return nil, fmt.Errorf("kafka: %s and %s must be set together", saslUserKey, saslPasswordKey)
+		}
+		config.Net.SASL.Enable = true
+		config.Net.SASL.User = user
+		config.Net.SASL.Password = password
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("kafka: %v", err)
+	}
+	return config, nil
+}
+
+func parseTLSConfig(cfg map[string]string) (*tls.Config, error) {
+	var skipVerify bool
+	if s, ok := cfg[tlsSkipVerifyKey]; ok {
+		var err error
+		if skipVerify, err = strconv.ParseBool(s); err != nil {
+			return nil, fmt.Errorf("kafka: invalid %s %q: %v", tlsSkipVerifyKey, s, err)
+		}
+	}
+
+	opts := tlsconfig.Options{
+		CAFile:             cfg[tlsCACertKey],
+		CertFile:           cfg[tlsCertKey],
+		KeyFile:            cfg[tlsKeyKey],
+		InsecureSkipVerify: skipVerify,
+	}
+
+	return tlsconfig.Client(opts)
+}
+
+// parseBrokers returns the addresses of a comma separated list of brokers,
+// with the default port if they have none.
+func parseBrokers(s string) ([]string, error) {
+	if s == "" {
+		return nil, fmt.Errorf("kafka: %s is required", brokersKey)
+	}
+	var brokers []string
+	for _, broker := range strings.Split(s, ",") {
+		broker = strings.TrimSpace(broker)
+		if broker == "" {
+			return nil, fmt.Errorf("kafka: invalid %s %q", brokersKey, s)
+		}
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			if !strings.Contains(err.Error(), "missing port in address") {
+				return nil, fmt.Errorf("kafka: invalid broker address %s: %v", broker, err)
+			}
+			broker = net.JoinHostPort(broker, defaultPort)
+		}
+		brokers = append(brokers, broker)
+	}
+	return brokers, nil
+}
+
+// logErrors logs the messages that could not be delivered, until the
+// producer is closed.
+func (l *kafkaLogger) logErrors() {
+	defer l.errs.Done()
+	for err := range l.producer.Errors() {
+		logrus.WithField("container", l.containerID).Errorf("kafka: cannot send message to topic %s: %v", l.topic, err.Err)
+	}
+}
+
+// Log queues the message for the producer, which sends it in batches. It
+// blocks while the buffer of the producer is full.
+func (l *kafkaLogger) Log(msg *logger.Message) error {
+	data := map[string]string{
+		"container_id":   l.containerID,
+		"container_name": l.containerName,
+		"tag":            l.tag,
+		"source":         msg.Source,
+		"log":            string(msg.Line),
+		"time":           msg.Timestamp.UTC().Format(logger.TimeFormat),
+	}
+	for k, v := range l.extra {
+		data[k] = v
+	}
+	// the attributes of the message don't replace the keys above
+	for k, v := range msg.Attrs {
+		if _, ok := data[k]; !ok {
+			data[k] = v
+		}
+	}
+	value, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		return errClosed
+	}
+	l.producer.Input() <- &sarama.ProducerMessage{
+		Topic: l.topic,
+		Key:   l.key,
+		Value: sarama.ByteEncoder(value),
+	}
+	return nil
+}
+
+// Close sends the queued messages and closes the connections to the
+// brokers.
+func (l *kafkaLogger) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	l.producer.AsyncClose()
+	l.errs.Wait()
+	return nil
+}
+
+func (l *kafkaLogger) Name() string {
+	return name
+}
+
+// ValidateLogOpt looks for kafka specific log options.
+func ValidateLogOpt(cfg map[string]string) error {
+	for key := range cfg {
+		switch key {
+		case "env":
+		case "labels":
+		case "tag":
+		case brokersKey:
+		case topicKey:
+		case partitionKeyKey:
+		case requiredAcksKey:
+		case batchSizeKey:
+		case batchTimeoutKey:
+		case compressionKey:
+		case bufferSizeKey:
+		case tlsKey:
+		case tlsCACertKey:
+		case tlsCertKey:
+		case tlsKeyKey:
+		case tlsSkipVerifyKey:
+		case saslUserKey:
+		case saslPasswordKey:
+			// Accepted
+		default:
+			return fmt.Errorf("unknown log opt '%s' for kafka log driver", key)
+		}
+	}
+
+	if _, err := parseBrokers(cfg[brokersKey]); err != nil {
+		return err
+	}
+	switch cfg[partitionKeyKey] {
+	case "", "container-id", "container-name", "tag", "none":
+	default:
+		return fmt.Errorf("kafka: invalid %s %q, expected container-id, container-name, tag or none", partitionKeyKey, cfg[partitionKeyKey])
+	}
+	_, err := parseConfig(cfg)
+	return err
+}
@@ -0,0 +1,499 @@
+package kafka
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+	"github.com/eapache/go-xerial-snappy"
+)
+
+const (
+	produceAPIKey  = 0
+	metadataAPIKey = 3
+
+	fakePartitions = 2
+)
+
+// fakeMessage is a message received by a fakeBroker.
+type fakeMessage struct {
+	topic     string
+	partition int32
+	key       []byte
+	value     []byte
+}
+
+// fakeBroker is a Kafka broker serving the metadata and produce requests of
+// version 0, and keeping the messages it receives. It leads all the
+// partitions of all the topics.
+type fakeBroker struct {
+	t        *testing.T
+	listener net.Listener
+
+	mu       sync.Mutex
+	messages []fakeMessage
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeBroker{t: t, listener: l}
+	go b.serve()
+	return b
+}
+
+func (b *fakeBroker) Addr() string {
+	return b.listener.Addr().String()
+}
+
+func (b *fakeBroker) Close() {
+	b.listener.Close()
+}
+
+func (b *fakeBroker) Messages() []fakeMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]fakeMessage(nil), b.messages...)
+}
+
+func (b *fakeBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *fakeBroker) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var size int32
+		if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+			return
+		}
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		req := &decoder{buf: buf}
+		apiKey, _, correlationID := req.int16(), req.int16(), req.int32()
+		req.string() // client id
+
+		res := &encoder{}
+		res.int32(correlationID)
+		switch apiKey {
+		case metadataAPIKey:
+			b.metadata(req, res)
+		case produceAPIKey:
+			if !b.produce(req, res) {
+				continue
+			}
+		default:
+			b.t.Errorf("unexpected request %d", apiKey)
+			return
+		}
+		if req.err != nil {
+			b.t.Errorf("invalid request %d: %v", apiKey, req.err)
+			return
+		}
+
+		out := &encoder{}
+		out.int32(int32(res.buf.Len()))
+		out.buf.Write(res.buf.Bytes())
+		if _, err := conn.Write(out.buf.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+func (b *fakeBroker) metadata(req *decoder, res *encoder) {
+	topics := make([]string, req.int32())
+	for i := range topics {
+		topics[i] = req.string()
+	}
+
+	host, portString, _ := net.SplitHostPort(b.Addr())
+	port, _ := strconv.Atoi(portString)
+	res.int32(1)
+	res.int32(1) // node id
+	res.string(host)
+	res.int32(int32(port))
+
+	res.int32(int32(len(topics)))
+	for _, topic := range topics {
+		res.int16(0)
+		res.string(topic)
+		res.int32(fakePartitions)
+		for p := int32(0); p < fakePartitions; p++ {
+			res.int16(0)
+			res.int32(p)
+			res.int32(1) // leader
+			res.int32(1) // replicas
+			res.int32(1)
+			res.int32(1) // isr
+			res.int32(1)
+		}
+	}
+}
+
+// produce keeps the messages of the request, and reports whether it needs
+// a response.
+func (b *fakeBroker) produce(req *decoder, res *encoder) bool {
+	acks := req.int16()
+	req.int32() // timeout
+
+	type partitionOffset struct {
+		partition int32
+		offset    int64
+	}
+	var responses []string
+	offsets := make(map[string][]partitionOffset)
+	for topics := req.int32(); topics > 0; topics-- {
+		topic := req.string()
+		responses = append(responses, topic)
+		for partitions := req.int32(); partitions > 0; partitions-- {
+			partition := req.int32()
+			set := req.bytes()
+			b.mu.Lock()
+			offsets[topic] = append(offsets[topic], partitionOffset{partition, int64(len(b.messages))})
+			b.mu.Unlock()
+			b.decodeMessageSet(topic, partition, set)
+		}
+	}
+	if acks == 0 {
+		return false
+	}
+
+	res.int32(int32(len(responses)))
+	for _, topic := range responses {
+		res.string(topic)
+		res.int32(int32(len(offsets[topic])))
+		for _, o := range offsets[topic] {
+			res.int32(o.partition)
+			res.int16(0)
+			res.int64(o.offset)
+		}
+	}
+	return true
+}
+
+func (b *fakeBroker) decodeMessageSet(topic string, partition int32, set []byte) {
+	d := &decoder{buf: set}
+	for len(d.buf) > 0 && d.err == nil {
+		d.int64() // offset
+		msg := &decoder{buf: d.bytes()}
+		msg.int32() // crc
+		magic, attributes := msg.int8(), msg.int8()
+		if magic > 0 {
+			msg.int64() // timestamp
+		}
+		key, value := msg.bytes(), msg.bytes()
+		if msg.err != nil {
+			d.err = msg.err
+			break
+		}
+
+		switch attributes & 0x07 {
+		case 0:
+			b.mu.Lock()
+			b.messages = append(b.messages, fakeMessage{topic: topic, partition: partition, key: key, value: value})
+			b.mu.Unlock()
+		case 1:
+			r, err := gzip.NewReader(bytes.NewReader(value))
+			if err != nil {
+				b.t.Error(err)
+				return
+			}
+			inner, err := ioutil.ReadAll(r)
+			if err != nil {
+				b.t.Error(err)
+				return
+			}
+			b.decodeMessageSet(topic, partition, inner)
+		case 2:
+			inner, err := snappy.Decode(value)
+			if err != nil {
+				b.t.Error(err)
+				return
+			}
+			b.decodeMessageSet(topic, partition, inner)
+		default:
+			b.t.Errorf("unexpected compression %d", attributes&0x07)
+			return
+		}
+	}
+	if d.err != nil {
+		b.t.Errorf("invalid message set: %v", d.err)
+	}
+}
+
+// decoder reads the big-endian values of the Kafka protocol.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.buf) {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) int8() int8 {
+	if b := d.next(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *decoder) int16() int16 {
+	if b := d.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *decoder) int32() int32 {
+	if b := d.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *decoder) int64() int64 {
+	if b := d.next(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *decoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.int32()
+	if n < 0 {
+		return nil
+	}
+	return d.next(int(n))
+}
+
+// encoder writes the big-endian values of the Kafka protocol.
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) int16(v int16) {
+	binary.Write(&e.buf, binary.BigEndian, v)
+}
+
+func (e *encoder) int32(v int32) {
+	binary.Write(&e.buf, binary.BigEndian, v)
+}
+
+func (e *encoder) int64(v int64) {
+	binary.Write(&e.buf, binary.BigEndian, v)
+}
+
+func (e *encoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.buf.WriteString(s)
+}
+
+func newContext(config map[string]string) logger.Context {
+	return logger.Context{
+		Config:          config,
+		ContainerID:     "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657",
+		ContainerName:   "/web",
+		ContainerLabels: map[string]string{"rack": "101"},
+	}
+}
+
+func logMessages(t *testing.T, l logger.Logger, n int) {
+	for i := 0; i < n; i++ {
+		msg := &logger.Message{
+			Line:      []byte("line" + strconv.Itoa(i)),
+			Source:    "stdout",
+			Timestamp: time.Unix(1000, 0),
+			Attrs:     logger.LogAttributes{"level": "info", "source": "ignored"},
+		}
+		if err := l.Log(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the queued messages are sent on close
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Log(&logger.Message{Line: []byte("late")}); err == nil {
+		t.Fatal("expected logging after close to fail")
+	}
+}
+
+func TestKafkaLogger(t *testing.T) {
+	broker := newFakeBroker(t)
+	defer broker.Close()
+
+	ctx := newContext(map[string]string{
+		brokersKey:      broker.Addr(),
+		topicKey:        "logs-{{.Name}}",
+		batchTimeoutKey: "10ms",
+		"labels":        "rack",
+	})
+	l, err := New(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logMessages(t, l, 3)
+
+	messages := broker.Messages()
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(messages))
+	}
+	for i, m := range messages {
+		if m.topic != "logs-web" {
+			t.Fatalf("expected topic logs-web, got %s", m.topic)
+		}
+		if string(m.key) != ctx.ContainerID || m.partition != messages[0].partition {
+			t.Fatalf("expected the messages to be keyed by container ID, got key %q on partition %d", m.key, m.partition)
+		}
+		var data map[string]string
+		if err := json.Unmarshal(m.value, &data); err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]string{
+			"container_id":   ctx.ContainerID,
+			"container_name": "web",
+			"tag":            ctx.ContainerID[:12],
+			"source":         "stdout",
+			"log":            "line" + strconv.Itoa(i),
+			"time":           "1970-01-01T00:16:40.000000000Z",
+			"rack":           "101",
+			"level":          "info",
+		}
+		if len(data) != len(expected) {
+			t.Fatalf("expected message %v, got %v", expected, data)
+		}
+		for k, v := range expected {
+			if data[k] != v {
+				t.Fatalf("expected message %v, got %v", expected, data)
+			}
+		}
+	}
+}
+
+func TestKafkaLoggerCompression(t *testing.T) {
+	for _, compression := range []string{"gzip", "snappy"} {
+		broker := newFakeBroker(t)
+		l, err := New(newContext(map[string]string{
+			brokersKey:      broker.Addr(),
+			compressionKey:  compression,
+			partitionKeyKey: "none",
+			batchSizeKey:    "5",
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		logMessages(t, l, 10)
+
+		messages := broker.Messages()
+		broker.Close()
+		if len(messages) != 10 {
+			t.Fatalf("expected 10 messages compressed with %s, got %d", compression, len(messages))
+		}
+		for _, m := range messages {
+			if m.topic != defaultTopicTemplate || m.key != nil {
+				t.Fatalf("expected unkeyed messages on topic %s, got key %q on topic %s", defaultTopicTemplate, m.key, m.topic)
+			}
+		}
+	}
+}
+
+func TestKafkaLoggerNoBroker(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	if _, err := New(newContext(map[string]string{brokersKey: addr})); err == nil {
+		t.Fatal("expected the logger to fail without brokers")
+	}
+}
+
+func TestParseTLSConfigSkipVerify(t *testing.T) {
+	for value, expected := range map[string]bool{"true": true, "1": true, "false": false, "0": false} {
+		config, err := parseTLSConfig(map[string]string{tlsSkipVerifyKey: value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.InsecureSkipVerify != expected {
+			t.Fatalf("expected %s=%s to set InsecureSkipVerify to %v", tlsSkipVerifyKey, value, expected)
+		}
+	}
+	if config, err := parseTLSConfig(map[string]string{}); err != nil || config.InsecureSkipVerify {
+		t.Fatalf("expected the brokers to be verified by default, got %v", err)
+	}
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	valid := []map[string]string{
+		{brokersKey: "kafka1,kafka2:9093"},
+		{brokersKey: "kafka1", topicKey: "logs-{{.Name}}", partitionKeyKey: "container-name", requiredAcksKey: "all"},
+		{brokersKey: "kafka1", batchSizeKey: "100", batchTimeoutKey: "1s", bufferSizeKey: "1024", compressionKey: "snappy"},
+		{brokersKey: "kafka1", tlsKey: "true", tlsSkipVerifyKey: "true"},
+		{brokersKey: "kafka1", tlsKey: "true", tlsSkipVerifyKey: "false"},
+		{brokersKey: "kafka1", saslUserKey: "docker", saslPasswordKey: "secret"},
+	}
+	for _, cfg := range valid {
+		if err := ValidateLogOpt(cfg); err != nil {
+			t.Fatalf("expected %v to be valid, got %v", cfg, err)
+		}
+	}
+	invalid := []map[string]string{
+		{},
+		{brokersKey: "kafka1,"},
+		{brokersKey: "kafka1", "kafka-unknown": "1"},
+		{brokersKey: "kafka1", partitionKeyKey: "image"},
+		{brokersKey: "kafka1", requiredAcksKey: "some"},
+		{brokersKey: "kafka1", batchSizeKey: "0"},
+		{brokersKey: "kafka1", batchTimeoutKey: "soon"},
+		{brokersKey: "kafka1", bufferSizeKey: "-1"},
+		{brokersKey: "kafka1", compressionKey: "lz4"},
+		{brokersKey: "kafka1", tlsKey: "maybe"},
+		{brokersKey: "kafka1", tlsSkipVerifyKey: "true"},
+		{brokersKey: "kafka1", tlsKey: "true", tlsSkipVerifyKey: "maybe"},
+		{brokersKey: "kafka1", saslUserKey: "docker"},
+	}
+	for _, cfg := range invalid {
+		if err := ValidateLogOpt(cfg); err == nil {
+			t.Fatalf("expected %v to be invalid", cfg)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package logdriver defines the format of the log entries exchanged with
+// log driver plugins.
+//
+// Entries are sent as frames made of the length of the encoded entry, a
+// big-endian uint32, followed by the entry. An entry is its source, its
+// timestamp in nanoseconds since the epoch, its line, and its attributes, in
+// that order. Strings and byte slices are encoded as their uvarint length
+// followed by their bytes, the timestamp as a varint, and the attributes as
+// their uvarint count followed by each key and value.
+package logdriver
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// MaxFrameSize is the size of the largest entry a decoder accepts.
+const MaxFrameSize = 16 << 20
+
+var errTruncated = errors.New("logdriver: truncated log entry")
+
+// LogEntry is a log message of a container.
+type LogEntry struct {
+	// Source is the stream the message was written to, "stdout" or
+	// "stderr".
+	Source string
+	// TimeNano is when the message was logged, in nanoseconds since the
+	// epoch.
+	TimeNano int64
+	// Line is the message, without its trailing newline.
+	Line []byte
+	// Attrs are the extra attributes of the message.
+	Attrs map[string]string
+}
+
+// Encoder writes log entries to a stream.
+type Encoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewEncoder returns an encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the frame of an entry with a single call to Write, so that
+// entries small enough are written atomically to pipes.
+func (e *Encoder) Encode(entry *LogEntry) error {
+	buf := append(e.buf[:0], 0, 0, 0, 0)
+	buf = appendBytes(buf, []byte(entry.Source))
+	buf = appendVarint(buf, entry.TimeNano)
+	buf = appendBytes(buf, entry.Line)
+	keys := make([]string, 0, len(entry.Attrs))
+	for k := range entry.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	buf = appendUvarint(buf, uint64(len(keys)))
+	for _, k := range keys {
+		buf = appendBytes(buf, []byte(k))
+		buf = appendBytes(buf, []byte(entry.Attrs[k]))
+	}
+	if len(buf)-4 > MaxFrameSize {
+		return fmt.Errorf("logdriver: log entry of %d bytes exceeds the maximum of %d", len(buf)-4, MaxFrameSize)
+	}
+	binary.BigEndian.PutUint32(buf, uint32(len(buf)-4))
+	e.buf = buf
+	_, err := e.w.Write(buf)
+	return err
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(buf, tmp[:binary.PutUvarint(tmp[:], v)]...)
+}
+
+func appendVarint(buf []byte, v int64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(buf, tmp[:binary.PutVarint(tmp[:], v)]...)
+}
+
+func appendBytes(buf, b []byte) []byte {
+	return append(appendUvarint(buf, uint64(len(b))), b...)
+}
+
+// Decoder reads log entries from a stream.
+type Decoder struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// NewDecoder returns a decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next entry into entry. It returns io.EOF at the end of
+// the stream, and io.ErrUnexpectedEOF if it ends in the middle of an entry.
+func (d *Decoder) Decode(entry *LogEntry) error {
+	var size [4]byte
+	if _, err := io.ReadFull(d.r, size[:]); err != nil {
+		return err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > MaxFrameSize {
+		return fmt.Errorf("logdriver: log entry of %d bytes exceeds the maximum of %d", n, MaxFrameSize)
+	}
+	if cap(d.buf) < int(n) {
+		d.buf = make([]byte, n)
+	}
+	buf := d.buf[:n]
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	p := &parser{buf: buf}
+	*entry = LogEntry{
+		Source:   string(p.bytes()),
+		TimeNano: p.varint(),
+		// the buffer is reused by the next call
+		Line: append([]byte(nil), p.bytes()...),
+	}
+	if count := p.uvarint(); count > 0 && p.err == nil {
+		entry.Attrs = make(map[string]string)
+		for i := uint64(0); i < count && p.err == nil; i++ {
+			k := string(p.bytes())
+			entry.Attrs[k] = string(p.bytes())
+		}
+	}
+	return p.err
+}
+
+// parser decodes the fields of an entry, recording the first error.
+type parser struct {
+	buf []byte
+	err error
+}
+
+func (p *parser) uvarint() uint64 {
+	if p.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(p.buf)
+	if n <= 0 {
+		p.err = errTruncated
+		return 0
+	}
+	p.buf = p.buf[n:]
+	return v
+}
+
+func (p *parser) varint() int64 {
+	if p.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(p.buf)
+	if n <= 0 {
+		p.err = errTruncated
+		return 0
+	}
+	p.buf = p.buf[n:]
+	return v
+}
+
+func (p *parser) bytes() []byte {
+	n := p.uvarint()
+	if p.err != nil {
+		return nil
+	}
+	if n > uint64(len(p.buf)) {
+		p.err = errTruncated
+		return nil
+	}
+	b := p.buf[:n]
+	p.buf = p.buf[n:]
+	return b
+}
@@ -0,0 +1,65 @@
+package logdriver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	entries := []LogEntry{
+		{Source: "stdout", TimeNano: 1473258345245186548, Line: []byte("hello")},
+		{Source: "stderr", TimeNano: -1, Attrs: map[string]string{"level": "error", "": "empty"}},
+		{Source: "stdout", TimeNano: 42, Line: bytes.Repeat([]byte("x"), 100000)},
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for i := range entries {
+		if err := enc.Encode(&entries[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dec := NewDecoder(&buf)
+	for i := range entries {
+		var entry LogEntry
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(entry, entries[i]) {
+			t.Fatalf("entry %d: expected %+v, got %+v", i, entries[i], entry)
+		}
+	}
+	var entry LogEntry
+	if err := dec.Decode(&entry); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(&LogEntry{Source: "stdout", Line: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	frame := buf.Bytes()
+
+	var entry LogEntry
+	if err := NewDecoder(bytes.NewReader(frame[:len(frame)-1])).Decode(&entry); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected unexpected EOF, got %v", err)
+	}
+
+	truncated := append([]byte(nil), frame[:len(frame)-2]...)
+	binary.BigEndian.PutUint32(truncated, uint32(len(truncated)-4))
+	if err := NewDecoder(bytes.NewReader(truncated)).Decode(&entry); err != errTruncated {
+		t.Fatalf("expected truncated entry error, got %v", err)
+	}
+
+	huge := make([]byte, 4)
+	binary.BigEndian.PutUint32(huge, MaxFrameSize+1)
+	if err := NewDecoder(bytes.NewReader(huge)).Decode(&entry); err == nil {
+		t.Fatal("expected oversized entry to be refused")
+	}
+}
@@ -8,7 +8,9 @@
 package logger
 
 import (
+	"bytes"
 	"errors"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
@@ -18,7 +20,11 @@ import (
 )
 
 // ErrReadLogsNotSupported is returned when the logger does not support reading logs.
-var ErrReadLogsNotSupported = errors.New("configured logging reader does not support reading")
+var ErrReadLogsNotSupported = errors.New("configured logging reader does not support reading, enable the local cache of the logs with the cache log option")
+
+// ErrReadLogsNoneDriver is returned when reading the logs of a container
+// using the none logging driver, which discards them.
+var ErrReadLogsNoneDriver = errors.New("configured logging reader does not support reading, the none logging driver discards the logs")
 
 const (
 	// TimeFormat is the time format used for timestamps sent to log readers.
@@ -67,9 +73,40 @@ type Logger interface {
 
 // ReadConfig is the configuration passed into ReadLogs.
 type ReadConfig struct {
-	Since  time.Time
+	Since time.Time
+	// Until, if set, is the time of the newest message to read. Readers
+	// stop at the first message logged after it.
+	Until  time.Time
 	Tail   int
 	Follow bool
+	// Source, if set, selects the messages of the "stdout" or "stderr"
+	// stream only.
+	Source string
+	// Filter, if set, selects the messages whose line matches it.
+	Filter *regexp.Regexp `json:"-"`
+}
+
+// PastUntil reports whether msg was logged after the Until time of the
+// config, if it has one.
+func (config ReadConfig) PastUntil(msg *Message) bool {
+	return !config.Until.IsZero() && msg.Timestamp.After(config.Until)
+}
+
+// Selects reports whether msg is selected by the Source and the Filter of
+// the config, which is matched against the line without its newline. Tail
+// counts the selected messages only.
+func (config ReadConfig) Selects(msg *Message) bool {
+	if config.Source != "" && msg.Source != config.Source {
+		return false
+	}
+	return config.Filter == nil || config.Filter.Match(bytes.TrimSuffix(msg.Line, []byte{'\n'}))
+}
+
+// Filtered reports whether the config selects messages by Until, Source or
+// Filter, in which case the messages to Tail can't be counted from the end
+// of the logs without reading them.
+func (config ReadConfig) Filtered() bool {
+	return !config.Until.IsZero() || config.Source != "" || config.Filter != nil
 }
 
 // LogReader is the interface for reading log messages for loggers that support reading.
@@ -0,0 +1,116 @@
+// Package cache keeps a local copy of the logs sent to the logging drivers
+// that can't read them, so that they can be read with `docker logs`.
+package cache
+
+import (
+	"errors"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/jsonfilelog"
+)
+
+var errReadOnly = errors.New("the log cache is read-only")
+
+const (
+	// DefaultMaxSize is the size of the files of the cache if
+	// logger.CacheMaxSizeOpt isn't set.
+	DefaultMaxSize = "20m"
+	// DefaultMaxFile is the number of files of the cache if
+	// logger.CacheMaxFileOpt isn't set.
+	DefaultMaxFile = "5"
+)
+
+// WithLocalCache returns a logger sending messages to l, and writing them
+// to a size capped file at ctx.LogPath, which serves ReadLogs.
+func WithLocalCache(l logger.Logger, ctx logger.Context) (logger.Logger, error) {
+	cache, err := jsonfilelog.New(cacheContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &loggerWithCache{l: l, cache: cache}, nil
+}
+
+// NewReader returns a logger reading the local cache at ctx.LogPath of the
+// logs sent to the named driver, for containers that aren't running. The
+// driver isn't started, and the logger doesn't log.
+func NewReader(driver string, ctx logger.Context) (logger.Logger, error) {
+	cache, err := jsonfilelog.New(cacheContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &cacheReader{driver: driver, cache: cache}, nil
+}
+
+// cacheContext returns the context of the json-file logger writing the
+// cache of the logs of a driver started with ctx.
+func cacheContext(ctx logger.Context) logger.Context {
+	cacheCtx := ctx
+	cacheCtx.Config = map[string]string{
+		"max-size": DefaultMaxSize,
+		"max-file": DefaultMaxFile,
+	}
+	if s, ok := ctx.Config[logger.CacheMaxSizeOpt]; ok {
+		cacheCtx.Config["max-size"] = s
+	}
+	if s, ok := ctx.Config[logger.CacheMaxFileOpt]; ok {
+		cacheCtx.Config["max-file"] = s
+	}
+	// keep the extra attributes, for `docker logs --details`
+	for _, key := range []string{"labels", "env"} {
+		if s, ok := ctx.Config[key]; ok {
+			cacheCtx.Config[key] = s
+		}
+	}
+	return cacheCtx
+}
+
+type loggerWithCache struct {
+	l     logger.Logger
+	cache logger.Logger
+}
+
+func (l *loggerWithCache) Log(msg *logger.Message) error {
+	// the driver may keep the message, the cache doesn't
+	if err := l.cache.Log(msg); err != nil {
+		logrus.Warnf("Failed to write message to the log cache of driver %s: %v", l.l.Name(), err)
+	}
+	return l.l.Log(msg)
+}
+
+func (l *loggerWithCache) Name() string {
+	return l.l.Name()
+}
+
+func (l *loggerWithCache) ReadLogs(config logger.ReadConfig) *logger.LogWatcher {
+	return l.cache.(logger.LogReader).ReadLogs(config)
+}
+
+func (l *loggerWithCache) Close() error {
+	err := l.l.Close()
+	if cacheErr := l.cache.Close(); cacheErr != nil && err == nil {
+		err = cacheErr
+	}
+	return err
+}
+
+type cacheReader struct {
+	driver string
+	cache  logger.Logger
+}
+
+func (r *cacheReader) Log(msg *logger.Message) error {
+	return errReadOnly
+}
+
+func (r *cacheReader) Name() string {
+	return r.driver
+}
+
+func (r *cacheReader) ReadLogs(config logger.ReadConfig) *logger.LogWatcher {
+	return r.cache.(logger.LogReader).ReadLogs(config)
+}
+
+func (r *cacheReader) Close() error {
+	return r.cache.Close()
+}
@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
+)
+
+type fakeLogger struct {
+	messages []string
+	closed   bool
+}
+
+func (l *fakeLogger) Log(msg *logger.Message) error {
+	l.messages = append(l.messages, string(msg.Line))
+	return nil
+}
+
+func (l *fakeLogger) Name() string {
+	return "fake"
+}
+
+func (l *fakeLogger) Close() error {
+	l.closed = true
+	return nil
+}
+
+func readAll(t *testing.T, r logger.LogReader, config logger.ReadConfig) []string {
+	watcher := r.ReadLogs(config)
+	defer watcher.Close()
+	var lines []string
+	for {
+		select {
+		case msg, ok := <-watcher.Msg:
+			if !ok {
+				return lines
+			}
+			lines = append(lines, strings.TrimSuffix(string(msg.Line), "\n"))
+		case err := <-watcher.Err:
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout reading logs")
+		}
+	}
+}
+
+func TestWithLocalCache(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "log-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	driver := &fakeLogger{}
+	l, err := WithLocalCache(driver, logger.Context{
+		Config:  map[string]string{logger.CacheOpt: "true"},
+		LogPath: filepath.Join(tmp, "container-cache.log"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := l.(logger.LogReader)
+	if !ok {
+		t.Fatal("expected the cache to read logs")
+	}
+
+	start := time.Now().UTC()
+	for i := 0; i < 10; i++ {
+		if err := l.Log(&logger.Message{Line: []byte("line" + strconv.Itoa(i)), Source: "stdout", Timestamp: start.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(driver.messages) != 10 {
+		t.Fatalf("expected the driver to get 10 messages, got %d", len(driver.messages))
+	}
+
+	if lines := readAll(t, r, logger.ReadConfig{Tail: -1}); len(lines) != 10 || lines[0] != "line0" {
+		t.Fatalf("unexpected logs: %v", lines)
+	}
+	if lines := readAll(t, r, logger.ReadConfig{Tail: 2}); len(lines) != 2 || lines[0] != "line8" || lines[1] != "line9" {
+		t.Fatalf("unexpected tail of the logs: %v", lines)
+	}
+	if lines := readAll(t, r, logger.ReadConfig{Tail: -1, Since: start.Add(7 * time.Second)}); len(lines) != 3 || lines[0] != "line7" {
+		t.Fatalf("unexpected logs since the 7th second: %v", lines)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !driver.closed {
+		t.Fatal("expected the driver to be closed")
+	}
+}
+
+func TestWithLocalCacheSizeCap(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "log-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	path := filepath.Join(tmp, "container-cache.log")
+	l, err := WithLocalCache(&fakeLogger{}, logger.Context{
+		Config: map[string]string{
+			logger.CacheOpt:        "true",
+			logger.CacheMaxSizeOpt: "1k",
+			logger.CacheMaxFileOpt: "2",
+		},
+		LogPath: path,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for i := 0; i < 100; i++ {
+		if err := l.Log(&logger.Message{Line: []byte("a line of the logs of the container"), Source: "stdout", Timestamp: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := filepath.Glob(path + "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(files); i++ {
+		if strings.HasSuffix(files[i], loggerutils.IndexSuffix) {
+			files = append(files[:i], files[i+1:]...)
+			i--
+		}
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 cache files, got %v", files)
+	}
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// files are rotated once they reach the cap
+		if fi.Size() > 1024+128 {
+			t.Fatalf("expected %s to be capped to about 1k, got %d bytes", f, fi.Size())
+		}
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "log-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	ctx := logger.Context{
+		Config:  map[string]string{logger.CacheOpt: "true"},
+		LogPath: filepath.Join(tmp, "container-cache.log"),
+	}
+	l, err := WithLocalCache(&fakeLogger{}, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := l.Log(&logger.Message{Line: []byte("line" + strconv.Itoa(i)), Source: "stdout", Timestamp: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader("fake", ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.Name() != "fake" {
+		t.Fatalf("expected the name of the driver, got %s", r.Name())
+	}
+	if lines := readAll(t, r.(logger.LogReader), logger.ReadConfig{Tail: -1}); len(lines) != 3 || lines[2] != "line2" {
+		t.Fatalf("unexpected logs: %v", lines)
+	}
+	if err := r.Log(&logger.Message{Line: []byte("line3"), Source: "stdout", Timestamp: time.Now()}); err != errReadOnly {
+		t.Fatalf("expected the reader not to log, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package loggerutils
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// IndexSuffix is the suffix of the time index of a log file.
+	IndexSuffix = ".idx"
+	// CompressedSuffix is the suffix of the compressed rotated log files.
+	CompressedSuffix = ".gz"
+
+	// indexInterval is the number of bytes written to a log file between
+	// two entries of its index.
+	indexInterval  = 1 << 20
+	indexEntrySize = 16
+)
+
+// IndexEntry maps the time of a message of a log file to its offset in the
+// file, uncompressed.
+type IndexEntry struct {
+	Time   time.Time
+	Offset int64
+}
+
+func (e IndexEntry) marshal() []byte {
+	b := make([]byte, indexEntrySize)
+	binary.BigEndian.PutUint64(b, uint64(e.Time.UnixNano()))
+	binary.BigEndian.PutUint64(b[8:], uint64(e.Offset))
+	return b
+}
+
+// IndexPath returns the path of the time index of the log file at path,
+// compressed or not.
+func IndexPath(path string) string {
+	return strings.TrimSuffix(path, CompressedSuffix) + IndexSuffix
+}
+
+// ReadIndex reads the time index of the log file at path. Files without an
+// index, such as those written by older versions, have an empty one.
+func ReadIndex(path string) ([]IndexEntry, error) {
+	b, err := ioutil.ReadFile(IndexPath(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// ignore an entry being written
+	entries := make([]IndexEntry, 0, len(b)/indexEntrySize)
+	for ; len(b) >= indexEntrySize; b = b[indexEntrySize:] {
+		entries = append(entries, IndexEntry{
+			Time:   time.Unix(0, int64(binary.BigEndian.Uint64(b))),
+			Offset: int64(binary.BigEndian.Uint64(b[8:])),
+		})
+	}
+	return entries, nil
+}
+
+// SeekOffset returns the offset to read a log file from to find the
+// messages logged since the given time, using its index.
+func SeekOffset(index []IndexEntry, since time.Time) int64 {
+	var offset int64
+	for _, e := range index {
+		if !e.Time.Before(since) {
+			break
+		}
+		offset = e.Offset
+	}
+	return offset
+}
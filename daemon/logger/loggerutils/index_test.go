@@ -0,0 +1,148 @@
+package loggerutils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateFileWriterIndex(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "container.log")
+
+	w, err := NewRotateFileWriter(path, -1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := append(bytes.Repeat([]byte("a"), 1023), '\n')
+	start := time.Unix(1000, 0)
+	// 3.5MB of messages, one second apart
+	for i := 0; i < 3584; i++ {
+		if _, err := w.WriteEntry(line, start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := ReadIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != 4 {
+		t.Fatalf("expected 4 index entries, got %v", index)
+	}
+	for i, e := range index {
+		offset := int64(i) * indexInterval
+		if e.Offset != offset || !e.Time.Equal(start.Add(time.Duration(offset/1024)*time.Second)) {
+			t.Fatalf("unexpected index entry %d: %v", i, e)
+		}
+	}
+
+	if offset := SeekOffset(index, start); offset != 0 {
+		t.Fatalf("expected to read from the start, got offset %d", offset)
+	}
+	if offset := SeekOffset(index, start.Add(2048*time.Second)); offset != indexInterval {
+		t.Fatalf("expected to read from the second entry, got offset %d", offset)
+	}
+	if offset := SeekOffset(index, start.Add(time.Hour)); offset != 3*indexInterval {
+		t.Fatalf("expected to read from the last entry, got offset %d", offset)
+	}
+}
+
+func TestRotateFileWriterCompress(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "container.log")
+
+	w, err := NewRotateFileWriter(path, 1024, 3, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := append(bytes.Repeat([]byte("a"), 511), '\n')
+	for i := 0; i < 8; i++ {
+		if _, err := w.WriteEntry(line, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{path, path + ".1.gz", path + ".2.gz", IndexPath(path + ".1.gz")} {
+		if _, err := os.Stat(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{path + ".1", path + ".2", path + ".3.gz"} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Fatalf("expected %s not to exist, got %v", name, err)
+		}
+	}
+}
+
+func TestRotateFileWriterCompressWhileRotating(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "container.log")
+
+	w, err := NewRotateFileWriter(path, 1024, 4, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// each file holds two lines, and the files are rotated faster than
+	// they are compressed
+	for i := 0; i < 200; i++ {
+		line := fmt.Sprintf("%03d%s\n", i, bytes.Repeat([]byte("a"), 508))
+		if _, err := w.WriteEntry([]byte(line), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for n, first := range map[int]int{1: 196, 2: 194, 3: 192} {
+		name := fmt.Sprintf("%s.%d%s", path, n, CompressedSuffix)
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(r)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := bytes.Split(bytes.TrimSuffix(content, []byte("\n")), []byte("\n")); len(lines) != 2 || string(lines[0][:3]) != fmt.Sprintf("%03d", first) {
+			t.Fatalf("expected %s to start with line %d, got %d lines", name, first, len(lines))
+		}
+	}
+	files, err := filepath.Glob(path + "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the current file, three rotated files and their indexes
+	if len(files) != 8 {
+		t.Fatalf("expected no leftover files, got %v", files)
+	}
+}
@@ -14,8 +14,13 @@ func ParseLogTag(ctx logger.Context, defaultTemplate string) (string, error) {
 	if tagTemplate == "" {
 		tagTemplate = defaultTemplate
 	}
+	return ParseLogTemplate(ctx, "log-tag", tagTemplate)
+}
 
-	tmpl, err := templates.NewParse("log-tag", tagTemplate)
+// ParseLogTemplate executes a template of the same form as the log tag,
+// such as "{{.Name}}", with the context of the container.
+func ParseLogTemplate(ctx logger.Context, name, template string) (string, error) {
+	tmpl, err := templates.NewParse(name, template)
 	if err != nil {
 		return "", err
 	}
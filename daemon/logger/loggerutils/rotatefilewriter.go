@@ -1,25 +1,35 @@
 package loggerutils
 
 import (
+	"compress/gzip"
+	"io"
 	"os"
 	"strconv"
 	"sync"
+	"time"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/pkg/pubsub"
 )
 
 // RotateFileWriter is Logger implementation for default Docker logging.
 type RotateFileWriter struct {
 	f            *os.File // store for closing
+	index        *os.File // time index of f, nil if it can't be written
 	mu           sync.Mutex
 	capacity     int64 //maximum size of each file
 	currentSize  int64 // current size of the latest file
+	nextIndex    int64 // offset from which the next message is indexed
 	maxFiles     int   //maximum number of files
+	compress     bool  // whether rotated files are compressed
+	compressJobs sync.WaitGroup
+	rotateMu     sync.Mutex // held while rotated files are renamed
+	rotations    int64      // number of rotations, guarded by rotateMu
 	notifyRotate *pubsub.Publisher
 }
 
 //NewRotateFileWriter creates new RotateFileWriter
-func NewRotateFileWriter(logPath string, capacity int64, maxFiles int) (*RotateFileWriter, error) {
+func NewRotateFileWriter(logPath string, capacity int64, maxFiles int, compress bool) (*RotateFileWriter, error) {
 	log, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
 	if err != nil {
 		return nil, err
@@ -32,21 +42,47 @@ func NewRotateFileWriter(logPath string, capacity int64, maxFiles int) (*RotateF
 
 	return &RotateFileWriter{
 		f:            log,
+		index:        openIndex(logPath, os.O_APPEND),
 		capacity:     capacity,
 		currentSize:  size,
+		nextIndex:    size,
 		maxFiles:     maxFiles,
+		compress:     compress,
 		notifyRotate: pubsub.NewPublisher(0, 1),
 	}, nil
 }
 
+func openIndex(logPath string, flag int) *os.File {
+	index, err := os.OpenFile(IndexPath(logPath), os.O_WRONLY|os.O_CREATE|flag, 0640)
+	if err != nil {
+		logrus.Warnf("Failed to open the time index of log file %s: %v", logPath, err)
+		return nil
+	}
+	return index
+}
+
 //WriteLog write log message to File
 func (w *RotateFileWriter) Write(message []byte) (int, error) {
+	return w.WriteEntry(message, time.Time{})
+}
+
+// WriteEntry writes a log message logged at the given time to the file,
+// recording it in the time index of the file if it is due. Messages are
+// indexed every megabyte.
+func (w *RotateFileWriter) WriteEntry(message []byte, timestamp time.Time) (int, error) {
 	w.mu.Lock()
 	if err := w.checkCapacityAndRotate(); err != nil {
 		w.mu.Unlock()
 		return -1, err
 	}
 
+	if w.index != nil && !timestamp.IsZero() && w.currentSize >= w.nextIndex {
+		if _, err := w.index.Write(IndexEntry{Time: timestamp, Offset: w.currentSize}.marshal()); err != nil {
+			logrus.Warnf("Failed to write the time index of log file %s: %v", w.f.Name(), err)
+		}
+		w.nextIndex = w.currentSize + indexInterval
+	}
+
 	n, err := w.f.Write(message)
 	if err == nil {
 		w.currentSize += int64(n)
@@ -65,15 +101,34 @@ func (w *RotateFileWriter) checkCapacityAndRotate() error {
 		if err := w.f.Close(); err != nil {
 			return err
 		}
+		if w.index != nil {
+			w.index.Close()
+		}
+		w.rotateMu.Lock()
 		if err := rotate(name, w.maxFiles); err != nil {
+			w.rotateMu.Unlock()
 			return err
 		}
+		w.rotations++
+		rotation := w.rotations
+		w.rotateMu.Unlock()
 		file, err := os.OpenFile(name, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 06400)
 		if err != nil {
 			return err
 		}
 		w.f = file
+		w.index = openIndex(name, os.O_TRUNC)
 		w.currentSize = 0
+		w.nextIndex = 0
+		if w.compress && w.maxFiles > 1 {
+			w.compressJobs.Add(1)
+			go func() {
+				defer w.compressJobs.Done()
+				if err := w.compressRotated(name, rotation); err != nil {
+					logrus.Errorf("Failed to compress log file %s rotated %d times: %v", name, rotation, err)
+				}
+			}()
+		}
 		w.notifyRotate.Publish(struct{}{})
 	}
 
@@ -84,18 +139,97 @@ func rotate(name string, maxFiles int) error {
 	if maxFiles < 2 {
 		return nil
 	}
+	// the oldest file may be compressed or not, and have an index
+	oldest := name + "." + strconv.Itoa(maxFiles-1)
+	for _, p := range []string{oldest, oldest + CompressedSuffix, oldest + IndexSuffix} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
 	for i := maxFiles - 1; i > 1; i-- {
 		toPath := name + "." + strconv.Itoa(i)
 		fromPath := name + "." + strconv.Itoa(i-1)
-		if err := os.Rename(fromPath, toPath); err != nil && !os.IsNotExist(err) {
+		for _, suffix := range []string{"", CompressedSuffix, IndexSuffix} {
+			if err := os.Rename(fromPath+suffix, toPath+suffix); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	for _, suffix := range []string{"", IndexSuffix} {
+		if err := os.Rename(name+suffix, name+".1"+suffix); err != nil && !os.IsNotExist(err) {
 			return err
 		}
 	}
+	return nil
+}
+
+// rotatedPath returns the path the file moved by the given rotation has
+// been renamed to since, or false if it has been removed. w.rotateMu must be
+// held.
+func (w *RotateFileWriter) rotatedPath(name string, rotation int64) (string, bool) {
+	n := w.rotations - rotation + 1
+	if n >= int64(w.maxFiles) {
+		return "", false
+	}
+	return name + "." + strconv.FormatInt(n, 10), true
+}
 
-	if err := os.Rename(name, name+".1"); err != nil && !os.IsNotExist(err) {
+// compressRotated replaces the file moved by the given rotation with a gzip
+// compressed copy. The copy is written under a temporary name, so that the
+// writer keeps rotating files meanwhile, and replaces the file wherever it
+// has been renamed to once complete. Readers always find one of them.
+func (w *RotateFileWriter) compressRotated(name string, rotation int64) error {
+	w.rotateMu.Lock()
+	path, ok := w.rotatedPath(name, rotation)
+	var in *os.File
+	var err error
+	if ok {
+		in, err = os.Open(path)
+	}
+	w.rotateMu.Unlock()
+	if !ok || os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
 		return err
 	}
-	return nil
+	defer in.Close()
+
+	tmp := name + ".compress-" + strconv.FormatInt(rotation, 10) + ".tmp"
+	if err := compressTo(tmp, in); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	w.rotateMu.Lock()
+	defer w.rotateMu.Unlock()
+	if path, ok = w.rotatedPath(name, rotation); !ok {
+		// rotated away while being compressed
+		return os.Remove(tmp)
+	}
+	if err := os.Rename(tmp, path+CompressedSuffix); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
+
+func compressTo(path string, in io.Reader) error {
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
 }
 
 // LogPath returns the location the given writer logs to.
@@ -120,5 +254,9 @@ func (w *RotateFileWriter) NotifyRotateEvict(sub chan interface{}) {
 
 // Close closes underlying file and signals all readers to stop.
 func (w *RotateFileWriter) Close() error {
+	w.compressJobs.Wait()
+	if w.index != nil {
+		w.index.Close()
+	}
 	return w.f.Close()
 }
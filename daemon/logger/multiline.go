@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+)
+
+const (
+	// MultilinePatternOpt is the log option setting the regular expression
+	// matching the first line of multiline messages. The lines not matching
+	// it are merged into the message before them.
+	MultilinePatternOpt = "multiline-pattern"
+	// MultilineFlushIntervalOpt is the log option setting how long a
+	// multiline message waits for more lines before being logged.
+	MultilineFlushIntervalOpt = "multiline-flush-interval"
+	// MultilineMaxSizeOpt is the log option setting the size above which a
+	// multiline message is logged without waiting for more lines.
+	MultilineMaxSizeOpt = "multiline-max-size"
+
+	// DefaultMultilineFlushInterval is the flush interval of multiline
+	// messages if MultilineFlushIntervalOpt isn't set.
+	DefaultMultilineFlushInterval = time.Second
+	// DefaultMultilineMaxSize is the maximum size of multiline messages if
+	// MultilineMaxSizeOpt isn't set.
+	DefaultMultilineMaxSize = 256 * 1024
+)
+
+// MultilineConfig configures the aggregation of the lines of multiline
+// messages, such as stack traces, by a Copier.
+type MultilineConfig struct {
+	// Pattern matches the first line of the messages.
+	Pattern *regexp.Regexp
+	// FlushInterval is how long a message waits for its next line.
+	FlushInterval time.Duration
+	// MaxSize is the size in bytes above which a message is logged without
+	// waiting for more lines. Single lines larger than it are not split.
+	MaxSize int
+}
+
+// ParseMultilineConfig returns the multiline config set by the log options,
+// or nil if multiline messages are not aggregated.
+func ParseMultilineConfig(cfg map[string]string) (*MultilineConfig, error) {
+	pattern, ok := cfg[MultilinePatternOpt]
+	if !ok {
+		for _, opt := range []string{MultilineFlushIntervalOpt, MultilineMaxSizeOpt} {
+			if _, ok := cfg[opt]; ok {
+				return nil, fmt.Errorf("logger: %s requires %s", opt, MultilinePatternOpt)
+			}
+		}
+		return nil, nil
+	}
+
+	config := &MultilineConfig{
+		FlushInterval: DefaultMultilineFlushInterval,
+		MaxSize:       DefaultMultilineMaxSize,
+	}
+	var err error
+	if config.Pattern, err = regexp.Compile(pattern); err != nil {
+		return nil, fmt.Errorf("logger: invalid %s %q: %v", MultilinePatternOpt, pattern, err)
+	}
+	if s, ok := cfg[MultilineFlushIntervalOpt]; ok {
+		if config.FlushInterval, err = time.ParseDuration(s); err != nil {
+			return nil, fmt.Errorf("logger: invalid %s %q: %v", MultilineFlushIntervalOpt, s, err)
+		}
+		if config.FlushInterval <= 0 {
+			return nil, fmt.Errorf("logger: %s must be positive", MultilineFlushIntervalOpt)
+		}
+	}
+	if s, ok := cfg[MultilineMaxSizeOpt]; ok {
+		size, err := units.RAMInBytes(s)
+		if err != nil {
+			return nil, fmt.Errorf("logger: invalid %s %q: %v", MultilineMaxSizeOpt, s, err)
+		}
+		if size <= 0 {
+			return nil, fmt.Errorf("logger: %s must be positive", MultilineMaxSizeOpt)
+		}
+		config.MaxSize = int(size)
+	}
+	return config, nil
+}
+
+// multilineAggregator merges the lines of a source into messages, logging
+// each one when its next message starts, when it grows too large, or when
+// no line follows it for the flush interval.
+type multilineAggregator struct {
+	config *MultilineConfig
+	log    func(*Message)
+
+	mu       sync.Mutex
+	msg      *Message // the message being aggregated, if any
+	deadline time.Time
+	timer    *time.Timer
+}
+
+func newMultilineAggregator(config *MultilineConfig, log func(*Message)) *multilineAggregator {
+	a := &multilineAggregator{config: config, log: log}
+	a.timer = time.AfterFunc(config.FlushInterval, a.flushIfDue)
+	a.timer.Stop()
+	return a
+}
+
+// add merges the line of msg into the message being aggregated, or starts
+// a new message with it.
+func (a *multilineAggregator) add(msg *Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.msg != nil && (a.config.Pattern.Match(msg.Line) || len(a.msg.Line)+1+len(msg.Line) > a.config.MaxSize) {
+		a.flush()
+	}
+	if a.msg == nil {
+		a.msg = msg
+	} else {
+		a.msg.Line = append(append(a.msg.Line, '\n'), msg.Line...)
+	}
+	a.deadline = time.Now().Add(a.config.FlushInterval)
+	a.timer.Reset(a.config.FlushInterval)
+}
+
+// flushIfDue logs the message being aggregated if no line was added to it
+// for the flush interval.
+func (a *multilineAggregator) flushIfDue() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.msg == nil {
+		return
+	}
+	// a line may have been added while the timer fired
+	if wait := a.deadline.Sub(time.Now()); wait > 0 {
+		a.timer.Reset(wait)
+		return
+	}
+	a.flush()
+}
+
+// flush logs the message being aggregated. a.mu must be held.
+func (a *multilineAggregator) flush() {
+	if a.msg != nil {
+		a.log(a.msg)
+		a.msg = nil
+	}
+}
+
+// close logs the message being aggregated, once the source is done.
+func (a *multilineAggregator) close() {
+	a.mu.Lock()
+	a.timer.Stop()
+	a.flush()
+	a.mu.Unlock()
+}
@@ -0,0 +1,339 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/logger/logdriver"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/plugin"
+)
+
+const extName = "LogDriver"
+
+var (
+	// pluginStreamDir is where the streams of the logs sent to plugins
+	// are created.
+	pluginStreamDir = "/run/docker/logging"
+	// pluginWriteTimeout is how long a plugin may take to connect to the
+	// log stream, or stop reading it, before it is assumed to have
+	// restarted.
+	pluginWriteTimeout = 10 * time.Second
+
+	errLoggerClosed = errors.New("logger: log driver is closed")
+)
+
+// getPlugin returns the creator of loggers for the log driver plugin with
+// the given name.
+func getPlugin(name string) (Creator, error) {
+	p, err := plugin.LookupWithCapability(name, extName)
+	if err != nil {
+		return nil, fmt.Errorf("error looking up logging plugin %s: %v", name, err)
+	}
+	return makePluginCreator(name, &logPluginProxy{p.Client()}), nil
+}
+
+// pluginReadsLogs returns whether the log driver plugin with the given name
+// implements LogDriver.ReadLogs.
+func pluginReadsLogs(name string) (bool, error) {
+	p, err := plugin.LookupWithCapability(name, extName)
+	if err != nil {
+		return false, fmt.Errorf("error looking up logging plugin %s: %v", name, err)
+	}
+	cap, err := (&logPluginProxy{p.Client()}).Capabilities()
+	if err != nil {
+		// LogDriver.Capabilities is optional
+		logrus.Debugf("Log driver plugin %s returned an error while trying to query its capabilities: %v", name, err)
+	}
+	return cap.ReadLogs, nil
+}
+
+func makePluginCreator(name string, proxy *logPluginProxy) Creator {
+	return func(ctx Context) (Logger, error) {
+		if err := os.MkdirAll(pluginStreamDir, 0700); err != nil {
+			return nil, err
+		}
+		file := filepath.Join(pluginStreamDir, stringid.GenerateNonCryptoID())
+		listener, err := listenPluginStream(file)
+		if err != nil {
+			return nil, fmt.Errorf("error creating the log stream for plugin %s: %v", name, err)
+		}
+		if err := proxy.StartLogging(file, ctx); err != nil {
+			listener.Close()
+			os.Remove(file)
+			return nil, fmt.Errorf("error starting logging with plugin %s: %v", name, err)
+		}
+
+		a := &pluginAdapter{
+			driverName: name,
+			file:       file,
+			ctx:        ctx,
+			plugin:     proxy,
+			listener:   listener,
+			closed:     make(chan struct{}),
+		}
+		a.enc = logdriver.NewEncoder(&a.buf)
+
+		cap, err := proxy.Capabilities()
+		if err != nil {
+			// LogDriver.Capabilities is optional
+			logrus.Debugf("Log driver plugin %s returned an error while trying to query its capabilities: %v", name, err)
+		}
+		if cap.ReadLogs {
+			return &pluginAdapterWithRead{a}, nil
+		}
+		return a, nil
+	}
+}
+
+// pluginAdapter sends the logs of a container to a log driver plugin over a
+// unix socket the plugin connects to.
+type pluginAdapter struct {
+	driverName string
+	file       string
+	ctx        Context
+	plugin     *logPluginProxy
+
+	mu        sync.Mutex
+	listener  *net.UnixListener
+	conn      net.Conn // the connection of the plugin, if any
+	buf       bytes.Buffer
+	enc       *logdriver.Encoder
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (a *pluginAdapter) Name() string {
+	return a.driverName
+}
+
+func (a *pluginAdapter) Log(msg *Message) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.buf.Reset()
+	if err := a.enc.Encode(&logdriver.LogEntry{
+		Source:   msg.Source,
+		TimeNano: msg.Timestamp.UnixNano(),
+		Line:     msg.Line,
+		Attrs:    msg.Attrs,
+	}); err != nil {
+		return err
+	}
+	return a.write(a.buf.Bytes())
+}
+
+// write writes b to the connection of the plugin, waiting for the plugin to
+// connect if needed. When the plugin doesn't connect or read for
+// pluginWriteTimeout, or the connection breaks, the plugin is told about the
+// stream again, in case it restarted, and b is written again on its new
+// connection. Writes are retried until the logger is closed.
+func (a *pluginAdapter) write(b []byte) error {
+	for {
+		select {
+		case <-a.closed:
+			return errLoggerClosed
+		default:
+		}
+		if a.conn == nil {
+			if err := a.listener.SetDeadline(time.Now().Add(pluginWriteTimeout)); err != nil {
+				return err
+			}
+			conn, err := a.listener.Accept()
+			if err != nil {
+				if !isTimeout(err) {
+					return err
+				}
+				a.restartLogging("is not connecting to the log stream")
+				continue
+			}
+			a.conn = conn
+		}
+
+		if err := a.conn.SetWriteDeadline(time.Now().Add(pluginWriteTimeout)); err != nil {
+			return err
+		}
+		_, err := a.conn.Write(b)
+		if err == nil {
+			return nil
+		}
+		// the plugin still reads the messages written before, and drops
+		// the partial one
+		a.conn.Close()
+		a.conn = nil
+		a.restartLogging(fmt.Sprintf("is not reading the log stream (%v)", err))
+	}
+}
+
+// restartLogging tells the plugin about the stream again, unless the logger
+// is closed.
+func (a *pluginAdapter) restartLogging(reason string) {
+	select {
+	case <-a.closed:
+		return
+	default:
+	}
+	logrus.Warnf("Log driver plugin %s %s for container %s, restarting logging", a.driverName, reason, a.ctx.ContainerID)
+	if err := a.plugin.StartLogging(a.file, a.ctx); err != nil {
+		logrus.Errorf("Failed to restart logging with plugin %s: %v", a.driverName, err)
+	}
+}
+
+func isTimeout(err error) bool {
+	e, ok := err.(interface {
+		Timeout() bool
+	})
+	return ok && e.Timeout()
+}
+
+func (a *pluginAdapter) Close() error {
+	a.closeOnce.Do(func() {
+		close(a.closed)
+	})
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.listener == nil {
+		return nil
+	}
+	var err error
+	if a.conn != nil {
+		err = a.conn.Close()
+		a.conn = nil
+	}
+	a.listener.Close()
+	a.listener = nil
+	// the plugin reads what is left in the stream before it stops
+	if stopErr := a.plugin.StopLogging(a.file); stopErr != nil {
+		logrus.Warnf("Failed to stop logging with plugin %s: %v", a.driverName, stopErr)
+	}
+	if rmErr := os.Remove(a.file); rmErr != nil && !os.IsNotExist(rmErr) {
+		logrus.Warnf("Failed to remove the log stream of plugin %s: %v", a.driverName, rmErr)
+	}
+	return err
+}
+
+// pluginAdapterWithRead is the adapter of the plugins that can read the logs
+// they were sent back.
+type pluginAdapterWithRead struct {
+	*pluginAdapter
+}
+
+func (a *pluginAdapterWithRead) ReadLogs(config ReadConfig) *LogWatcher {
+	watcher := NewLogWatcher()
+	send := func(msg *Message) bool {
+		select {
+		case watcher.Msg <- msg:
+			return true
+		case <-watcher.WatchClose():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(watcher.Msg)
+
+		if config.Tail > 0 && config.Filtered() {
+			// plugins may ignore the selection of the read config, so the
+			// selected messages are counted here, reading all the logs
+			// before following the new ones
+			tailConfig := config
+			tailConfig.Tail = -1
+			tailConfig.Follow = false
+			tail := make([]*Message, 0, config.Tail)
+			pastUntil, err := a.readLogs(watcher, tailConfig, func(msg *Message) bool {
+				if len(tail) == config.Tail {
+					copy(tail, tail[1:])
+					tail = tail[:len(tail)-1]
+				}
+				tail = append(tail, msg)
+				return true
+			})
+			if err != nil {
+				a.sendError(watcher, err)
+				return
+			}
+			for _, msg := range tail {
+				if !send(msg) {
+					return
+				}
+			}
+			if !config.Follow || pastUntil {
+				return
+			}
+			config.Tail = 0
+		}
+		if _, err := a.readLogs(watcher, config, send); err != nil {
+			a.sendError(watcher, err)
+		}
+	}()
+	return watcher
+}
+
+// readLogs passes the messages the plugin reads for config to send, until
+// the logs end, send returns false or the watcher is closed. It reports
+// whether it stopped at a message logged after config.Until.
+func (a *pluginAdapterWithRead) readLogs(watcher *LogWatcher, config ReadConfig, send func(*Message) bool) (bool, error) {
+	stream, err := a.plugin.ReadLogs(a.ctx, config)
+	if err != nil {
+		return false, fmt.Errorf("error reading logs from plugin %s: %v", a.driverName, err)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// unblock the decoder when the reader goes away
+		select {
+		case <-watcher.WatchClose():
+		case <-done:
+		}
+		stream.Close()
+	}()
+
+	dec := logdriver.NewDecoder(stream)
+	for {
+		var entry logdriver.LogEntry
+		if err := dec.Decode(&entry); err != nil {
+			if err == io.EOF {
+				return false, nil
+			}
+			select {
+			case <-watcher.WatchClose():
+				return false, nil
+			default:
+				return false, err
+			}
+		}
+		msg := &Message{
+			Line:      entry.Line,
+			Source:    entry.Source,
+			Timestamp: time.Unix(0, entry.TimeNano).UTC(),
+			Attrs:     entry.Attrs,
+		}
+		// plugins may ignore the until time and the selection of the
+		// read config
+		if config.PastUntil(msg) {
+			return true, nil
+		}
+		if !config.Selects(msg) {
+			continue
+		}
+		if !send(msg) {
+			return false, nil
+		}
+	}
+}
+
+func (a *pluginAdapterWithRead) sendError(watcher *LogWatcher, err error) {
+	select {
+	case watcher.Err <- err:
+	case <-watcher.WatchClose():
+	}
+}
@@ -0,0 +1,11 @@
+// +build !windows
+
+package logger
+
+import "net"
+
+// listenPluginStream creates the unix socket at path that plugins connect to
+// to read the logs of a container.
+func listenPluginStream(path string) (*net.UnixListener, error) {
+	return net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+}
@@ -0,0 +1,190 @@
+// +build !windows
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger/logdriver"
+	"github.com/docker/docker/pkg/plugins"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+// fakeLogPlugin reads the logs it is sent, ignoring the first startsIgnored
+// calls to LogDriver.StartLogging, and serves them back.
+type fakeLogPlugin struct {
+	startsIgnored int
+
+	mu      sync.Mutex
+	starts  int
+	stopped bool
+	entries []logdriver.LogEntry
+	readers sync.WaitGroup
+}
+
+func (p *fakeLogPlugin) serve(t *testing.T) (*logPluginProxy, func()) {
+	mux := http.NewServeMux()
+	reply := func(w http.ResponseWriter, v interface{}) {
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("/LogDriver.StartLogging", func(w http.ResponseWriter, r *http.Request) {
+		var req logPluginProxyStartLoggingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		p.mu.Lock()
+		p.starts++
+		ignore := p.starts <= p.startsIgnored
+		p.mu.Unlock()
+		if !ignore {
+			p.readers.Add(1)
+			go p.read(t, req.File)
+		}
+		reply(w, map[string]string{})
+	})
+	mux.HandleFunc("/LogDriver.StopLogging", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		p.stopped = true
+		p.mu.Unlock()
+		reply(w, map[string]string{})
+	})
+	mux.HandleFunc("/LogDriver.Capabilities", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, map[string]interface{}{"Cap": Capability{ReadLogs: true}})
+	})
+	mux.HandleFunc("/LogDriver.ReadLogs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		enc := logdriver.NewEncoder(w)
+		for i := range p.entries {
+			enc.Encode(&p.entries[i])
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	u, _ := url.Parse(server.URL)
+	client, err := plugins.NewClient("tcp://"+u.Host, &tlsconfig.Options{InsecureSkipVerify: true})
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return &logPluginProxy{client}, server.Close
+}
+
+func (p *fakeLogPlugin) read(t *testing.T, file string) {
+	defer p.readers.Done()
+	conn, err := net.Dial("unix", file)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+	dec := logdriver.NewDecoder(conn)
+	for {
+		var entry logdriver.LogEntry
+		if err := dec.Decode(&entry); err != nil {
+			return
+		}
+		p.mu.Lock()
+		p.entries = append(p.entries, entry)
+		p.mu.Unlock()
+	}
+}
+
+func testPluginLogger(t *testing.T, p *fakeLogPlugin, lines int, lineSize int) {
+	dir, err := ioutil.TempDir("", "logger-plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(dir string) { pluginStreamDir = dir }(pluginStreamDir)
+	pluginStreamDir = dir
+
+	proxy, stop := p.serve(t)
+	defer stop()
+
+	l, err := makePluginCreator("fake", proxy)(Context{ContainerID: "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := bytes.Repeat([]byte("x"), lineSize)
+	for i := 0; i < lines; i++ {
+		if err := l.Log(&Message{Line: line, Source: "stdout", Timestamp: time.Unix(int64(i), 0).UTC()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	stopped := make(chan struct{})
+	go func() {
+		p.readers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the plugin to read the logs")
+	}
+
+	p.mu.Lock()
+	if !p.stopped {
+		t.Fatal("expected logging to be stopped")
+	}
+	if len(p.entries) != lines {
+		t.Fatalf("expected %d entries, got %d", lines, len(p.entries))
+	}
+	p.mu.Unlock()
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 0 {
+		t.Fatalf("expected the log stream to be removed, found %d files", len(files))
+	}
+
+	watcher := l.(LogReader).ReadLogs(ReadConfig{})
+	defer watcher.Close()
+	var read int
+	for msg := range watcher.Msg {
+		if msg.Source != "stdout" || !msg.Timestamp.Equal(time.Unix(int64(read), 0)) || !bytes.Equal(msg.Line, line) {
+			t.Fatalf("unexpected message %d: %+v", read, msg)
+		}
+		read++
+	}
+	if read != lines {
+		t.Fatalf("expected to read %d messages, got %d", lines, read)
+	}
+
+	// the plugin ignores the read config, and the tail of the selected
+	// messages is counted by the daemon
+	until := time.Unix(10, 0)
+	watcher = l.(LogReader).ReadLogs(ReadConfig{Tail: 2, Until: until})
+	defer watcher.Close()
+	var tail []time.Time
+	for msg := range watcher.Msg {
+		tail = append(tail, msg.Timestamp)
+	}
+	if len(tail) != 2 || !tail[0].Equal(time.Unix(9, 0)) || !tail[1].Equal(until) {
+		t.Fatalf("expected the last 2 messages until %v, got %v", until, tail)
+	}
+}
+
+func TestPluginLogger(t *testing.T) {
+	testPluginLogger(t, &fakeLogPlugin{}, 100, 10)
+}
+
+func TestPluginLoggerRestart(t *testing.T) {
+	defer func(timeout time.Duration) { pluginWriteTimeout = timeout }(pluginWriteTimeout)
+	pluginWriteTimeout = 100 * time.Millisecond
+
+	// the plugin only connects to the stream after it is told about it
+	// again
+	testPluginLogger(t, &fakeLogPlugin{startsIgnored: 1}, 200, 1024)
+}
@@ -0,0 +1,207 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	// ModeOpt is the log option selecting how messages are delivered to
+	// the driver, BlockingMode or NonBlockingMode.
+	ModeOpt = "mode"
+	// BufferSizeOpt is the log option setting the size of the buffer of
+	// the non-blocking mode.
+	BufferSizeOpt = "max-buffer-size"
+
+	// BlockingMode makes containers wait for the driver to log their
+	// messages. It is the default.
+	BlockingMode = "blocking"
+	// NonBlockingMode buffers the messages of containers in memory, and
+	// drops them when the driver doesn't keep up.
+	NonBlockingMode = "non-blocking"
+
+	// DefaultBufferSize is the size of the buffer of the non-blocking mode
+	// if BufferSizeOpt isn't set.
+	DefaultBufferSize = 1 << 20
+
+	// ringDrainTimeout is how long closing a RingLogger waits for its driver
+	// to log the buffered messages before dropping them.
+	ringDrainTimeout = 5 * time.Second
+)
+
+var errRingClosed = errors.New("logger: ring buffer is closed")
+
+// DropCounter is implemented by loggers that drop messages rather than
+// wait for their driver.
+type DropCounter interface {
+	// Dropped returns the number of messages dropped so far.
+	Dropped() int64
+}
+
+// RingLogger is a Logger buffering messages in memory for another logger,
+// so that logging never blocks. Messages are dropped while the buffer is
+// full.
+type RingLogger struct {
+	buffer       *messageRing
+	l            Logger
+	dropped      int64 // accessed atomically
+	done         chan struct{}
+	drainTimeout time.Duration
+}
+
+type ringWithReader struct {
+	*RingLogger
+}
+
+func (r *ringWithReader) ReadLogs(cfg ReadConfig) *LogWatcher {
+	return r.RingLogger.l.(LogReader).ReadLogs(cfg)
+}
+
+// NewRingLogger returns a logger buffering up to maxSize bytes of messages
+// for driver. It can read logs if driver can.
+func NewRingLogger(driver Logger, maxSize int64) Logger {
+	if maxSize <= 0 {
+		maxSize = DefaultBufferSize
+	}
+	r := &RingLogger{
+		buffer:       newRing(maxSize),
+		l:            driver,
+		done:         make(chan struct{}),
+		drainTimeout: ringDrainTimeout,
+	}
+	go r.run()
+	if _, ok := driver.(LogReader); ok {
+		return &ringWithReader{r}
+	}
+	return r
+}
+
+// Log queues the message for the driver, or drops it if the buffer is full.
+func (r *RingLogger) Log(msg *Message) error {
+	queued, err := r.buffer.Enqueue(msg)
+	if err != nil {
+		return err
+	}
+	if !queued {
+		atomic.AddInt64(&r.dropped, 1)
+	}
+	return nil
+}
+
+// Name returns the name of the driver.
+func (r *RingLogger) Name() string {
+	return r.l.Name()
+}
+
+// Dropped returns the number of messages dropped because the buffer was
+// full.
+func (r *RingLogger) Dropped() int64 {
+	return atomic.LoadInt64(&r.dropped)
+}
+
+// Close stops accepting messages, waits for the driver to log the buffered
+// ones, and closes it. The messages the driver hasn't logged after the drain
+// timeout are dropped, so that a stalled driver doesn't block the container.
+func (r *RingLogger) Close() error {
+	r.buffer.Close()
+	select {
+	case <-r.done:
+	case <-time.After(r.drainTimeout):
+		dropped := r.buffer.Drop()
+		atomic.AddInt64(&r.dropped, int64(dropped))
+		logrus.Warnf("Logger %s didn't log the buffered messages in time: %d messages dropped", r.l.Name(), dropped)
+	}
+	return r.l.Close()
+}
+
+// run logs the buffered messages with the driver until the buffer is closed
+// and empty.
+func (r *RingLogger) run() {
+	defer close(r.done)
+	for {
+		msg, err := r.buffer.Dequeue()
+		if err != nil {
+			return
+		}
+		if err := r.l.Log(msg); err != nil {
+			logrus.Debugf("Failed to log message for logger %s: %v", r.l.Name(), err)
+		}
+	}
+}
+
+// messageRing is a queue of messages bounded by the total size of their
+// lines.
+type messageRing struct {
+	mu   sync.Mutex
+	wait *sync.Cond
+
+	queue    []*Message
+	size     int64
+	maxSize  int64
+	isClosed bool
+}
+
+func newRing(maxSize int64) *messageRing {
+	r := &messageRing{maxSize: maxSize}
+	r.wait = sync.NewCond(&r.mu)
+	return r
+}
+
+// Enqueue adds the message to the queue, unless it would exceed the size of
+// the ring. A message larger than the ring is only queued if it is empty.
+func (r *messageRing) Enqueue(msg *Message) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.isClosed {
+		return false, errRingClosed
+	}
+	size := int64(len(msg.Line))
+	if r.size+size > r.maxSize && len(r.queue) > 0 {
+		return false, nil
+	}
+	r.queue = append(r.queue, msg)
+	r.size += size
+	r.wait.Signal()
+	return true, nil
+}
+
+// Dequeue removes the oldest message of the queue, waiting for one if it is
+// empty. It returns an error once the ring is closed and empty.
+func (r *messageRing) Dequeue() (*Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(r.queue) == 0 {
+		if r.isClosed {
+			return nil, errRingClosed
+		}
+		r.wait.Wait()
+	}
+	msg := r.queue[0]
+	r.queue[0] = nil
+	r.queue = r.queue[1:]
+	r.size -= int64(len(msg.Line))
+	return msg, nil
+}
+
+// Close stops the ring from accepting messages. The queued ones can still be
+// dequeued.
+func (r *messageRing) Close() {
+	r.mu.Lock()
+	r.isClosed = true
+	r.wait.Broadcast()
+	r.mu.Unlock()
+}
+
+// Drop removes the queued messages, and returns how many there were.
+func (r *messageRing) Drop() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(r.queue)
+	r.queue = nil
+	r.size = 0
+	return n
+}
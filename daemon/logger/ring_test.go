@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockedLogger logs nothing until it is released.
+type blockedLogger struct {
+	release chan struct{}
+
+	mu       sync.Mutex
+	messages []*Message
+	closed   bool
+}
+
+func (l *blockedLogger) Log(msg *Message) error {
+	<-l.release
+	l.mu.Lock()
+	l.messages = append(l.messages, msg)
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *blockedLogger) Name() string {
+	return "blocked"
+}
+
+func (l *blockedLogger) Close() error {
+	l.mu.Lock()
+	l.closed = true
+	l.mu.Unlock()
+	return nil
+}
+
+func TestRingLogger(t *testing.T) {
+	driver := &blockedLogger{release: make(chan struct{})}
+	l := NewRingLogger(driver, 10)
+	if _, ok := l.(LogReader); ok {
+		t.Fatal("expected the ring logger not to read logs of a driver that can't")
+	}
+
+	// the first message is dequeued by the driver, or fills the buffer
+	// with the second one, and the others are dropped
+	for i := 0; i < 10; i++ {
+		if err := l.Log(&Message{Line: []byte("12345"), Source: "stdout"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dropped := l.(DropCounter).Dropped()
+	if dropped < 7 || dropped > 8 {
+		t.Fatalf("expected 7 or 8 dropped messages, got %d", dropped)
+	}
+
+	close(driver.release)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !driver.closed {
+		t.Fatal("expected the driver to be closed")
+	}
+	if logged := int64(len(driver.messages)); logged+dropped != 10 {
+		t.Fatalf("expected %d messages to be logged, got %d", 10-dropped, logged)
+	}
+	if err := l.Log(&Message{Line: []byte("late")}); err == nil {
+		t.Fatal("expected logging after close to fail")
+	}
+}
+
+func TestRingLoggerLargeMessage(t *testing.T) {
+	driver := &blockedLogger{release: make(chan struct{})}
+	close(driver.release)
+	l := NewRingLogger(driver, 1)
+	if err := l.Log(&Message{Line: []byte("larger than the buffer")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(driver.messages) != 1 || l.(DropCounter).Dropped() != 0 {
+		t.Fatalf("expected a message larger than the empty buffer to be logged, got %d messages", len(driver.messages))
+	}
+}
+
+func TestRingLoggerCloseStalledDriver(t *testing.T) {
+	driver := &blockedLogger{release: make(chan struct{})}
+	defer close(driver.release)
+	l := NewRingLogger(driver, 100)
+	l.(*RingLogger).drainTimeout = 10 * time.Millisecond
+
+	for i := 0; i < 5; i++ {
+		if err := l.Log(&Message{Line: []byte("12345"), Source: "stdout"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	closed := make(chan error)
+	go func() {
+		closed <- l.Close()
+	}()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected closing to give up on the stalled driver")
+	}
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+	if !driver.closed {
+		t.Fatal("expected the driver to be closed")
+	}
+	// one message may be held by the stalled driver
+	if dropped := l.(DropCounter).Dropped(); dropped < 4 || dropped > 5 {
+		t.Fatalf("expected the buffered messages to be dropped, got %d", dropped)
+	}
+}
+
+func TestValidateModeOpts(t *testing.T) {
+	valid := []map[string]string{
+		{},
+		{ModeOpt: BlockingMode},
+		{ModeOpt: NonBlockingMode},
+		{ModeOpt: NonBlockingMode, BufferSizeOpt: "4m"},
+	}
+	for _, cfg := range valid {
+		if err := validateModeOpts(cfg); err != nil {
+			t.Fatalf("expected %v to be valid, got %v", cfg, err)
+		}
+	}
+	invalid := []map[string]string{
+		{ModeOpt: "sometimes"},
+		{BufferSizeOpt: "4m"},
+		{ModeOpt: BlockingMode, BufferSizeOpt: "4m"},
+		{ModeOpt: NonBlockingMode, BufferSizeOpt: "lots"},
+		{ModeOpt: NonBlockingMode, BufferSizeOpt: "0"},
+	}
+	for _, cfg := range invalid {
+		if err := validateModeOpts(cfg); err == nil {
+			t.Fatalf("expected %v to be invalid", cfg)
+		}
+	}
+}
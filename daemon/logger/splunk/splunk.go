@@ -160,6 +160,17 @@ func (l *splunkLogger) Log(msg *logger.Message) error {
 	message.Time = fmt.Sprintf("%f", float64(msg.Timestamp.UnixNano())/1000000000)
 	message.Event.Line = string(msg.Line)
 	message.Event.Source = msg.Source
+	if len(msg.Attrs) > 0 {
+		// the attributes of the message take precedence
+		attrs := make(map[string]string, len(message.Event.Attrs)+len(msg.Attrs))
+		for k, v := range message.Event.Attrs {
+			attrs[k] = v
+		}
+		for k, v := range msg.Attrs {
+			attrs[k] = v
+		}
+		message.Event.Attrs = attrs
+	}
 
 	jsonEvent, err := json.Marshal(&message)
 	if err != nil {
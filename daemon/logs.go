@@ -3,6 +3,7 @@ package daemon
 import (
 	"fmt"
 	"io"
+	"regexp"
 	"strconv"
 	"time"
 
@@ -39,7 +40,6 @@ func (daemon *Daemon) ContainerLogs(ctx context.Context, containerName string, c
 		return logger.ErrReadLogsNotSupported
 	}
 
-	follow := config.Follow && container.IsRunning()
 	tailLines, err := strconv.Atoi(config.Tail)
 	if err != nil {
 		tailLines = -1
@@ -55,13 +55,47 @@ func (daemon *Daemon) ContainerLogs(ctx context.Context, containerName string, c
 		}
 		since = time.Unix(s, n)
 	}
+
+	var until time.Time
+	if config.Until != "" {
+		s, n, err := timetypes.ParseTimestamps(config.Until, 0)
+		if err != nil {
+			return err
+		}
+		until = time.Unix(s, n)
+	}
+
+	var filter *regexp.Regexp
+	if config.Grep != "" {
+		if filter, err = regexp.Compile(config.Grep); err != nil {
+			return fmt.Errorf("invalid grep pattern %q: %v", config.Grep, err)
+		}
+	}
+
+	// there is nothing to follow once past the until time
+	follow := config.Follow && container.IsRunning() && (until.IsZero() || until.After(time.Now()))
 	readConfig := logger.ReadConfig{
 		Since:  since,
+		Until:  until,
 		Tail:   tailLines,
 		Follow: follow,
+		Filter: filter,
+	}
+	if !config.ShowStdout {
+		readConfig.Source = "stderr"
+	} else if !config.ShowStderr {
+		readConfig.Source = "stdout"
 	}
 	logs := logReader.ReadLogs(readConfig)
 
+	// stop following at the until time, even if no message is logged after it
+	var untilReached <-chan time.Time
+	if follow && !until.IsZero() {
+		timer := time.NewTimer(until.Sub(time.Now()))
+		defer timer.Stop()
+		untilReached = timer.C
+	}
+
 	wf := ioutils.NewWriteFlusher(config.OutStream)
 	defer wf.Close()
 	close(started)
@@ -82,6 +116,9 @@ func (daemon *Daemon) ContainerLogs(ctx context.Context, containerName string, c
 		case <-ctx.Done():
 			logs.Close()
 			return nil
+		case <-untilReached:
+			// let the reader send the messages logged until now
+			logs.Close()
 		case msg, ok := <-logs.Msg:
 			if !ok {
 				logrus.Debug("logs: end stream")
@@ -113,10 +150,59 @@ func (daemon *Daemon) ContainerLogs(ctx context.Context, containerName string, c
 }
 
 func (daemon *Daemon) getLogger(container *container.Container) (logger.Logger, error) {
+	if container.HostConfig.LogConfig.Type == "none" {
+		return nil, logger.ErrReadLogsNoneDriver
+	}
 	if container.LogDriver != nil && container.IsRunning() {
 		return container.LogDriver, nil
 	}
-	return container.StartLogger(container.HostConfig.LogConfig)
+	return container.StartLogReader(container.HostConfig.LogConfig)
+}
+
+// logDropEventInterval is how often the log messages dropped by containers
+// using the non-blocking log mode are reported.
+var logDropEventInterval = 30 * time.Second
+
+// logDropLoop emits a log-dropped event for each container that dropped log
+// messages since the last interval, until the daemon shuts down.
+func (daemon *Daemon) logDropLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	reported := make(map[string]int64)
+	for range ticker.C {
+		if daemon.IsShuttingDown() {
+			return
+		}
+		seen := make(map[string]bool)
+		for _, c := range daemon.List() {
+			c.Lock()
+			dropped := c.LogDroppedMessages()
+			c.Unlock()
+			if dropped == 0 {
+				continue
+			}
+			seen[c.ID] = true
+			last := reported[c.ID]
+			if dropped < last {
+				// the container restarted
+				last = 0
+			}
+			if dropped == last {
+				continue
+			}
+			reported[c.ID] = dropped
+			daemon.LogDaemonEventWithAttributes("log-dropped", map[string]string{
+				"container": c.ID,
+				"dropped":   strconv.FormatInt(dropped-last, 10),
+				"total":     strconv.FormatInt(dropped, 10),
+			})
+		}
+		for id := range reported {
+			if !seen[id] {
+				delete(reported, id)
+			}
+		}
+	}
 }
 
 // mergeLogConfig merges the daemon log config to the container's log config if the container's log driver is not specified.
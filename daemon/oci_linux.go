@@ -534,7 +534,7 @@ func setMounts(daemon *Daemon, s *specs.Spec, c *container.Container, mounts []c
 
 		opts := []string{"rbind"}
 
-		if m.Data == "nfs" || m.Data == "ceph" {
+		if m.Data == "nfs" {
 			mt.Type = m.Data
 			opts = []string{"defaults"}
 		} else if pFlag != 0 {
@@ -0,0 +1,147 @@
+package daemon
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	volumetypes "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/volume"
+	volumestore "github.com/docker/docker/volume/store"
+	"github.com/docker/engine-api/types/filters"
+	timetypes "github.com/docker/engine-api/types/time"
+)
+
+var acceptedVolumePruneFilterTags = map[string]bool{
+	"label":  true,
+	"driver": true,
+	"until":  true,
+}
+
+// VolumesPrune removes the volumes that are not used by any container and
+// match the filter. If dryRun is set, the volumes that would be removed are
+// reported, and left alone.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumesPrune(filter string, dryRun bool) (*volumetypes.PruneReport, error) {
+	pruneFilters, err := filters.FromParam(filter)
+	if err != nil {
+		return nil, err
+	}
+	return daemon.volumesPrune(pruneFilters, dryRun)
+}
+
+func (daemon *Daemon) volumesPrune(pruneFilters filters.Args, dryRun bool) (*volumetypes.PruneReport, error) {
+	if err := pruneFilters.Validate(acceptedVolumePruneFilterTags); err != nil {
+		return nil, err
+	}
+	until, err := pruneUntil(pruneFilters, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	vols, _, err := daemon.volumes.List()
+	if err != nil {
+		return nil, err
+	}
+	report := &volumetypes.PruneReport{}
+	for _, v := range daemon.volumes.FilterByUsed(vols, false) {
+		if !daemon.pruneMatch(v, pruneFilters, until) {
+			continue
+		}
+
+		var reclaimed uint64
+		if usage, err := daemon.volumes.Usage(v); err == nil && usage.Reclaimable {
+			reclaimed = uint64(usage.Used)
+		}
+		if !dryRun {
+			if err := daemon.volumes.Remove(v); err != nil {
+				// the volume may have been taken by a container since
+				// it was listed
+				if !volumestore.IsInUse(err) {
+					logrus.Warnf("Failed to prune volume %s: %v", v.Name(), err)
+				}
+				report.Warnings = append(report.Warnings, fmt.Sprintf("Unable to remove volume %s: %v", v.Name(), err))
+				continue
+			}
+			daemon.LogVolumeEvent(v.Name(), "destroy", map[string]string{"driver": v.DriverName(), "pruned": "true"})
+		}
+		report.VolumesDeleted = append(report.VolumesDeleted, v.Name())
+		report.SpaceReclaimed += reclaimed
+	}
+	if !dryRun && len(report.VolumesDeleted) > 0 {
+		daemon.LogVolumeEvent("", "prune", map[string]string{
+			"count":     strconv.Itoa(len(report.VolumesDeleted)),
+			"reclaimed": strconv.FormatUint(report.SpaceReclaimed, 10),
+		})
+	}
+	return report, nil
+}
+
+// pruneUntil returns the time before which volumes must have been created
+// to be pruned, from the until filter, relative to now. It returns the zero
+// time without the filter.
+func pruneUntil(pruneFilters filters.Args, now time.Time) (time.Time, error) {
+	values := pruneFilters.Get("until")
+	switch len(values) {
+	case 0:
+		return time.Time{}, nil
+	case 1:
+	default:
+		return time.Time{}, fmt.Errorf("more than one until filter specified")
+	}
+	ts, err := timetypes.GetTimestamp(values[0], now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid filter 'until=%s': %v", values[0], err)
+	}
+	seconds, nanoseconds, err := timetypes.ParseTimestamps(ts, 0)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid filter 'until=%s': %v", values[0], err)
+	}
+	return time.Unix(seconds, nanoseconds), nil
+}
+
+// pruneMatch returns whether the volume matches the prune filters. Drivers
+// must match exactly. Volumes created before their creation time was
+// recorded never match an until filter.
+func (daemon *Daemon) pruneMatch(v volume.Volume, pruneFilters filters.Args, until time.Time) bool {
+	if pruneFilters.Include("driver") && !pruneFilters.ExactMatch("driver", v.DriverName()) {
+		return false
+	}
+	if pruneFilters.Include("label") {
+		var labels map[string]string
+		if lv, ok := v.(volume.LabeledVolume); ok {
+			labels = lv.Labels()
+		}
+		if !pruneFilters.MatchKVList("label", labels) {
+			return false
+		}
+	}
+	if !until.IsZero() {
+		created := daemon.volumes.CreatedAt(v.Name())
+		if created.IsZero() || !created.Before(until) {
+			return false
+		}
+	}
+	return true
+}
+
+// volumePruneLoop prunes the volumes matching the filters of the daemon
+// configuration every interval, until the daemon shuts down.
+func (daemon *Daemon) volumePruneLoop(interval time.Duration, pruneFilters filters.Args) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if daemon.IsShuttingDown() {
+			return
+		}
+		report, err := daemon.volumesPrune(pruneFilters, false)
+		if err != nil {
+			logrus.Errorf("Failed to prune volumes: %v", err)
+			continue
+		}
+		if len(report.VolumesDeleted) > 0 {
+			logrus.Infof("Pruned %d volumes, reclaiming %d bytes", len(report.VolumesDeleted), report.SpaceReclaimed)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types/filters"
+)
+
+func TestPruneUntil(t *testing.T) {
+	now := time.Unix(1000000, 0)
+
+	until, err := pruneUntil(filters.NewArgs(), now)
+	if err != nil || !until.IsZero() {
+		t.Fatalf("Expected no until time without filter, got %v, %v", until, err)
+	}
+
+	args := filters.NewArgs()
+	args.Add("until", "24h")
+	until, err = pruneUntil(args, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := now.Add(-24 * time.Hour); !until.Equal(expected) {
+		t.Fatalf("Expected until %v, got %v", expected, until)
+	}
+
+	args = filters.NewArgs()
+	args.Add("until", "1000")
+	if until, err = pruneUntil(args, now); err != nil || until.Unix() != 1000 {
+		t.Fatalf("Expected until 1000, got %v, %v", until, err)
+	}
+
+	args.Add("until", "2000")
+	if _, err := pruneUntil(args, now); err == nil {
+		t.Fatal("Expected an error with two until filters")
+	}
+
+	args = filters.NewArgs()
+	args.Add("until", "yesterday")
+	if _, err := pruneUntil(args, now); err == nil {
+		t.Fatal("Expected an error with an invalid until filter")
+	}
+}
+
+func TestVolumePrunePolicy(t *testing.T) {
+	config := &Config{}
+	if interval, _, err := config.volumePrunePolicy(); err != nil || interval != 0 {
+		t.Fatalf("Expected no pruning by default, got %v, %v", interval, err)
+	}
+
+	config = &Config{
+		CommonConfig: CommonConfig{
+			VolumePruneInterval: "6h",
+			VolumePruneFilters:  []string{"driver=local", "until=24h"},
+		},
+	}
+	interval, pruneFilters, err := config.volumePrunePolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if interval != 6*time.Hour {
+		t.Fatalf("Expected an interval of 6h, got %v", interval)
+	}
+	if !pruneFilters.ExactMatch("driver", "local") || pruneFilters.Get("until")[0] != "24h" {
+		t.Fatalf("Unexpected filters %v", pruneFilters)
+	}
+
+	for _, invalid := range []struct {
+		interval string
+		filters  []string
+	}{
+		{"", []string{"driver=local"}},
+		{"often", nil},
+		{"-1h", nil},
+		{"1h", []string{"dangling=true"}},
+		{"1h", []string{"driver"}},
+	} {
+		config := &Config{}
+		config.VolumePruneInterval = invalid.interval
+		config.VolumePruneFilters = invalid.filters
+		if _, _, err := config.volumePrunePolicy(); err == nil {
+			t.Fatalf("Expected an error for interval %q and filters %v", invalid.interval, invalid.filters)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/errors"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/stringid"
+	volumestore "github.com/docker/docker/volume/store"
+	"github.com/docker/engine-api/types"
+)
+
+// VolumeExport writes the contents of the volume with the given name to the
+// given writer as a tar archive, gzip compressed if compress is set. The
+// volume is mounted on the host for the duration of the export, and can't
+// be removed meanwhile.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeExport(name string, compress bool, out io.Writer) error {
+	v, err := daemon.volumes.Get(name)
+	if err != nil {
+		return err
+	}
+	ref := "export-" + stringid.GenerateNonCryptoID()
+	if v, err = daemon.volumes.GetWithRef(v.Name(), v.DriverName(), ref); err != nil {
+		return err
+	}
+	defer daemon.volumes.Dereference(v, ref)
+
+	path, err := v.Mount(ref)
+	if err != nil {
+		return volumeOperationError("mount", name, err)
+	}
+	defer func() {
+		if err := v.Unmount(ref); err != nil {
+			logrus.Warnf("Failed to unmount volume %s after exporting it: %v", v.Name(), err)
+		}
+	}()
+
+	compression := archive.Uncompressed
+	if compress {
+		compression = archive.Gzip
+	}
+	uidMaps, gidMaps := daemon.GetUIDGIDMaps()
+	data, err := archive.TarWithOptions(path, &archive.TarOptions{
+		Compression:   compression,
+		UIDMaps:       uidMaps,
+		GIDMaps:       gidMaps,
+		IncludeXattrs: true,
+	})
+	if err != nil {
+		return fmt.Errorf("Error exporting volume %s: %v", name, err)
+	}
+	defer data.Close()
+
+	daemon.LogVolumeEvent(v.Name(), "export", map[string]string{"driver": v.DriverName()})
+	if _, err := io.Copy(out, data); err != nil {
+		return fmt.Errorf("Error exporting volume %s: %v", name, err)
+	}
+	return nil
+}
+
+// VolumeImport creates a volume with the given name, driver and options and
+// fills it with the contents of the tar archive read from in, which may be
+// compressed. The volume must not exist yet, and is removed again if the
+// archive can't be extracted.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeImport(name, driverName string, opts, labels map[string]string, in io.Reader) (*types.Volume, error) {
+	if name == "" {
+		name = stringid.GenerateNonCryptoID()
+	} else if _, err := daemon.volumes.Get(name); err == nil {
+		return nil, errors.NewRequestConflictError(fmt.Errorf("A volume named %s already exists. Choose a different volume name.", name))
+	} else if !volumestore.IsNotExist(err) {
+		return nil, err
+	}
+
+	v, err := daemon.volumes.Create(name, driverName, opts, labels)
+	if err != nil {
+		if volumestore.IsNameConflict(err) {
+			return nil, errors.NewRequestConflictError(fmt.Errorf("A volume named %s already exists. Choose a different volume name.", name))
+		}
+		return nil, volumeOperationError("create", name, err)
+	}
+	daemon.LogVolumeEvent(v.Name(), "create", map[string]string{"driver": v.DriverName()})
+
+	if err := daemon.importVolume(v.Name(), v.DriverName(), in); err != nil {
+		if rmErr := daemon.volumes.Remove(v); rmErr != nil {
+			logrus.Errorf("Failed to remove volume %s after its import failed: %v", v.Name(), rmErr)
+		} else {
+			daemon.LogVolumeEvent(v.Name(), "destroy", map[string]string{"driver": v.DriverName()})
+		}
+		return nil, err
+	}
+
+	daemon.LogVolumeEvent(v.Name(), "import", map[string]string{"driver": v.DriverName()})
+	apiV := volumeToAPIType(v)
+	apiV.Mountpoint = v.Path()
+	return apiV, nil
+}
+
+// importVolume extracts the archive read from in into the volume, holding a
+// reference to it so that it can't be removed meanwhile.
+func (daemon *Daemon) importVolume(name, driverName string, in io.Reader) error {
+	ref := "import-" + stringid.GenerateNonCryptoID()
+	v, err := daemon.volumes.GetWithRef(name, driverName, ref)
+	if err != nil {
+		return err
+	}
+	defer daemon.volumes.Dereference(v, ref)
+
+	path, err := v.Mount(ref)
+	if err != nil {
+		return volumeOperationError("mount", name, err)
+	}
+	defer func() {
+		if err := v.Unmount(ref); err != nil {
+			logrus.Warnf("Failed to unmount volume %s after importing it: %v", name, err)
+		}
+	}()
+
+	uidMaps, gidMaps := daemon.GetUIDGIDMaps()
+	if err := untarVolume(in, path, uidMaps, gidMaps); err != nil {
+		return fmt.Errorf("Error importing volume %s: %v", name, err)
+	}
+	return nil
+}
+
+// untarVolume extracts the archive read from in into the mounted volume at
+// path. The archive is extracted chrooted into the volume, so that symlinks
+// in the archive, or already in the volume, can't lead outside of it.
+func untarVolume(in io.Reader, path string, uidMaps, gidMaps []idtools.IDMap) error {
+	return chrootarchive.Untar(in, path, &archive.TarOptions{
+		UIDMaps: uidMaps,
+		GIDMaps: gidMaps,
+	})
+}
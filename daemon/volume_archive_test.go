@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/pkg/reexec"
+)
+
+func init() {
+	reexec.Init()
+}
+
+// TestUntarVolumeSymlinkBreakout checks that an archive can't write outside
+// of the volume through an absolute symlink it creates first.
+func TestUntarVolumeSymlinkBreakout(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("extracting chrooted requires root")
+	}
+	dir, err := ioutil.TempDir("", "volume-import-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	volume := filepath.Join(dir, "volume")
+	outside := filepath.Join(dir, "outside")
+	for _, d := range []string{volume, outside} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "a", Typeflag: tar.TypeSymlink, Linkname: outside, Mode: 0777}); err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("pwned")
+	if err := tw.WriteHeader(&tar.Header{Name: "a/passwd", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the symlink resolves inside the volume once chrooted, so the file may
+	// be extracted there or refused, but never written outside
+	untarVolume(buf, volume, nil, nil)
+	if _, err := os.Stat(filepath.Join(outside, "passwd")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be written outside of the volume, got %v", err)
+	}
+	if files, err := ioutil.ReadDir(outside); err != nil || len(files) != 0 {
+		t.Fatalf("expected the directory outside of the volume to be empty, got %v (%v)", files, err)
+	}
+}
@@ -0,0 +1,228 @@
+package daemon
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	volumetypes "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errors"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/utils"
+	"github.com/docker/docker/volume"
+	volumestore "github.com/docker/docker/volume/store"
+	"github.com/docker/engine-api/types"
+)
+
+// VolumeRotateKey replaces the encryption key of the volume with the given
+// name. It is only supported by drivers that encrypt their volumes.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeRotateKey(name string) error {
+	v, err := daemon.volumes.Get(name)
+	if err != nil {
+		return err
+	}
+	if err := daemon.volumes.RotateKey(v.Name()); err != nil {
+		return volumeOperationError("rotate the key of", name, err)
+	}
+	daemon.LogVolumeEvent(v.Name(), "rotate-key", map[string]string{"driver": v.DriverName()})
+	return nil
+}
+
+// VolumeResize changes the size of the volume with the given name to size
+// bytes. Volumes are only shrunk if force is set.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeResize(name string, size int64, force bool) error {
+	v, err := daemon.volumes.Get(name)
+	if err != nil {
+		return err
+	}
+	if err := daemon.volumes.Resize(v.Name(), size, force); err != nil {
+		return volumeOperationError("resize", name, err)
+	}
+	daemon.LogVolumeEvent(v.Name(), "resize", map[string]string{"driver": v.DriverName(), "size": strconv.FormatInt(size, 10)})
+	return nil
+}
+
+// VolumeUnlock breaks the lock held on the volume with the given name by the
+// host that mounted it. Locks of hosts that still appear to use the volume
+// are only broken if force is set.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeUnlock(name string, force bool) error {
+	v, err := daemon.volumes.Get(name)
+	if err != nil {
+		return err
+	}
+	if err := daemon.volumes.Unlock(v.Name(), force); err != nil {
+		return volumeOperationError("unlock", name, err)
+	}
+	daemon.LogVolumeEvent(v.Name(), "unlock", map[string]string{"driver": v.DriverName(), "force": strconv.FormatBool(force)})
+	return nil
+}
+
+// VolumeSnapshotCreate takes a snapshot of the volume with the given name.
+// Snapshots of a volume mounted read-write by a running container may be
+// inconsistent, so they are refused unless freeze is set, in which case the
+// driver freezes the filesystem of the volume while taking the snapshot.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeSnapshotCreate(name, snapshot string, freeze bool) (*volumetypes.Snapshot, error) {
+	if err := validateSnapshotName(snapshot); err != nil {
+		return nil, err
+	}
+	v, err := daemon.volumes.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, rw := daemon.runningVolumeMounts(v); len(rw) > 0 && !freeze {
+		return nil, errors.NewRequestConflictError(fmt.Errorf("Unable to snapshot volume %s, it is mounted read-write by running containers %s: freeze its filesystem to snapshot it while in use", name, strings.Join(rw, ", ")))
+	}
+	snap, err := daemon.volumes.CreateSnapshot(v.Name(), snapshot, freeze)
+	if err != nil {
+		return nil, volumeOperationError("snapshot", name, err)
+	}
+	daemon.LogVolumeEvent(v.Name(), "snapshot-create", map[string]string{"driver": v.DriverName(), "snapshot": snap.Name, "freeze": strconv.FormatBool(freeze)})
+	return snapshotToAPIType(v.Name(), snap), nil
+}
+
+// VolumeSnapshots lists the snapshots of the volume with the given name.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeSnapshots(name string) ([]*volumetypes.Snapshot, error) {
+	v, err := daemon.volumes.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	snaps, err := daemon.volumes.Snapshots(v.Name())
+	if err != nil {
+		return nil, volumeOperationError("list the snapshots of", name, err)
+	}
+	ls := make([]*volumetypes.Snapshot, 0, len(snaps))
+	for _, snap := range snaps {
+		ls = append(ls, snapshotToAPIType(v.Name(), snap))
+	}
+	return ls, nil
+}
+
+// VolumeSnapshotRemove removes a snapshot of the volume with the given name.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeSnapshotRemove(name, snapshot string) error {
+	v, err := daemon.volumes.Get(name)
+	if err != nil {
+		return err
+	}
+	if err := daemon.volumes.RemoveSnapshot(v.Name(), snapshot); err != nil {
+		return volumeOperationError("remove snapshot "+snapshot+" of", name, err)
+	}
+	daemon.LogVolumeEvent(v.Name(), "snapshot-destroy", map[string]string{"driver": v.DriverName(), "snapshot": snapshot})
+	return nil
+}
+
+// VolumeSnapshotRollback reverts the volume with the given name to one of
+// its snapshots. The contents of the volume are replaced underneath its
+// users, so volumes mounted by running containers are not rolled back.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeSnapshotRollback(name, snapshot string) error {
+	v, err := daemon.volumes.Get(name)
+	if err != nil {
+		return err
+	}
+	if mounts, _ := daemon.runningVolumeMounts(v); len(mounts) > 0 {
+		return errors.NewRequestConflictError(fmt.Errorf("Unable to roll back volume %s, it is in use by running containers %s", name, strings.Join(mounts, ", ")))
+	}
+	if err := daemon.volumes.RollbackSnapshot(v.Name(), snapshot); err != nil {
+		return volumeOperationError("roll back", name, err)
+	}
+	daemon.LogVolumeEvent(v.Name(), "snapshot-rollback", map[string]string{"driver": v.DriverName(), "snapshot": snapshot})
+	return nil
+}
+
+// VolumeCreateFromSnapshot creates a volume with the specified name, opts
+// and labels from a snapshot, given as "volume@snapshot". The volume is
+// created by the driver of the snapshotted volume.
+// This is called directly from the remote API
+func (daemon *Daemon) VolumeCreateFromSnapshot(name, driverName, fromSnapshot string, opts, labels map[string]string) (*types.Volume, error) {
+	i := strings.LastIndex(fromSnapshot, "@")
+	if i <= 0 || i == len(fromSnapshot)-1 {
+		return nil, errors.NewBadRequestError(fmt.Errorf("Invalid snapshot %q, expected volume@snapshot", fromSnapshot))
+	}
+	source, snapshot := fromSnapshot[:i], fromSnapshot[i+1:]
+	src, err := daemon.volumes.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	if driverName != "" && driverName != src.DriverName() {
+		return nil, errors.NewBadRequestError(fmt.Errorf("Unable to create a %s volume from a snapshot of %s volume %s", driverName, src.DriverName(), source))
+	}
+	if name == "" {
+		name = stringid.GenerateNonCryptoID()
+	}
+
+	v, err := daemon.volumes.CreateFromSnapshot(name, src.Name(), snapshot, opts, labels)
+	if err != nil {
+		if volumestore.IsNameConflict(err) {
+			return nil, errors.NewRequestConflictError(fmt.Errorf("A volume named %s already exists. Choose a different volume name.", name))
+		}
+		return nil, volumeOperationError("create", name, err)
+	}
+
+	daemon.LogVolumeEvent(v.Name(), "create", map[string]string{"driver": v.DriverName(), "snapshot": fromSnapshot})
+	apiV := volumeToAPIType(v)
+	apiV.Mountpoint = v.Path()
+	return apiV, nil
+}
+
+// runningVolumeMounts returns the running containers mounting the volume,
+// and those of them that mount it read-write.
+func (daemon *Daemon) runningVolumeMounts(v volume.Volume) (mounts []string, rw []string) {
+	for _, id := range daemon.volumes.Refs(v) {
+		c := daemon.containers.Get(id)
+		if c == nil || !c.IsRunning() {
+			continue
+		}
+		for _, mp := range c.MountPoints {
+			if mp.Name != v.Name() {
+				continue
+			}
+			mounts = append(mounts, c.ID)
+			if mp.RW {
+				rw = append(rw, c.ID)
+			}
+			break
+		}
+	}
+	return mounts, rw
+}
+
+// validateSnapshotName checks that a snapshot name is made of the characters
+// allowed in volume names, which all drivers can store.
+func validateSnapshotName(name string) error {
+	if !utils.RestrictedVolumeNamePattern.MatchString(name) {
+		return errors.NewBadRequestError(fmt.Errorf("Invalid snapshot name (%s), only %s are allowed", name, utils.RestrictedNameChars))
+	}
+	return nil
+}
+
+func snapshotToAPIType(name string, snap volume.Snapshot) *volumetypes.Snapshot {
+	s := &volumetypes.Snapshot{
+		Name:   snap.Name,
+		Volume: name,
+		Size:   snap.Size,
+	}
+	if !snap.CreatedAt.IsZero() {
+		s.Created = snap.CreatedAt.Format(time.RFC3339)
+	}
+	return s
+}
+
+// volumeOperationError converts the error of a volume store operation to an
+// error with the matching API status code.
+func volumeOperationError(action, name string, err error) error {
+	switch {
+	case volumestore.IsNotSupported(err), volumestore.IsValidation(err):
+		return errors.NewBadRequestError(fmt.Errorf("Unable to %s volume %s: %v", action, name, err))
+	case volumestore.IsTimeout(err):
+		return errors.NewErrorWithStatusCode(fmt.Errorf("Timed out trying to %s volume %s: %v", action, name, err), http.StatusGatewayTimeout)
+	}
+	return fmt.Errorf("Error while trying to %s volume %s: %v", action, name, err)
+}
@@ -0,0 +1,35 @@
+package daemon
+
+import (
+	"github.com/docker/docker/volume/block"
+	"github.com/docker/docker/volume/block/ceph"
+	"github.com/docker/docker/volume/block/iscsi"
+	"github.com/docker/docker/volume/block/lvm"
+	volumedrivers "github.com/docker/docker/volume/drivers"
+)
+
+// registerBlockVolumeDrivers registers the volume drivers storing volumes on
+// block devices attached to the host, configured by the daemon volume
+// options. Ceph images are locked on behalf of the daemon with the given id.
+func registerBlockVolumeDrivers(root, daemonID string, opts map[string]string) error {
+	cephBackend, err := ceph.New(root, daemonID, opts)
+	if err != nil {
+		return err
+	}
+	backends := []block.Backend{cephBackend, lvm.New(opts), iscsi.New()}
+	loopBackend, err := newLoopBackend(root, opts)
+	if err != nil {
+		return err
+	}
+	if loopBackend != nil {
+		backends = append(backends, loopBackend)
+	}
+	for _, backend := range backends {
+		driver, err := block.New(root, backend, opts)
+		if err != nil {
+			return err
+		}
+		volumedrivers.Register(driver, driver.Name())
+	}
+	return nil
+}
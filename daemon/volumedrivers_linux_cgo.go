@@ -0,0 +1,17 @@
+// +build linux,cgo
+
+package daemon
+
+import (
+	"github.com/docker/docker/volume/block"
+	"github.com/docker/docker/volume/block/loop"
+)
+
+// newLoopBackend returns the backend of the loop volume driver.
+func newLoopBackend(root string, opts map[string]string) (block.Backend, error) {
+	b, err := loop.New(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,9 @@
+// +build !linux
+
+package daemon
+
+// registerBlockVolumeDrivers does nothing, block volumes are only supported
+// on Linux.
+func registerBlockVolumeDrivers(root, daemonID string, opts map[string]string) error {
+	return nil
+}
@@ -30,7 +30,7 @@ func (daemon *Daemon) setupMounts(c *container.Container) ([]container.Mount, er
 		if err := daemon.lazyInitializeVolume(c.ID, m); err != nil {
 			return nil, err
 		}
-		path, err := m.Setup(c.MountLabel)
+		path, err := m.Setup(c.MountLabel, c.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -51,7 +51,7 @@ func (daemon *Daemon) setupMounts(c *container.Container) ([]container.Mount, er
 				}
 				daemon.LogVolumeEvent(m.Volume.Name(), "mount", attributes)
 			}
-			if m.Driver == "nfs" || m.Driver == "ceph" {
+			if m.Driver == "nfs" {
 				mnt.Data = m.Driver
 			}
 
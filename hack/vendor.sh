@@ -119,6 +119,15 @@ clone git github.com/fluent/fluent-logger-golang v1.2.1
 clone git github.com/philhofer/fwd 899e4efba8eaa1fea74175308f3fae18ff3319fa
 clone git github.com/tinylib/msgp 75ee40d2601edf122ef667e2a07d600d4c44490c
 
+# kafka logging driver deps
+clone git github.com/Shopify/sarama v1.10.1
+clone git github.com/eapache/go-resiliency v1.0.0
+clone git github.com/eapache/go-xerial-snappy bb955e01b934
+clone git github.com/eapache/queue v1.0.2
+clone git github.com/golang/snappy d9eb7a3d35ec
+clone git github.com/klauspost/crc32 19b0b332c9e4
+clone git github.com/davecgh/go-spew v1.0.0
+
 # fsnotify
 clone git gopkg.in/fsnotify.v1 v1.2.11
 
@@ -59,6 +59,10 @@ type (
 		// For each include when creating an archive, the included name will be
 		// replaced with the matching name from this map.
 		RebaseNames map[string]string
+		// When creating an archive, specifies whether all the extended
+		// attributes of the files are archived, rather than only their
+		// capabilities.
+		IncludeXattrs bool
 	}
 
 	// Archiver allows the reuse of most utility functions of this package
@@ -261,6 +265,37 @@ type tarAppender struct {
 	// by the AUFS standard are used as the tar whiteout
 	// standard.
 	WhiteoutConverter tarWhiteoutConverter
+
+	// Archive all the extended attributes of the files
+	// rather than only security.capability.
+	IncludeXattrs bool
+}
+
+// addXattrs adds all the extended attributes of the file at path to the
+// header. Filesystems and platforms without extended attributes have none.
+func addXattrs(hdr *tar.Header, path string) error {
+	attrs, err := system.Llistxattr(path)
+	if err != nil {
+		if err == system.ErrNotSupportedPlatform || err == syscall.ENOTSUP {
+			return nil
+		}
+		return fmt.Errorf("tar: cannot list the extended attributes of %s: %v", path, err)
+	}
+	for _, attr := range attrs {
+		value, err := system.Lgetxattr(path, attr)
+		if err != nil {
+			return fmt.Errorf("tar: cannot read extended attribute %s of %s: %v", attr, path, err)
+		}
+		if value == nil {
+			// removed since it was listed
+			continue
+		}
+		if hdr.Xattrs == nil {
+			hdr.Xattrs = make(map[string]string)
+		}
+		hdr.Xattrs[attr] = string(value)
+	}
+	return nil
 }
 
 // canonicalTarName provides a platform-independent and consistent posix-style
@@ -328,6 +363,11 @@ func (ta *tarAppender) addTarFile(path, name string) error {
 		hdr.Xattrs = make(map[string]string)
 		hdr.Xattrs["security.capability"] = string(capability)
 	}
+	if ta.IncludeXattrs {
+		if err := addXattrs(hdr, path); err != nil {
+			return err
+		}
+	}
 
 	//handle re-mapping container ID mappings back to host ID mappings before
 	//writing tar headers/files. We skip whiteout files because they were written
@@ -562,6 +602,7 @@ func TarWithOptions(srcPath string, options *TarOptions) (io.ReadCloser, error)
 			UIDMaps:           options.UIDMaps,
 			GIDMaps:           options.GIDMaps,
 			WhiteoutConverter: getWhiteoutConverter(options.WhiteoutFormat),
+			IncludeXattrs:     options.IncludeXattrs,
 		}
 
 		defer func() {
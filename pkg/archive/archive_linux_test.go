@@ -185,3 +185,56 @@ func TestOverlayTarAUFSUntar(t *testing.T) {
 	checkFileMode(t, filepath.Join(dst, "d2", "f1"), 0660)
 	checkFileMode(t, filepath.Join(dst, "d3", WhiteoutPrefix+"f1"), 0600)
 }
+
+func TestTarUntarXattrs(t *testing.T) {
+	src, err := ioutil.TempDir("", "docker-test-xattrs-src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	if err := ioutil.WriteFile(filepath.Join(src, "f1"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := system.Lsetxattr(filepath.Join(src, "f1"), "user.docker.test", []byte("value"), 0); err != nil {
+		if err == syscall.ENOTSUP || err == syscall.EPERM {
+			t.Skipf("user extended attributes are not supported: %v", err)
+		}
+		t.Fatal(err)
+	}
+	attrs, err := system.Llistxattr(filepath.Join(src, "f1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attrs) != 1 || attrs[0] != "user.docker.test" {
+		t.Fatalf("Unexpected extended attributes %v", attrs)
+	}
+
+	for _, include := range []bool{false, true} {
+		dst, err := ioutil.TempDir("", "docker-test-xattrs-dst")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dst)
+
+		archive, err := TarWithOptions(src, &TarOptions{IncludeXattrs: include})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Untar(archive, dst, nil)
+		archive.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		value, err := system.Lgetxattr(filepath.Join(dst, "f1"), "user.docker.test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if include && string(value) != "value" {
+			t.Fatalf("Expected the extended attribute to be archived, got %q", value)
+		}
+		if !include && value != nil {
+			t.Fatalf("Expected the extended attribute not to be archived, got %q", value)
+		}
+	}
+}
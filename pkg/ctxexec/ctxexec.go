@@ -0,0 +1,93 @@
+// Package ctxexec runs external commands under a context, so that a hung
+// helper process is killed instead of blocking its caller forever.
+package ctxexec
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// TimeoutError is returned when a command does not complete before the
+// deadline of its context.
+type TimeoutError struct {
+	// Cmd is the command line of the process that was killed.
+	Cmd string
+}
+
+// Error satisfies the built-in error interface type.
+func (e *TimeoutError) Error() string {
+	return "timed out waiting for " + e.Cmd
+}
+
+// Timeout returns true, so that TimeoutError can be recognized by callers
+// that check for the Timeout method, like they do for net.Error.
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// IsTimeout returns whether err indicates that a command timed out.
+func IsTimeout(err error) bool {
+	t, ok := err.(interface {
+		Timeout() bool
+	})
+	return ok && t.Timeout()
+}
+
+// Run starts cmd and waits for it to complete, like cmd.Run.
+func Run(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return Wait(ctx, cmd)
+}
+
+// Output runs cmd and returns its standard output, like cmd.Output.
+func Output(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := Run(ctx, cmd); err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// CombinedOutput runs cmd and returns its combined standard output and
+// standard error, like cmd.CombinedOutput. The output is not returned if the
+// command timed out.
+func CombinedOutput(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := Run(ctx, cmd); err != nil {
+		if IsTimeout(err) || err == context.Canceled {
+			return nil, err
+		}
+		return out.Bytes(), err
+	}
+	return out.Bytes(), nil
+}
+
+// Wait waits for the started cmd to complete, like cmd.Wait. If ctx is done
+// first the process is killed and a *TimeoutError, or context.Canceled, is
+// returned. Wait does not wait for the killed process to exit: a process
+// stuck in the kernel, e.g. on an unreachable NFS server, may not die
+// until the kernel gives up.
+func Wait(ctx context.Context, cmd *exec.Cmd) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		cmd.Process.Kill()
+		if ctx.Err() == context.DeadlineExceeded {
+			return &TimeoutError{Cmd: strings.Join(cmd.Args, " ")}
+		}
+		return ctx.Err()
+	}
+}
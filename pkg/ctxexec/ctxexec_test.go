@@ -0,0 +1,62 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestRun(t *testing.T) {
+	if err := Run(context.Background(), exec.Command("true")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(context.Background(), exec.Command("false")); err == nil || IsTimeout(err) {
+		t.Fatalf("expected an exit error, got %v", err)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := Run(ctx, exec.Command("sleep", "10"))
+	if !IsTimeout(err) {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("expected the command to be killed")
+	}
+	if err.Error() != "timed out waiting for sleep 10" {
+		t.Fatalf("unexpected error message %q", err)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	if err := Run(ctx, exec.Command("sleep", "10")); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	out, err := Output(context.Background(), exec.Command("echo", "hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "hello\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+
+	out, err = CombinedOutput(context.Background(), exec.Command("sh", "-c", "echo out; echo err >&2; exit 1"))
+	if err == nil {
+		t.Fatal("expected an exit error")
+	}
+	if string(out) != "out\nerr\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
@@ -190,3 +190,38 @@ func TestSizeNonExistingDirectory(t *testing.T) {
 		t.Fatalf("error is expected")
 	}
 }
+
+// Usage of a directory with a nested directory and a 5-byte file hard linked
+// twice counts three inodes
+func TestUsage(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "testUsage")
+	if err != nil {
+		t.Fatalf("failed to create directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "nested"), 0755); err != nil {
+		t.Fatalf("failed to create nested directory: %s", err)
+	}
+	file := filepath.Join(dir, "nested", "file")
+	if err := ioutil.WriteFile(file, []byte("abcde"), 0644); err != nil {
+		t.Fatalf("failed to create file: %s", err)
+	}
+	if err := os.Link(file, filepath.Join(dir, "link")); err != nil {
+		t.Fatalf("failed to link file: %s", err)
+	}
+
+	size, inodes, err := Usage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inodes != 3 {
+		t.Fatalf("expected 3 inodes, got %d", inodes)
+	}
+	if size < 5 {
+		t.Fatalf("expected at least 5 bytes, got %d", size)
+	}
+
+	if _, _, err := Usage(filepath.Join(dir, "nonexistent")); err == nil {
+		t.Fatal("expected an error for a missing directory")
+	}
+}
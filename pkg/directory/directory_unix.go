@@ -46,3 +46,36 @@ func Size(dir string) (size int64, err error) {
 	})
 	return
 }
+
+// Usage walks a directory tree and returns the space its files use on disk
+// in bytes, and the number of inodes it uses, including its directories.
+// Hard links are only counted once.
+func Usage(dir string) (size int64, inodes int64, err error) {
+	seen := make(map[uint64]struct{})
+	err = filepath.Walk(dir, func(d string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			// if dir does not exist, Usage() returns the error.
+			// if dir/x disappeared while walking, Usage() ignores dir/x.
+			if os.IsNotExist(err) && d != dir {
+				return nil
+			}
+			return err
+		}
+		if fileInfo == nil {
+			return nil
+		}
+
+		stat := fileInfo.Sys().(*syscall.Stat_t)
+		// inode is not a uint64 on all platforms. Cast it to avoid issues.
+		if _, exists := seen[uint64(stat.Ino)]; exists {
+			return nil
+		}
+		seen[uint64(stat.Ino)] = struct{}{}
+
+		inodes++
+		// st_blocks is in 512 byte units, whatever the block size
+		size += int64(stat.Blocks) * 512
+		return nil
+	})
+	return
+}
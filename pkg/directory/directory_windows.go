@@ -35,3 +35,28 @@ func Size(dir string) (size int64, err error) {
 	})
 	return
 }
+
+// Usage walks a directory tree and returns the total size of its files in
+// bytes, and the number of files and directories in it.
+func Usage(dir string) (size int64, inodes int64, err error) {
+	err = filepath.Walk(dir, func(d string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			// if dir does not exist, Usage() returns the error.
+			// if dir/x disappeared while walking, Usage() ignores dir/x.
+			if os.IsNotExist(err) && d != dir {
+				return nil
+			}
+			return err
+		}
+		if fileInfo == nil {
+			return nil
+		}
+
+		inodes++
+		if !fileInfo.IsDir() {
+			size += fileInfo.Size()
+		}
+		return nil
+	})
+	return
+}
@@ -1,6 +1,7 @@
 package system
 
 import (
+	"strings"
 	"syscall"
 	"unsafe"
 )
@@ -61,3 +62,35 @@ func Lsetxattr(path string, attr string, data []byte, flags int) error {
 	}
 	return nil
 }
+
+// Llistxattr lists the names of the extended attributes associated with the
+// given path in the file system.
+func Llistxattr(path string) ([]string, error) {
+	pathBytes, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dest := make([]byte, 128)
+	destBytes := unsafe.Pointer(&dest[0])
+	sz, _, errno := syscall.Syscall(syscall.SYS_LLISTXATTR, uintptr(unsafe.Pointer(pathBytes)), uintptr(destBytes), uintptr(len(dest)))
+	if errno == syscall.ERANGE {
+		sz, _, errno = syscall.Syscall(syscall.SYS_LLISTXATTR, uintptr(unsafe.Pointer(pathBytes)), 0, 0)
+		if errno == 0 && sz > 0 {
+			dest = make([]byte, sz)
+			destBytes := unsafe.Pointer(&dest[0])
+			sz, _, errno = syscall.Syscall(syscall.SYS_LLISTXATTR, uintptr(unsafe.Pointer(pathBytes)), uintptr(destBytes), uintptr(len(dest)))
+		}
+	}
+	if errno != 0 {
+		return nil, errno
+	}
+
+	var attrs []string
+	for _, attr := range strings.Split(string(dest[:sz]), "\x00") {
+		if attr != "" {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs, nil
+}
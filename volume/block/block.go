@@ -0,0 +1,166 @@
+// Package block implements the parts of volume drivers that are common to
+// all volumes stored on a block device: formatting, encrypting, checking,
+// growing and mounting the filesystem of the device. Drivers provide a
+// Backend that creates the storage of volumes and attaches it to the host as
+// a device; Root turns any Backend into a volume driver.
+package block
+
+import (
+	"time"
+
+	"github.com/docker/docker/volume"
+	"golang.org/x/net/context"
+)
+
+const (
+	// DefaultTimeout is how long attaching, encryption and key helpers may
+	// run before they are killed.
+	DefaultTimeout = 2 * time.Minute
+	// DefaultFsTimeout is how long helpers working on a whole filesystem,
+	// such as mkfs and fsck, may run before they are killed.
+	DefaultFsTimeout = 30 * time.Minute
+
+	// CryptoLuksFsType is the filesystem type blkid reports for LUKS
+	// encrypted devices.
+	CryptoLuksFsType = "crypto_LUKS"
+	// LuksDevMapperPath is where opened LUKS devices are found.
+	LuksDevMapperPath = "/dev/mapper/"
+
+	// procRoot is where procfs is mounted
+	procRoot = "/proc"
+)
+
+// Backend creates the storage of block volumes and attaches it to the host
+// as a device. Methods are only called for a volume with its lock held, and
+// are given the options the volume was created with that Backend.IsOption
+// accepted.
+type Backend interface {
+	// Name returns the name of the volume driver.
+	Name() string
+	// IsOption returns whether key is an option of the backend.
+	IsOption(key string) bool
+	// ValidateOptions validates the backend options a volume is created
+	// with. Errors should be validation errors.
+	ValidateOptions(name string, opts map[string]string) error
+	// Create creates the storage of the named volume, of size bytes. It is
+	// called each time the volume is mounted and must succeed if the
+	// storage already exists.
+	Create(ctx context.Context, name string, size int64, opts map[string]string) error
+	// Attach attaches the storage of the named volume to the host and
+	// returns the path of the device. It must succeed, returning the
+	// existing device, if the storage is already attached.
+	Attach(ctx context.Context, name string, opts map[string]string) (string, error)
+	// Detach detaches the storage of the named volume from device.
+	Detach(ctx context.Context, name, device string, opts map[string]string) error
+	// Remove destroys the storage of the named volume, which is detached.
+	// It must succeed if the storage does not exist.
+	Remove(ctx context.Context, name string, opts map[string]string) error
+}
+
+// Resizer is implemented by backends that can change the size of the
+// storage of volumes. Attached devices must reflect the new size once
+// Resize returns.
+type Resizer interface {
+	// Resize changes the size of the storage of the named volume to size
+	// bytes. attached is the device of the volume, empty if it is not
+	// attached.
+	Resize(ctx context.Context, name string, size int64, attached string, opts map[string]string) error
+}
+
+// DefaultSizer is implemented by backends whose volumes are created with
+// another size than DefaultSize when the `size` option is not given.
+type DefaultSizer interface {
+	// DefaultSize returns the size in bytes of new volumes.
+	DefaultSize() int64
+}
+
+// LegacyKeyer is implemented by backends that encrypted volumes before
+// their keys were managed by a KeyProvider.
+type LegacyKeyer interface {
+	// LegacyKey returns the passphrase the named volume was encrypted with
+	// if it has no key stored.
+	LegacyKey(name string) []byte
+}
+
+// Reconciler is implemented by backends that can list the storage attached
+// to the host, so that a restarted daemon finds the volumes that are still
+// attached, detaches those nobody uses, and adopts the storage it has no
+// record of as new volumes.
+type Reconciler interface {
+	// Attached returns the devices the storage of volumes is attached to,
+	// by volume name. It is given the backend options of the known volumes,
+	// by name.
+	Attached(volumes map[string]map[string]string) (map[string]string, error)
+}
+
+// StorageIdentifier is implemented by backends whose volumes use storage
+// named by their options rather than storage created for them, so that two
+// volumes don't use the same storage.
+type StorageIdentifier interface {
+	// StorageID returns what identifies the storage a volume created with
+	// the given backend options uses.
+	StorageID(opts map[string]string) string
+}
+
+// Snapshotter is implemented by backends that can take snapshots of the
+// storage of volumes, and create volumes from them.
+type Snapshotter interface {
+	// CreateSnapshot takes a snapshot of the storage of the named volume.
+	CreateSnapshot(ctx context.Context, name, snapshot string, opts map[string]string) (volume.Snapshot, error)
+	// Snapshots lists the snapshots of the storage of the named volume, none
+	// if its storage has not been created.
+	Snapshots(ctx context.Context, name string, opts map[string]string) ([]volume.Snapshot, error)
+	// RemoveSnapshot removes a snapshot of the storage of the named volume.
+	RemoveSnapshot(ctx context.Context, name, snapshot string, opts map[string]string) error
+	// RollbackSnapshot reverts the storage of the named volume, which is
+	// detached, to one of its snapshots.
+	RollbackSnapshot(ctx context.Context, name, snapshot string, opts map[string]string) error
+	// Clone creates the storage of the named volume from a snapshot of the
+	// storage of the source volume.
+	Clone(ctx context.Context, source, snapshot string, sourceOpts map[string]string, name string, opts map[string]string) error
+}
+
+// Unlocker is implemented by backends that lock the storage of attached
+// volumes, so that hosts sharing the storage don't attach it at the same
+// time.
+type Unlocker interface {
+	// Unlock breaks the lock held on the storage of the named volume.
+	// attached is the device of the volume, empty if it is not attached to
+	// this host. Locks of hosts that still appear to use the storage are
+	// only broken if force is set.
+	Unlock(ctx context.Context, name, attached string, force bool, opts map[string]string) error
+}
+
+// DiskUsageReporter is implemented by backends that can tell how much space
+// the storage of volumes uses, while their filesystem is not mounted.
+type DiskUsageReporter interface {
+	// DiskUsage returns the provisioned size of the storage of the named
+	// volume and the space it uses, in bytes, or zero sizes if its storage
+	// has not been created.
+	DiskUsage(ctx context.Context, name string, opts map[string]string) (int64, int64, error)
+}
+
+// SpaceReclaimer is implemented by backends whose Remove frees the space the
+// storage of volumes uses, rather than leaving it provisioned or shared.
+type SpaceReclaimer interface {
+	// ReclaimsSpace returns whether removing the storage of a volume frees
+	// the space it uses.
+	ReclaimsSpace() bool
+}
+
+// StatusReporter is implemented by backends that describe the storage of
+// volumes in `docker volume inspect`.
+type StatusReporter interface {
+	// Status returns the fields added to the status of the named volume.
+	// It must not wait for longer than ctx allows.
+	Status(ctx context.Context, name string, opts map[string]string) map[string]interface{}
+}
+
+// withTimeout returns the context a helper command runs under, done after
+// timeout, or def if timeout is not set.
+func withTimeout(timeout, def time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = def
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
@@ -0,0 +1,271 @@
+// Package ceph implements a block volume backend storing each volume in a
+// RADOS block device image of a Ceph cluster, mapped to the host through the
+// kernel RBD driver. Images are locked while they are mapped, so that hosts
+// sharing the cluster don't mount a volume at the same time.
+package ceph
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/volume/block/ceph/rbd"
+	"github.com/docker/go-units"
+	"golang.org/x/net/context"
+)
+
+const (
+	// Name is the name of the driver using the backend.
+	Name = "ceph"
+
+	// DefaultSize is the size of images created without the `size` option.
+	DefaultSize = units.TiB
+
+	// daemon volume options describing how to reach the cluster
+	confPathOpt    = "ceph.conf"
+	keyringPathOpt = "ceph.keyring"
+	userOpt        = "ceph.user"
+	monitorsOpt    = "ceph.monitors"
+
+	// defaultRbdPool is the pool of images of volumes named without a pool
+	defaultRbdPool = "rbd"
+)
+
+// Backend creates the images of volumes and maps them through the kernel.
+type Backend struct {
+	// rbd maps the images of the volumes through the kernel
+	rbd *rbd.Client
+	// locking is whether images are locked while they are mapped, with
+	// lockID
+	locking bool
+	lockID  string
+}
+
+// New returns the Ceph backend. The daemon volume options describe how to
+// reach the cluster, and whether images are locked; they are locked with an
+// id derived from daemonID. Volumes recorded under scope, the daemon root,
+// by the Ceph driver before it was a block volume backend are converted to
+// block volumes.
+func New(scope, daemonID string, opts map[string]string) (*Backend, error) {
+	locking, err := parseLocking(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateMetadata(filepath.Join(scope, metadataDirName)); err != nil {
+		return nil, err
+	}
+	return &Backend{
+		rbd:     rbd.NewClient(rbd.DefaultSysfsRoot, rbd.DefaultDevRoot, rbdConfigOptions(opts)),
+		locking: locking,
+		lockID:  lockID(daemonID),
+	}, nil
+}
+
+// rbdConfigOptions returns how the cluster configuration is found, from the
+// daemon volume options.
+func rbdConfigOptions(opts map[string]string) rbd.ConfigOptions {
+	o := rbd.ConfigOptions{
+		ConfPath:    opts[confPathOpt],
+		KeyringPath: opts[keyringPathOpt],
+		User:        opts[userOpt],
+	}
+	for _, m := range strings.Split(opts[monitorsOpt], ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			o.Monitors = append(o.Monitors, m)
+		}
+	}
+	return o
+}
+
+// Name returns the name of the driver.
+func (b *Backend) Name() string {
+	return Name
+}
+
+// DefaultSize returns the size of images created without the `size`
+// option.
+func (b *Backend) DefaultSize() int64 {
+	return DefaultSize
+}
+
+// LegacyKey returns the name of the volume: volumes encrypted before keys
+// were managed used it as passphrase. It is still accepted, with a warning,
+// until the key is rotated.
+func (b *Backend) LegacyKey(name string) []byte {
+	return []byte(name)
+}
+
+// poolAndImage returns the pool and the name of the image the named volume
+// is stored in. Volumes may be named "pool/image", or be given the `pool`
+// option.
+func poolAndImage(name string, opts map[string]string) (string, string) {
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	if pool := opts["pool"]; pool != "" {
+		return pool, name
+	}
+	return defaultRbdPool, name
+}
+
+// imageSpec returns the "pool/image" the named volume is stored in.
+func imageSpec(name string, opts map[string]string) string {
+	pool, image := poolAndImage(name, opts)
+	return pool + "/" + image
+}
+
+// Create creates the image of the volume, unless it exists.
+func (b *Backend) Create(ctx context.Context, name string, size int64, opts map[string]string) error {
+	pool, image := poolAndImage(name, opts)
+	err := b.rbd.Create(ctx, pool, image, createArgs(size, opts)...)
+	if rbd.IsExists(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Created Ceph image %s/%s", pool, image)
+	return nil
+}
+
+// Attach locks the image of the volume and maps it, or finds the device it
+// is already mapped to.
+func (b *Backend) Attach(ctx context.Context, name string, opts map[string]string) (string, error) {
+	pool, image := poolAndImage(name, opts)
+	unlock, err := b.lockImage(ctx, name, opts)
+	if err != nil {
+		return "", err
+	}
+	device, err := b.rbd.Map(ctx, pool, image)
+	if rbd.IsExists(err) {
+		logrus.Warnf("Ceph volume '%s' is already mapped to %s, using the existing mapping", name, device)
+		return device, nil
+	}
+	if err != nil {
+		unlock()
+		return "", err
+	}
+	return device, nil
+}
+
+// Detach unmaps the device of the volume and releases the lock of its
+// image. An image left locked is reported, it can be unlocked with
+// `docker volume unlock`.
+func (b *Backend) Detach(ctx context.Context, name, device string, opts map[string]string) error {
+	if err := b.rbd.Unmap(ctx, device); err != nil && !rbd.IsNotFound(err) {
+		return err
+	}
+	b.unlockImage(ctx, name, opts)
+	return nil
+}
+
+// Remove leaves the image of the volume in the cluster: the data of Ceph
+// volumes outlives them, images are removed with the rbd tool.
+func (b *Backend) Remove(ctx context.Context, name string, opts map[string]string) error {
+	return nil
+}
+
+// Resize changes the size of the image of the volume to size bytes, rounded
+// up to a megabyte, and waits for the device it is mapped to to pick the
+// new size up.
+func (b *Backend) Resize(ctx context.Context, name string, size int64, attached string, opts map[string]string) error {
+	pool, image := poolAndImage(name, opts)
+	current, err := b.rbd.ImageSize(ctx, pool, image)
+	if rbd.IsNotFound(err) {
+		// the image is created with the new size when first mounted
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sizeMB := (size + units.MiB - 1) / units.MiB
+	if err := b.rbd.Resize(ctx, pool, image, sizeMB, sizeMB*units.MiB < current); err != nil {
+		return err
+	}
+	if attached == "" {
+		return nil
+	}
+	return b.waitForDeviceSize(ctx, attached, sizeMB*units.MiB)
+}
+
+// waitForDeviceSize waits for the kernel to pick the new size of the image
+// mapped to device up, which it does when it is notified of the change by
+// the cluster.
+func (b *Backend) waitForDeviceSize(ctx context.Context, device string, size int64) error {
+	for {
+		current, err := b.rbd.Size(device)
+		if err != nil {
+			return err
+		}
+		if current == size {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %s to grow to %d bytes, it is %d bytes", device, size, current)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Attached returns the devices the heads of images are mapped to. Images
+// of the known volumes, given with their options by name, are returned
+// under the name of their volume; other images under their "pool/image",
+// or their image name in the default pool.
+func (b *Backend) Attached(volumes map[string]map[string]string) (map[string]string, error) {
+	mappings, err := b.rbd.Mapped()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string)
+	for name, opts := range volumes {
+		names[imageSpec(name, opts)] = name
+	}
+	attached := make(map[string]string)
+	for _, m := range mappings {
+		// mapped snapshots are not volumes
+		if m.Snap != "-" {
+			continue
+		}
+		spec := m.Pool + "/" + m.Image
+		name, ok := names[spec]
+		switch {
+		case ok:
+		case m.Pool == defaultRbdPool:
+			name = m.Image
+		default:
+			name = spec
+		}
+		attached[name] = m.Device
+	}
+	return attached, nil
+}
+
+// DiskUsage returns the provisioned size of the image of the volume and the
+// space it and its snapshots use in the cluster.
+func (b *Backend) DiskUsage(ctx context.Context, name string, opts map[string]string) (int64, int64, error) {
+	pool, image := poolAndImage(name, opts)
+	provisioned, used, err := b.rbd.DiskUsage(ctx, pool, image)
+	if rbd.IsNotFound(err) {
+		return 0, 0, nil
+	}
+	return provisioned, used, err
+}
+
+// Status returns the image of the volume, and the lock held on it.
+func (b *Backend) Status(ctx context.Context, name string, opts map[string]string) map[string]interface{} {
+	status := map[string]interface{}{
+		"Image": imageSpec(name, opts),
+	}
+	if !b.locking {
+		return status
+	}
+	if lock, err := b.lockStatus(ctx, name, opts); err != nil {
+		logrus.Debugf("Unable to get the lock of Ceph volume '%s': %v", name, err)
+	} else if lock != nil {
+		status["Lock"] = lock
+	}
+	return status
+}
@@ -0,0 +1,212 @@
+package ceph
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/volume/block/ceph/rbd"
+	"golang.org/x/net/context"
+)
+
+const (
+	// lockingOpt is the daemon volume option turning the exclusive locking
+	// of images off, for clusters where the user can't lock images
+	lockingOpt = "ceph.locking"
+	// lockIDPrefix starts the ids of the locks taken by docker daemons,
+	// which are followed by the host name and the id of the daemon
+	lockIDPrefix = "docker/"
+)
+
+// lockID returns the id of the locks taken by the daemon with the given id,
+// which names the host so that locks of dead hosts can be recognised.
+func lockID(daemonID string) string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return lockIDPrefix + host + "/" + daemonID
+}
+
+// lockHost returns the host named by the id of a lock taken by a docker
+// daemon, or "" for locks taken by others.
+func lockHost(id string) string {
+	if !strings.HasPrefix(id, lockIDPrefix) {
+		return ""
+	}
+	return strings.SplitN(strings.TrimPrefix(id, lockIDPrefix), "/", 2)[0]
+}
+
+// parseLocking returns whether images are locked, from the daemon volume
+// options. They are unless ceph.locking is false.
+func parseLocking(opts map[string]string) (bool, error) {
+	value, ok := opts[lockingOpt]
+	if !ok {
+		return true, nil
+	}
+	locking, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid value %q for %s: %v", value, lockingOpt, err)
+	}
+	return locking, nil
+}
+
+// lockedError is returned when mounting a volume whose image is locked by
+// another host.
+type lockedError struct {
+	name string
+	lock rbd.Lock
+}
+
+func (e lockedError) Error() string {
+	holder := e.lock.String()
+	if host := lockHost(e.lock.ID); host != "" {
+		holder = "host " + host
+	}
+	return fmt.Sprintf("Ceph volume '%s' is locked by %s, which may still be using it. If that host is dead, unlock the volume with `docker volume unlock`", e.name, holder)
+}
+
+// lockImage takes the exclusive lock of the image of the volume, unless
+// this daemon already holds it, and returns a function releasing the lock
+// if it was taken here.
+func (b *Backend) lockImage(ctx context.Context, name string, opts map[string]string) (func(), error) {
+	if !b.locking {
+		return func() {}, nil
+	}
+	pool, image := poolAndImage(name, opts)
+	locks, err := b.rbd.Locks(ctx, pool, image)
+	if err != nil {
+		logrus.Errorf("Failed to list the locks of Ceph volume '%s': %v", name, err)
+		return nil, err
+	}
+	for _, l := range locks {
+		if l.ID == b.lockID {
+			// taken before the daemon restarted
+			return func() {}, nil
+		}
+		return nil, lockedError{name: name, lock: l}
+	}
+	if err := b.rbd.AddLock(ctx, pool, image, b.lockID); err != nil {
+		if rbd.IsBusy(err) {
+			// another host locked the image since it was listed
+			if locks, _ := b.rbd.Locks(ctx, pool, image); len(locks) > 0 {
+				return nil, lockedError{name: name, lock: locks[0]}
+			}
+		}
+		logrus.Errorf("Failed to lock Ceph volume '%s': %v", name, err)
+		return nil, err
+	}
+	logrus.Debugf("Locked Ceph volume '%s' with id %s", name, b.lockID)
+	return func() { b.unlockImage(ctx, name, opts) }, nil
+}
+
+// unlockImage releases the lock this daemon holds on the image of the
+// volume, if any.
+func (b *Backend) unlockImage(ctx context.Context, name string, opts map[string]string) error {
+	if !b.locking {
+		return nil
+	}
+	pool, image := poolAndImage(name, opts)
+	locks, err := b.rbd.Locks(ctx, pool, image)
+	if err != nil {
+		logrus.Warnf("Failed to list the locks of Ceph volume '%s', leaving it locked: %v", name, err)
+		return err
+	}
+	for _, l := range locks {
+		if l.ID != b.lockID {
+			continue
+		}
+		if err := b.rbd.RemoveLock(ctx, pool, image, l.ID, l.Locker); err != nil && !rbd.IsNotFound(err) {
+			logrus.Warnf("Failed to unlock Ceph volume '%s', leaving it locked: %v", name, err)
+			return err
+		}
+		logrus.Debugf("Unlocked Ceph volume '%s'", name)
+	}
+	return nil
+}
+
+// lockStatus describes the lock held on the image of the volume, for
+// Status. The lock is stale if the image is not mapped on any host.
+func (b *Backend) lockStatus(ctx context.Context, name string, opts map[string]string) (map[string]interface{}, error) {
+	pool, image := poolAndImage(name, opts)
+	locks, err := b.rbd.Locks(ctx, pool, image)
+	if err != nil || len(locks) == 0 {
+		return nil, err
+	}
+	l := locks[0]
+	status := map[string]interface{}{
+		"ID":      l.ID,
+		"Locker":  l.Locker,
+		"Address": l.Address,
+		"Local":   l.ID == b.lockID,
+	}
+	if host := lockHost(l.ID); host != "" {
+		status["Host"] = host
+	}
+	if watchers, err := b.rbd.Watchers(ctx, pool, image); err == nil {
+		status["Stale"] = len(watchers) == 0
+	}
+	return status, nil
+}
+
+// Unlock breaks the lock held on the image of the volume, which must not be
+// attached to this host. The lock of another host is broken if the image is
+// not mapped anywhere, which happens when that host died, or if force is
+// set. Breaking the lock of a host that still maps the image first
+// blacklists the kernel clients mapping it, which fences that host off the
+// image: its writes fail from then on.
+func (b *Backend) Unlock(ctx context.Context, name, attached string, force bool, opts map[string]string) error {
+	pool, image := poolAndImage(name, opts)
+	locks, err := b.rbd.Locks(ctx, pool, image)
+	if rbd.IsNotFound(err) || (err == nil && len(locks) == 0) {
+		return validationError{fmt.Errorf("Ceph volume '%s' is not locked", name)}
+	}
+	if err != nil {
+		return err
+	}
+	if attached != "" {
+		// this host maps the image too, and would fence itself off
+		return validationError{fmt.Errorf("Ceph volume '%s' is in use on this host, it can't be unlocked", name)}
+	}
+	for _, l := range locks {
+		if l.ID != b.lockID {
+			if err := b.fence(ctx, name, l, force, opts); err != nil {
+				return err
+			}
+		}
+		if err := b.rbd.RemoveLock(ctx, pool, image, l.ID, l.Locker); err != nil && !rbd.IsNotFound(err) {
+			logrus.Errorf("Failed to unlock Ceph volume '%s': %v", name, err)
+			return err
+		}
+		logrus.Infof("Broke the lock of %s on Ceph volume '%s'", l, name)
+	}
+	return nil
+}
+
+// fence blacklists the kernel clients that map the image of the volume,
+// locked by another host, so that the host can't write to the image once
+// its lock is broken. Images that are still mapped are only fenced if force
+// is set.
+func (b *Backend) fence(ctx context.Context, name string, l rbd.Lock, force bool, opts map[string]string) error {
+	pool, image := poolAndImage(name, opts)
+	watchers, err := b.rbd.Watchers(ctx, pool, image)
+	if err != nil {
+		return err
+	}
+	if len(watchers) == 0 {
+		return nil
+	}
+	if !force {
+		return validationError{fmt.Errorf("Ceph volume '%s' is locked by %s and is still mapped, force the unlock to fence the hosts mapping it off the volume and break the lock", name, l)}
+	}
+	for _, w := range watchers {
+		if err := b.rbd.Blacklist(ctx, w.Address); err != nil {
+			logrus.Errorf("Failed to fence client %s off Ceph volume '%s', leaving it locked: %v", w.Address, name, err)
+			return err
+		}
+		logrus.Infof("Fenced client %s off Ceph volume '%s'", w.Address, name)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package ceph
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/volume/block/ceph/rbd"
+	"golang.org/x/net/context"
+)
+
+func TestLockHost(t *testing.T) {
+	id := lockID("KWA7:3JPV:ZXCN")
+	if !strings.HasPrefix(id, lockIDPrefix) || !strings.HasSuffix(id, "/KWA7:3JPV:ZXCN") {
+		t.Fatalf("unexpected lock id %q", id)
+	}
+	if lockHost("docker/node-3/KWA7:3JPV") != "node-3" {
+		t.Fatalf("expected host node-3, got %q", lockHost("docker/node-3/KWA7:3JPV"))
+	}
+	if host := lockHost("auto 139643345791728"); host != "" {
+		t.Fatalf("expected no host for a foreign lock, got %q", host)
+	}
+}
+
+func TestParseLocking(t *testing.T) {
+	if locking, err := parseLocking(nil); err != nil || !locking {
+		t.Fatalf("expected locking by default, got %v (%v)", locking, err)
+	}
+	if locking, err := parseLocking(map[string]string{lockingOpt: "false"}); err != nil || locking {
+		t.Fatalf("expected locking to be turned off, got %v (%v)", locking, err)
+	}
+	if _, err := parseLocking(map[string]string{lockingOpt: "maybe"}); err == nil {
+		t.Fatal("expected an error for an invalid value")
+	}
+}
+
+func TestLockedError(t *testing.T) {
+	err := lockedError{name: "data", lock: rbd.Lock{ID: "docker/node-3/KWA7", Locker: "client.84150"}}
+	if !strings.Contains(err.Error(), "host node-3") {
+		t.Fatalf("expected the error to name the host, got %q", err)
+	}
+}
+
+// fakeCephTools puts rbd and ceph scripts first in PATH, which log their
+// arguments to the returned file. The image is locked by another host and
+// mapped by a client of that host; ceph exits with cephStatus.
+func fakeCephTools(t *testing.T, cephStatus int) (string, func()) {
+	dir, err := ioutil.TempDir("", "ceph-bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := filepath.Join(dir, "log")
+	scripts := map[string]string{
+		"rbd": fmt.Sprintf(`#!/bin/sh
+echo "rbd $*" >> %s
+case "$1 $2" in
+"lock ls"*) echo '[{"id":"docker/node-3/KWA7","locker":"client.84150","address":"10.0.3.12:0/1843"}]' ;;
+status*) echo '{"watchers":[{"address":"10.0.3.12:0/3162846912","client":84151}]}' ;;
+esac
+`, log),
+		"ceph": fmt.Sprintf("#!/bin/sh\necho \"ceph $*\" >> %s\nexit %d\n", log, cephStatus),
+	}
+	for name, script := range scripts {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			os.RemoveAll(dir)
+			t.Fatal(err)
+		}
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	return log, func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestUnlockFencesMappedHost(t *testing.T) {
+	b := &Backend{
+		rbd:     rbd.NewClient(rbd.DefaultSysfsRoot, rbd.DefaultDevRoot, rbd.ConfigOptions{}),
+		locking: true,
+		lockID:  "docker/node-1/ABCD",
+	}
+	calls := func(log string) string {
+		out, _ := ioutil.ReadFile(log)
+		os.Remove(log)
+		return string(out)
+	}
+
+	log, cleanup := fakeCephTools(t, 0)
+	defer cleanup()
+	if err := b.Unlock(context.Background(), "data", "/dev/rbd0", true, nil); err == nil {
+		t.Fatal("expected an error unlocking a volume in use on this host")
+	}
+	if out := calls(log); strings.Contains(out, "lock rm") || strings.Contains(out, "ceph") {
+		t.Fatalf("expected the lock to be left alone, got calls:\n%s", out)
+	}
+	if err := b.Unlock(context.Background(), "data", "", false, nil); err == nil {
+		t.Fatal("expected an error unlocking a volume still mapped without force")
+	} else if _, ok := err.(validationError); !ok {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if out := calls(log); strings.Contains(out, "lock rm") || strings.Contains(out, "ceph") {
+		t.Fatalf("expected the lock to be left alone, got calls:\n%s", out)
+	}
+
+	if err := b.Unlock(context.Background(), "data", "", true, nil); err != nil {
+		t.Fatal(err)
+	}
+	out := calls(log)
+	fence := strings.Index(out, "ceph osd blacklist add 10.0.3.12:0/3162846912\n")
+	unlock := strings.Index(out, "rbd lock rm rbd/data docker/node-3/KWA7 client.84150\n")
+	if fence < 0 || unlock < fence {
+		t.Fatalf("expected the mapping client to be blacklisted before the lock is broken, got calls:\n%s", out)
+	}
+
+	// the lock is kept if the host can't be fenced
+	log, cleanup = fakeCephTools(t, 13)
+	defer cleanup()
+	if err := b.Unlock(context.Background(), "data", "", true, nil); err == nil {
+		t.Fatal("expected an error when blacklisting fails")
+	}
+	if out := calls(log); strings.Contains(out, "lock rm") {
+		t.Fatalf("expected the lock to be left alone, got calls:\n%s", out)
+	}
+}
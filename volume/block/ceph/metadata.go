@@ -0,0 +1,128 @@
+package ceph
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/volume/block"
+	"github.com/docker/go-units"
+)
+
+const (
+	// metadataDirName is the directory under the daemon root where the
+	// block volume driver keeps the metadata of Ceph volumes, as the Ceph
+	// driver did before it was a block volume backend.
+	metadataDirName     = Name + "_volumes"
+	metadataFileSuffix  = ".json"
+	metadataPermissions = 0600
+)
+
+// legacyMetadata is the metadata of a volume written by the Ceph driver
+// before it was a block volume backend.
+type legacyMetadata struct {
+	Name                 string
+	MappedDevicePath     string
+	MappedLuksDevicePath string
+	MountID              string
+	Options              *legacyOptions
+}
+
+// legacyOptions are the options of a volume written by the Ceph driver
+// before it was a block volume backend.
+type legacyOptions struct {
+	SizeMB        int64
+	Pool          string
+	ImageFeatures []string
+	block.Options
+}
+
+// blockMetadata is the metadata of a volume in the format of the block
+// volume driver.
+type blockMetadata struct {
+	Name           string
+	Size           int64
+	Options        *block.Options    `json:",omitempty"`
+	BackendOptions map[string]string `json:",omitempty"`
+	Device         string            `json:",omitempty"`
+	LuksDevice     string            `json:",omitempty"`
+	Users          map[string]int    `json:",omitempty"`
+}
+
+// migrateMetadata converts the metadata files of volumes written by the
+// Ceph driver before it was a block volume backend, found in dir, to the
+// format of the block volume driver. Files already in that format, which
+// always records the size of volumes, are left alone.
+func migrateMetadata(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), metadataFileSuffix) {
+			continue
+		}
+		path := filepath.Join(dir, f.Name())
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(b, &fields); err != nil {
+			// reported when the block volume driver loads it
+			continue
+		}
+		if _, ok := fields["Size"]; ok {
+			continue
+		}
+		var legacy legacyMetadata
+		if err := json.Unmarshal(b, &legacy); err != nil {
+			continue
+		}
+		if b, err = json.Marshal(convertMetadata(&legacy)); err != nil {
+			return err
+		}
+		if err := ioutils.AtomicWriteFile(path, b, metadataPermissions); err != nil {
+			return err
+		}
+		logrus.Infof("Converted the metadata of Ceph volume '%s' to a block volume", legacy.Name)
+	}
+	return nil
+}
+
+// convertMetadata returns the metadata of a volume written by the Ceph
+// driver before it was a block volume backend, in the format of the block
+// volume driver.
+func convertMetadata(legacy *legacyMetadata) *blockMetadata {
+	meta := &blockMetadata{
+		Name:           legacy.Name,
+		Size:           DefaultSize,
+		Options:        &block.Options{},
+		BackendOptions: make(map[string]string),
+		Device:         legacy.MappedDevicePath,
+		LuksDevice:     legacy.MappedLuksDevicePath,
+	}
+	if o := legacy.Options; o != nil {
+		if o.SizeMB != 0 {
+			meta.Size = o.SizeMB * units.MiB
+		}
+		if o.Pool != "" {
+			meta.BackendOptions["pool"] = o.Pool
+		}
+		if len(o.ImageFeatures) > 0 {
+			meta.BackendOptions["image-features"] = strings.Join(o.ImageFeatures, ",")
+		}
+		meta.Options = &o.Options
+	}
+	if legacy.MountID != "" {
+		meta.Users = map[string]int{legacy.MountID: 1}
+	}
+	return meta
+}
@@ -0,0 +1,63 @@
+package ceph
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/volume/block"
+)
+
+func TestMigrateMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ceph-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	legacy := `{"Name":"testing","MappedDevicePath":"/dev/rbd3","MountID":"abc",` +
+		`"Options":{"SizeMB":51200,"Pool":"ssd","ImageFeatures":["layering","exclusive-lock"],"FsType":"xfs","Encrypted":true}}`
+	current := `{"Name":"other","Size":1048576}`
+	for name, content := range map[string]string{"testing.json": legacy, "other.json": current} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := migrateMetadata(dir); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "testing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta blockMetadata
+	if err := json.Unmarshal(b, &meta); err != nil {
+		t.Fatal(err)
+	}
+	expected := blockMetadata{
+		Name:           "testing",
+		Size:           50 << 30,
+		Options:        &block.Options{FsType: "xfs", Encrypted: true},
+		BackendOptions: map[string]string{"pool": "ssd", "image-features": "layering,exclusive-lock"},
+		Device:         "/dev/rbd3",
+		Users:          map[string]int{"abc": 1},
+	}
+	if !reflect.DeepEqual(meta, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, meta)
+	}
+
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "other.json")); err != nil || string(b) != current {
+		t.Fatalf("expected metadata in the block format to be left alone, got %s (%v)", b, err)
+	}
+}
+
+func TestMigrateMetadataDefaults(t *testing.T) {
+	meta := convertMetadata(&legacyMetadata{Name: "testing"})
+	if meta.Size != DefaultSize || meta.Options == nil || len(meta.BackendOptions) != 0 || meta.Users != nil {
+		t.Fatalf("unexpected metadata %+v", meta)
+	}
+}
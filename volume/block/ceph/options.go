@@ -0,0 +1,97 @@
+package ceph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/go-units"
+)
+
+var (
+	// validOpts are the options specific to Ceph volumes, the size and
+	// filesystem options common to block volumes are also accepted
+	validOpts = map[string]bool{
+		"pool":           true, // pool the RBD image lives in
+		"image-features": true, // comma separated list of RBD image features
+	}
+
+	validImageFeatures = map[string]bool{
+		"layering":       true,
+		"striping":       true,
+		"exclusive-lock": true,
+		"object-map":     true,
+		"fast-diff":      true,
+		"deep-flatten":   true,
+		"journaling":     true,
+	}
+)
+
+type validationError struct {
+	error
+}
+
+func (validationError) IsValidationError() bool {
+	return true
+}
+
+// IsOption returns whether key is the `pool` or `image-features` option.
+func (b *Backend) IsOption(key string) bool {
+	return validOpts[key]
+}
+
+// ValidateOptions validates the pool and the image features of the image of
+// the volume.
+func (b *Backend) ValidateOptions(name string, opts map[string]string) error {
+	if value, ok := opts["pool"]; ok {
+		if strings.Contains(name, "/") {
+			return validationError{fmt.Errorf("pool option conflicts with the pool in volume name %q", name)}
+		}
+		if value == "" || strings.ContainsAny(value, "/@ ") {
+			return validationError{fmt.Errorf("invalid pool name %q", value)}
+		}
+	}
+	for _, f := range imageFeatures(opts) {
+		if !validImageFeatures[f] {
+			return validationError{fmt.Errorf("unsupported image feature %q, supported features are %s", f, joinKeys(validImageFeatures))}
+		}
+	}
+	return nil
+}
+
+// imageFeatures returns the features new images are created with.
+func imageFeatures(opts map[string]string) []string {
+	var features []string
+	for _, f := range strings.Split(opts["image-features"], ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
+// createArgs returns the arguments to pass to `rbd create` for an image of
+// size bytes, rounded up to a megabyte.
+func createArgs(size int64, opts map[string]string) []string {
+	sizeMB := (size + units.MiB - 1) / units.MiB
+	return append([]string{"--size", fmt.Sprintf("%d", sizeMB)}, featureArgs(opts)...)
+}
+
+// featureArgs returns the arguments selecting the features of new images,
+// which are also passed to `rbd clone`.
+func featureArgs(opts map[string]string) []string {
+	var args []string
+	for _, f := range imageFeatures(opts) {
+		args = append(args, "--image-feature", f)
+	}
+	return args
+}
+
+func joinKeys(m map[string]bool) string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,57 @@
+package ceph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateOptions(t *testing.T) {
+	b := &Backend{}
+	if err := b.ValidateOptions("testing", map[string]string{"pool": "ssd", "image-features": "layering, exclusive-lock"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name string
+		opts map[string]string
+	}{
+		{"testing", map[string]string{"image-features": "layering,teleportation"}},
+		{"testing", map[string]string{"pool": "a/b"}},
+		{"testing", map[string]string{"pool": ""}},
+		{"ssd/testing", map[string]string{"pool": "hdd"}},
+	} {
+		if err := b.ValidateOptions(c.name, c.opts); err == nil {
+			t.Fatalf("expected an error for %s with %v", c.name, c.opts)
+		} else if _, ok := err.(validationError); !ok {
+			t.Fatalf("expected a validation error for %s with %v, got %T", c.name, c.opts, err)
+		}
+	}
+}
+
+func TestCreateArgs(t *testing.T) {
+	opts := map[string]string{"image-features": "layering, exclusive-lock"}
+	expected := []string{"--size", "51200", "--image-feature", "layering", "--image-feature", "exclusive-lock"}
+	if args := createArgs(50<<30, opts); !reflect.DeepEqual(args, expected) {
+		t.Fatalf("expected %v, got %v", expected, args)
+	}
+	// sizes are rounded up to a megabyte
+	if args := createArgs(1<<20+1, nil); !reflect.DeepEqual(args, []string{"--size", "2"}) {
+		t.Fatalf("expected a 2M image, got %v", args)
+	}
+}
+
+func TestImageSpec(t *testing.T) {
+	cases := []struct {
+		name     string
+		opts     map[string]string
+		expected string
+	}{
+		{"testing", nil, "rbd/testing"},
+		{"testing", map[string]string{"pool": "ssd"}, "ssd/testing"},
+		{"ssd/testing", nil, "ssd/testing"},
+	}
+	for _, c := range cases {
+		if spec := imageSpec(c.name, c.opts); spec != c.expected {
+			t.Fatalf("expected %s for %s with %v, got %s", c.expected, c.name, c.opts, spec)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package rbd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// DefaultConfPath is the default location of the Ceph configuration.
+	DefaultConfPath = "/etc/ceph/ceph.conf"
+	// DefaultUser is the default Ceph user mappings authenticate as.
+	DefaultUser = "admin"
+	// defaultMonitorPort is the port of the monitor messenger v1 protocol,
+	// the only one the kernel client speaks.
+	defaultMonitorPort = "6789"
+)
+
+// Config holds how the kernel client reaches and authenticates with the
+// Ceph cluster.
+type Config struct {
+	// Monitors are the "address:port" of the cluster monitors.
+	Monitors []string
+	// User is the Ceph user, without the "client." prefix.
+	User string
+	// Secret is the base64 encoded key of the user.
+	Secret string
+}
+
+// ConfigOptions describe where the configuration is read from. Values that
+// are set override those read from the configuration files.
+type ConfigOptions struct {
+	// ConfPath is the Ceph configuration file the monitors are read from.
+	ConfPath string
+	// KeyringPath is the keyring the secret of the user is read from. It
+	// defaults to /etc/ceph/ceph.client.<user>.keyring.
+	KeyringPath string
+	// Monitors overrides the monitors of the configuration file.
+	Monitors []string
+	// User is the Ceph user, defaults to admin.
+	User string
+}
+
+// LoadConfig reads the cluster configuration described by opts.
+func LoadConfig(opts ConfigOptions) (*Config, error) {
+	c := &Config{
+		Monitors: opts.Monitors,
+		User:     opts.User,
+	}
+	if c.User == "" {
+		c.User = DefaultUser
+	}
+
+	if len(c.Monitors) == 0 {
+		confPath := opts.ConfPath
+		if confPath == "" {
+			confPath = DefaultConfPath
+		}
+		f, err := os.Open(confPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		monHost, err := iniValue(f, "global", "mon host")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", confPath, err)
+		}
+		c.Monitors = parseMonHost(monHost)
+	}
+	if len(c.Monitors) == 0 {
+		return nil, fmt.Errorf("no Ceph monitors configured")
+	}
+
+	keyringPath := opts.KeyringPath
+	if keyringPath == "" {
+		keyringPath = "/etc/ceph/ceph.client." + c.User + ".keyring"
+	}
+	f, err := os.Open(keyringPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c.Secret, err = iniValue(f, "client."+c.User, "key")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", keyringPath, err)
+	}
+	return c, nil
+}
+
+// iniValue returns the value of key in section of a Ceph configuration or
+// keyring file. Ceph treats spaces and underscores in keys the same.
+func iniValue(r io.Reader, section, key string) (string, error) {
+	normalize := func(s string) string {
+		return strings.Replace(strings.ToLower(strings.TrimSpace(s)), "_", " ", -1)
+	}
+	key = normalize(key)
+
+	var current string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if current != section {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 && normalize(parts[0]) == key {
+			return strings.TrimSpace(parts[1]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no %q in section [%s]", key, section)
+}
+
+// parseMonHost converts the `mon host` configuration, which may list
+// messenger v2 address vectors such as "[v2:10.0.0.1:3300,v1:10.0.0.1:6789]",
+// to the comma separated v1 addresses the kernel expects.
+func parseMonHost(monHost string) []string {
+	var monitors []string
+	for _, entry := range splitMonHost(monHost) {
+		var addr string
+		if strings.HasPrefix(entry, "[") {
+			for _, a := range strings.Split(strings.Trim(entry, "[]"), ",") {
+				if strings.HasPrefix(a, "v1:") {
+					addr = strings.TrimPrefix(a, "v1:")
+				}
+			}
+			if addr == "" {
+				continue
+			}
+		} else {
+			addr = strings.TrimPrefix(entry, "v1:")
+		}
+		if i := strings.Index(addr, "/"); i >= 0 {
+			addr = addr[:i]
+		}
+		if !strings.Contains(addr, ":") {
+			addr = addr + ":" + defaultMonitorPort
+		}
+		monitors = append(monitors, addr)
+	}
+	return monitors
+}
+
+// splitMonHost splits the `mon host` configuration on commas and spaces,
+// keeping bracketed address vectors together.
+func splitMonHost(monHost string) []string {
+	var (
+		entries []string
+		depth   int
+		start   = -1
+	)
+	for i, c := range monHost + " " {
+		switch {
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0 && (c == ',' || c == ' ' || c == ';'):
+			if start >= 0 {
+				entries = append(entries, monHost[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	return entries
+}
@@ -0,0 +1,77 @@
+package rbd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMonHost(t *testing.T) {
+	cases := map[string][]string{
+		"10.0.0.1":                   {"10.0.0.1:6789"},
+		"10.0.0.1:6790, 10.0.0.2":    {"10.0.0.1:6790", "10.0.0.2:6789"},
+		"10.0.0.1 10.0.0.2;10.0.0.3": {"10.0.0.1:6789", "10.0.0.2:6789", "10.0.0.3:6789"},
+		"v1:10.0.0.1:6789/0":         {"10.0.0.1:6789"},
+		"[v2:10.0.0.1:3300,v1:10.0.0.1:6789],[v2:10.0.0.2:3300/0,v1:10.0.0.2:6789/0]": {"10.0.0.1:6789", "10.0.0.2:6789"},
+		"[v2:10.0.0.1:3300]": nil,
+	}
+	for monHost, expected := range cases {
+		if monitors := parseMonHost(monHost); !reflect.DeepEqual(monitors, expected) {
+			t.Fatalf("%q: expected %v, got %v", monHost, expected, monitors)
+		}
+	}
+}
+
+func TestIniValue(t *testing.T) {
+	conf := `
+# comment
+[global]
+fsid = 1234
+mon_host = 10.0.0.1
+
+[client.admin]
+	key = c2VjcmV0
+`
+	if v, err := iniValue(strings.NewReader(conf), "global", "mon host"); err != nil || v != "10.0.0.1" {
+		t.Fatalf("expected 10.0.0.1, got %q (%v)", v, err)
+	}
+	if v, err := iniValue(strings.NewReader(conf), "client.admin", "key"); err != nil || v != "c2VjcmV0" {
+		t.Fatalf("expected c2VjcmV0, got %q (%v)", v, err)
+	}
+	if _, err := iniValue(strings.NewReader(conf), "client.docker", "key"); err == nil {
+		t.Fatal("expected an error for a missing section")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rbd-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	confPath := filepath.Join(dir, "ceph.conf")
+	keyringPath := filepath.Join(dir, "keyring")
+	if err := ioutil.WriteFile(confPath, []byte("[global]\nmon host = [v2:10.0.0.1:3300,v1:10.0.0.1:6789]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyringPath, []byte("[client.admin]\nkey = c2VjcmV0\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfig(ConfigOptions{ConfPath: confPath, KeyringPath: keyringPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Config{Monitors: []string{"10.0.0.1:6789"}, User: DefaultUser, Secret: "c2VjcmV0"}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, c)
+	}
+
+	if _, err := LoadConfig(ConfigOptions{ConfPath: confPath, KeyringPath: keyringPath, User: "docker"}); err == nil {
+		t.Fatal("expected an error for a user missing from the keyring")
+	}
+}
@@ -0,0 +1,87 @@
+package rbd
+
+import (
+	"errors"
+	"syscall"
+
+	"github.com/docker/docker/pkg/ctxexec"
+)
+
+var (
+	// ErrExists is returned when creating an image that already exists, or
+	// mapping an image that is already mapped.
+	ErrExists = errors.New("already exists")
+	// ErrNotFound is returned when an image or mapped device does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrBusy is returned when unmapping a device that is still open.
+	ErrBusy = errors.New("device or resource busy")
+	// ErrPermission is returned when the kernel or the cluster denies access.
+	ErrPermission = errors.New("permission denied")
+)
+
+// Error is the error type returned by the client. It describes the
+// operation, the image or device it was performed on, and the error.
+type Error struct {
+	// Op is the operation which caused the error, such as "map" or "unmap".
+	Op string
+	// Name is the image ("pool/image") or the device of the operation.
+	Name string
+	// Err is one of the typed errors of this package, or the underlying
+	// error if it could not be classified.
+	Err error
+}
+
+// Error satisfies the built-in error interface type.
+func (e *Error) Error() string {
+	return "rbd " + e.Op + " " + e.Name + ": " + e.Err.Error()
+}
+
+// Timeout returns whether the operation timed out.
+func (e *Error) Timeout() bool {
+	return ctxexec.IsTimeout(e.Err)
+}
+
+// IsExists returns whether the error indicates that the image already exists
+// or is already mapped.
+func IsExists(err error) bool {
+	return isErr(err, ErrExists)
+}
+
+// IsNotFound returns whether the error indicates that the image or device
+// does not exist.
+func IsNotFound(err error) bool {
+	return isErr(err, ErrNotFound)
+}
+
+// IsBusy returns whether the error indicates that the device is in use.
+func IsBusy(err error) bool {
+	return isErr(err, ErrBusy)
+}
+
+// IsPermission returns whether the error indicates that access was denied.
+func IsPermission(err error) bool {
+	return isErr(err, ErrPermission)
+}
+
+func isErr(err error, expected error) bool {
+	if e, ok := err.(*Error); ok {
+		err = e.Err
+	}
+	return err == expected
+}
+
+// errnoToError converts the errno of a failed sysfs write, or the exit status
+// of the rbd command, to one of the typed errors.
+func errnoToError(errno syscall.Errno) error {
+	switch errno {
+	case syscall.EEXIST:
+		return ErrExists
+	case syscall.ENOENT, syscall.ENXIO, syscall.ENODEV:
+		return ErrNotFound
+	case syscall.EBUSY:
+		return ErrBusy
+	case syscall.EACCES, syscall.EPERM:
+		return ErrPermission
+	}
+	return errno
+}
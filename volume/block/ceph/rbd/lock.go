@@ -0,0 +1,155 @@
+package rbd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/docker/pkg/ctxexec"
+	"golang.org/x/net/context"
+)
+
+// Lock is an advisory lock held on an image.
+type Lock struct {
+	// ID is the id the lock was taken with.
+	ID string
+	// Locker is the client that took the lock, such as "client.4123".
+	Locker string
+	// Address is the address of the locker.
+	Address string
+}
+
+// Watcher is a client watching the header of an image, which the kernel
+// does for as long as the image is mapped.
+type Watcher struct {
+	// Address is the address of the client.
+	Address string
+	// Client is the id of the client.
+	Client int64
+}
+
+// Locks returns the locks held on the image.
+func (c *Client) Locks(ctx context.Context, pool, image string) ([]Lock, error) {
+	out, err := run(ctx, "lock ls", pool+"/"+image, "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	locks, err := parseLocks(out)
+	if err != nil {
+		return nil, &Error{Op: "lock ls", Name: pool + "/" + image, Err: err}
+	}
+	return locks, nil
+}
+
+// parseLocks parses the output of `rbd lock ls --format json`. Older
+// releases print an object keyed by lock id, newer ones an array.
+func parseLocks(b []byte) ([]Lock, error) {
+	type lock struct {
+		ID      string `json:"id"`
+		Locker  string `json:"locker"`
+		Address string `json:"address"`
+	}
+	var ls []lock
+	if err := json.Unmarshal(b, &ls); err != nil {
+		var byID map[string]lock
+		if json.Unmarshal(b, &byID) != nil {
+			return nil, err
+		}
+		for id, l := range byID {
+			l.ID = id
+			ls = append(ls, l)
+		}
+	}
+	locks := make([]Lock, 0, len(ls))
+	for _, l := range ls {
+		locks = append(locks, Lock{ID: l.ID, Locker: l.Locker, Address: l.Address})
+	}
+	return locks, nil
+}
+
+// AddLock takes an exclusive lock on the image with the given id. ErrBusy
+// is returned if the image is already locked, even with the same id.
+func (c *Client) AddLock(ctx context.Context, pool, image, id string) error {
+	_, err := run(ctx, "lock add", pool+"/"+image, id)
+	return err
+}
+
+// RemoveLock breaks the lock with the given id held by locker. The locker is
+// the rbd tool that took the lock, not the kernel client that maps the
+// image, so breaking a lock doesn't stop a host from writing to the image;
+// see Blacklist.
+func (c *Client) RemoveLock(ctx context.Context, pool, image, id, locker string) error {
+	_, err := run(ctx, "lock rm", pool+"/"+image, id, locker)
+	return err
+}
+
+// Blacklist blacklists the client with the given address in the cluster,
+// such as the address of a Watcher, so that the OSDs refuse its requests
+// until the entry expires. Blacklisting the kernel client of a host that
+// maps an image fences that host off the image. It runs the ceph tool, as a
+// user allowed to change the OSD map.
+func (c *Client) Blacklist(ctx context.Context, address string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("ceph", "osd", "blacklist", "add", address)
+	cmd.Stderr = &stderr
+	if err := ctxexec.Run(ctx, cmd); err != nil {
+		if ctxexec.IsTimeout(err) {
+			return err
+		}
+		return fmt.Errorf("ceph osd blacklist add %s failed: %v - %s", address, err, strings.TrimRight(stderr.String(), "\n"))
+	}
+	return nil
+}
+
+// Watchers returns the clients watching the image. An image without
+// watchers is not mapped anywhere.
+func (c *Client) Watchers(ctx context.Context, pool, image string) ([]Watcher, error) {
+	out, err := run(ctx, "status", pool+"/"+image, "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	watchers, err := parseWatchers(out)
+	if err != nil {
+		return nil, &Error{Op: "status", Name: pool + "/" + image, Err: err}
+	}
+	return watchers, nil
+}
+
+// parseWatchers parses the output of `rbd status --format json`.
+func parseWatchers(b []byte) ([]Watcher, error) {
+	var status struct {
+		Watchers json.RawMessage `json:"watchers"`
+	}
+	if err := json.Unmarshal(b, &status); err != nil {
+		return nil, err
+	}
+	type watcher struct {
+		Address string `json:"address"`
+		Client  int64  `json:"client"`
+	}
+	var ws []watcher
+	if len(status.Watchers) > 0 {
+		if err := json.Unmarshal(status.Watchers, &ws); err != nil {
+			// older releases nest the list: {"watchers":{"watcher":[...]}}
+			var nested struct {
+				Watcher []watcher `json:"watcher"`
+			}
+			if json.Unmarshal(status.Watchers, &nested) != nil {
+				return nil, err
+			}
+			ws = nested.Watcher
+		}
+	}
+	watchers := make([]Watcher, 0, len(ws))
+	for _, w := range ws {
+		watchers = append(watchers, Watcher{Address: w.Address, Client: w.Client})
+	}
+	return watchers, nil
+}
+
+// String returns the locker and the id of the lock.
+func (l Lock) String() string {
+	return fmt.Sprintf("%s (%s) with id %s", l.Locker, strings.TrimSpace(l.Address), l.ID)
+}
@@ -0,0 +1,49 @@
+package rbd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLocks(t *testing.T) {
+	expected := []Lock{{ID: "docker/node-1/ABCD", Locker: "client.4123", Address: "10.0.0.5:0/3012"}}
+	for _, out := range []string{
+		`[{"id":"docker/node-1/ABCD","locker":"client.4123","address":"10.0.0.5:0/3012"}]`,
+		`{"docker/node-1/ABCD":{"locker":"client.4123","address":"10.0.0.5:0/3012"}}`,
+	} {
+		locks, err := parseLocks([]byte(out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(locks, expected) {
+			t.Fatalf("expected %+v, got %+v", expected, locks)
+		}
+	}
+
+	if locks, err := parseLocks([]byte("{}")); err != nil || len(locks) != 0 {
+		t.Fatalf("expected no locks, got %+v (%v)", locks, err)
+	}
+	if _, err := parseLocks([]byte("locked")); err == nil {
+		t.Fatal("expected an error for invalid output")
+	}
+}
+
+func TestParseWatchers(t *testing.T) {
+	expected := []Watcher{{Address: "10.0.0.5:0/1447839", Client: 84150}}
+	for _, out := range []string{
+		`{"watchers":[{"address":"10.0.0.5:0/1447839","client":84150,"cookie":18446462598732840961}]}`,
+		`{"watchers":{"watcher":[{"address":"10.0.0.5:0/1447839","client":84150,"cookie":1}]}}`,
+	} {
+		watchers, err := parseWatchers([]byte(out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(watchers, expected) {
+			t.Fatalf("expected %+v, got %+v", expected, watchers)
+		}
+	}
+
+	if watchers, err := parseWatchers([]byte(`{"watchers":[]}`)); err != nil || len(watchers) != 0 {
+		t.Fatalf("expected no watchers, got %+v (%v)", watchers, err)
+	}
+}
@@ -0,0 +1,357 @@
+// Package rbd maps and unmaps RADOS block device images through the kernel
+// RBD sysfs interface, without depending on the rbd command line tool.
+package rbd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/docker/docker/pkg/ctxexec"
+	"golang.org/x/net/context"
+)
+
+const (
+	// DefaultSysfsRoot is where sysfs is mounted.
+	DefaultSysfsRoot = "/sys"
+	// DefaultDevRoot is where the rbd block devices appear.
+	DefaultDevRoot = "/dev"
+	// noSnap is the snapshot name the kernel uses for the image head.
+	noSnap = "-"
+)
+
+// Mapping is an image mapped to a block device on this host.
+type Mapping struct {
+	// ID is the kernel id of the device, /dev/rbd<ID>.
+	ID int
+	// Pool is the pool of the image.
+	Pool string
+	// Image is the name of the image.
+	Image string
+	// Snap is the mapped snapshot, or "-" for the image head.
+	Snap string
+	// Device is the path of the block device.
+	Device string
+}
+
+// Client maps images through the kernel RBD driver. The cluster
+// configuration is loaded the first time an image is mapped, so that hosts
+// without Ceph can create a Client.
+type Client struct {
+	sysfsRoot string
+	devRoot   string
+
+	m          sync.Mutex
+	config     *Config
+	configOpts ConfigOptions
+}
+
+// NewClient returns a client using the sysfs and device trees rooted at
+// sysfsRoot and devRoot, and the cluster configuration described by opts.
+func NewClient(sysfsRoot, devRoot string, opts ConfigOptions) *Client {
+	return &Client{
+		sysfsRoot:  sysfsRoot,
+		devRoot:    devRoot,
+		configOpts: opts,
+	}
+}
+
+func (c *Client) busPath(elem ...string) string {
+	return filepath.Join(append([]string{c.sysfsRoot, "bus", "rbd"}, elem...)...)
+}
+
+func (c *Client) getConfig() (*Config, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.config == nil {
+		config, err := LoadConfig(c.configOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Ceph configuration: %v", err)
+		}
+		c.config = config
+	}
+	return c.config, nil
+}
+
+// controlFile returns the sysfs file to write to for action ("add" or
+// "remove"), preferring the single major interface of newer kernels.
+func (c *Client) controlFile(action string) string {
+	p := c.busPath(action + "_single_major")
+	if _, err := os.Stat(p); err == nil {
+		return p
+	}
+	return c.busPath(action)
+}
+
+// Mapped returns the images currently mapped on this host.
+func (c *Client) Mapped() ([]Mapping, error) {
+	dirs, err := ioutil.ReadDir(c.busPath("devices"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			// the rbd kernel module is not loaded, so nothing is mapped
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var mappings []Mapping
+	for _, d := range dirs {
+		id, err := strconv.Atoi(d.Name())
+		if err != nil {
+			continue
+		}
+		m := Mapping{
+			ID:     id,
+			Device: filepath.Join(c.devRoot, "rbd"+d.Name()),
+		}
+		for attr, value := range map[string]*string{"pool": &m.Pool, "name": &m.Image, "current_snap": &m.Snap} {
+			b, err := ioutil.ReadFile(c.busPath("devices", d.Name(), attr))
+			if err != nil {
+				return nil, err
+			}
+			*value = strings.TrimSpace(string(b))
+		}
+		mappings = append(mappings, m)
+	}
+	sort.Sort(byID(mappings))
+	return mappings, nil
+}
+
+// Device returns the device the head of the image is mapped to, or
+// ErrNotFound.
+func (c *Client) Device(pool, image string) (string, error) {
+	mappings, err := c.Mapped()
+	if err != nil {
+		return "", err
+	}
+	for _, m := range mappings {
+		if m.Pool == pool && m.Image == image && m.Snap == noSnap {
+			return m.Device, nil
+		}
+	}
+	return "", &Error{Op: "device", Name: pool + "/" + image, Err: ErrNotFound}
+}
+
+// Size returns the size in bytes of the image mapped to device.
+func (c *Client) Size(device string) (int64, error) {
+	id, err := deviceID(device)
+	if err != nil {
+		return 0, &Error{Op: "size", Name: device, Err: err}
+	}
+	b, err := ioutil.ReadFile(c.busPath("devices", id, "size"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, &Error{Op: "size", Name: device, Err: ErrNotFound}
+		}
+		return 0, &Error{Op: "size", Name: device, Err: err}
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+}
+
+// deviceID returns the kernel id of an rbd device, "N" for /dev/rbdN.
+func deviceID(device string) (string, error) {
+	id := strings.TrimPrefix(filepath.Base(device), "rbd")
+	if _, err := strconv.Atoi(id); err != nil {
+		return "", fmt.Errorf("not an rbd device")
+	}
+	return id, nil
+}
+
+// Map maps the head of the image and returns the block device. If the image
+// is already mapped the existing device is returned along with ErrExists.
+func (c *Client) Map(ctx context.Context, pool, image string) (string, error) {
+	name := pool + "/" + image
+	if device, err := c.Device(pool, image); err == nil {
+		return device, &Error{Op: "map", Name: name, Err: ErrExists}
+	} else if !IsNotFound(err) {
+		return "", err
+	}
+
+	config, err := c.getConfig()
+	if err != nil {
+		return "", &Error{Op: "map", Name: name, Err: err}
+	}
+	opts := "name=" + config.User
+	if config.Secret != "" {
+		opts += ",secret=" + config.Secret
+	}
+	spec := strings.Join([]string{strings.Join(config.Monitors, ","), opts, pool, image, noSnap}, " ")
+	if err := writeControl(ctx, c.controlFile("add"), spec); err != nil {
+		return "", &Error{Op: "map", Name: name, Err: err}
+	}
+
+	device, err := c.Device(pool, image)
+	if err != nil {
+		return "", &Error{Op: "map", Name: name, Err: fmt.Errorf("image was mapped but its device can't be found: %v", err)}
+	}
+	return device, nil
+}
+
+// Unmap unmaps the given rbd block device.
+func (c *Client) Unmap(ctx context.Context, device string) error {
+	id, err := deviceID(device)
+	if err != nil {
+		return &Error{Op: "unmap", Name: device, Err: err}
+	}
+	if _, err := os.Stat(c.busPath("devices", id)); os.IsNotExist(err) {
+		return &Error{Op: "unmap", Name: device, Err: ErrNotFound}
+	}
+	if err := writeControl(ctx, c.controlFile("remove"), id); err != nil {
+		return &Error{Op: "unmap", Name: device, Err: err}
+	}
+	return nil
+}
+
+// Create creates a new image. Images are created by librbd in user space,
+// so this runs `rbd create`; its exit status is translated to a typed error.
+func (c *Client) Create(ctx context.Context, pool, image string, args ...string) error {
+	_, err := run(ctx, "create", pool+"/"+image, args...)
+	return err
+}
+
+// ImageSize returns the size in bytes of the image, which does not need to
+// be mapped.
+func (c *Client) ImageSize(ctx context.Context, pool, image string) (int64, error) {
+	out, err := run(ctx, "info", pool+"/"+image, "--format", "json")
+	if err != nil {
+		return 0, err
+	}
+	var info struct {
+		Size int64 `json:"size"`
+	}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return 0, &Error{Op: "info", Name: pool + "/" + image, Err: err}
+	}
+	return info.Size, nil
+}
+
+// DiskUsage returns the provisioned size of the image and the space it and
+// its snapshots use in the cluster, in bytes. Images without the fast-diff
+// feature are scanned, which may take a while.
+func (c *Client) DiskUsage(ctx context.Context, pool, image string) (int64, int64, error) {
+	out, err := run(ctx, "du", pool+"/"+image, "--format", "json")
+	if err != nil {
+		return 0, 0, err
+	}
+	provisioned, used, err := parseDiskUsage(out)
+	if err != nil {
+		return 0, 0, &Error{Op: "du", Name: pool + "/" + image, Err: err}
+	}
+	return provisioned, used, nil
+}
+
+// parseDiskUsage parses the output of `rbd du --format json`, which lists
+// the image head and each of its snapshots, and their total.
+func parseDiskUsage(b []byte) (int64, int64, error) {
+	var du struct {
+		Images []struct {
+			Snapshot    string `json:"snapshot"`
+			Provisioned int64  `json:"provisioned_size"`
+		} `json:"images"`
+		TotalUsed int64 `json:"total_used_size"`
+	}
+	if err := json.Unmarshal(b, &du); err != nil {
+		return 0, 0, err
+	}
+	for _, i := range du.Images {
+		if i.Snapshot == "" {
+			return i.Provisioned, du.TotalUsed, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("no image in disk usage")
+}
+
+// Resize changes the size of the image to sizeMB megabytes. The image is
+// only shrunk if allowShrink is set. The kernel picks the new size of mapped
+// images up by itself.
+func (c *Client) Resize(ctx context.Context, pool, image string, sizeMB int64, allowShrink bool) error {
+	args := []string{"--size", strconv.FormatInt(sizeMB, 10)}
+	if allowShrink {
+		args = append(args, "--allow-shrink")
+	}
+	_, err := run(ctx, "resize", pool+"/"+image, args...)
+	return err
+}
+
+// run runs `rbd <op> <name> <args>` and returns its output. Operations on
+// images are done by librbd in user space, so they can't go through sysfs.
+// op may name a subcommand, such as "snap create". The exit status of rbd
+// is translated to a typed error.
+func run(ctx context.Context, op, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("rbd", append(append(strings.Fields(op), name), args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := ctxexec.Run(ctx, cmd); err != nil {
+		if ctxexec.IsTimeout(err) || err == context.Canceled {
+			return nil, &Error{Op: op, Name: name, Err: err}
+		}
+		// rbd exits with the errno of the failed librbd call
+		if exitError, ok := err.(*exec.ExitError); ok {
+			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
+				errno := syscall.Errno(status.ExitStatus())
+				if typed := errnoToError(errno); typed != errno {
+					return nil, &Error{Op: op, Name: name, Err: typed}
+				}
+			}
+		}
+		return nil, &Error{Op: op, Name: name, Err: fmt.Errorf("%v - %s", err, strings.TrimRight(stderr.String(), "\n"))}
+	}
+	return stdout.Bytes(), nil
+}
+
+// writeControl writes to one of the rbd bus control files, giving up when
+// ctx is done. The write itself can't be interrupted, so it is left behind
+// in its goroutine.
+func writeControl(ctx context.Context, path, data string) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- writeControlFile(path, data)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return &ctxexec.TimeoutError{Cmd: "write to " + path}
+		}
+		return ctx.Err()
+	}
+}
+
+// writeControlFile writes to a control file. The kernel reports failures as
+// the errno of the write.
+func writeControlFile(path, data string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s not found, is the rbd kernel module loaded?", path)
+		}
+		return err
+	}
+	_, err = f.Write([]byte(data))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if pe, ok := err.(*os.PathError); ok {
+		if errno, ok := pe.Err.(syscall.Errno); ok {
+			return errnoToError(errno)
+		}
+	}
+	return err
+}
+
+type byID []Mapping
+
+func (m byID) Len() int           { return len(m) }
+func (m byID) Less(i, j int) bool { return m[i].ID < m[j].ID }
+func (m byID) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
@@ -0,0 +1,282 @@
+package rbd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// fakeSysfs creates a sysfs tree with the rbd bus control files and the
+// given devices, keyed by id.
+func fakeSysfs(t *testing.T, devices map[string][3]string) string {
+	root, err := ioutil.TempDir("", "rbd-sysfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := filepath.Join(root, "bus", "rbd")
+	if err := os.MkdirAll(filepath.Join(bus, "devices"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"add_single_major", "remove_single_major"} {
+		if err := ioutil.WriteFile(filepath.Join(bus, f), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for id, d := range devices {
+		addDevice(t, root, id, d[0], d[1], d[2])
+	}
+	return root
+}
+
+func addDevice(t *testing.T, root, id, pool, image, snap string) {
+	dir := filepath.Join(root, "bus", "rbd", "devices", id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for attr, value := range map[string]string{"pool": pool, "name": image, "current_snap": snap} {
+		if err := ioutil.WriteFile(filepath.Join(dir, attr), []byte(value+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestMapped(t *testing.T) {
+	root := fakeSysfs(t, map[string][3]string{
+		"1": {"ssd", "bar", "-"},
+		"0": {"rbd", "foo", "-"},
+		"2": {"rbd", "foo", "snap1"},
+	})
+	defer os.RemoveAll(root)
+
+	c := NewClient(root, "/dev", ConfigOptions{})
+	mappings, err := c.Mapped()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Mapping{
+		{ID: 0, Pool: "rbd", Image: "foo", Snap: "-", Device: "/dev/rbd0"},
+		{ID: 1, Pool: "ssd", Image: "bar", Snap: "-", Device: "/dev/rbd1"},
+		{ID: 2, Pool: "rbd", Image: "foo", Snap: "snap1", Device: "/dev/rbd2"},
+	}
+	if !reflect.DeepEqual(mappings, expected) {
+		t.Fatalf("expected %v, got %v", expected, mappings)
+	}
+
+	device, err := c.Device("rbd", "foo")
+	if err != nil || device != "/dev/rbd0" {
+		t.Fatalf("expected /dev/rbd0, got %q (%v)", device, err)
+	}
+	if _, err := c.Device("rbd", "baz"); !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestMappedWithoutModule(t *testing.T) {
+	root, err := ioutil.TempDir("", "rbd-sysfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	mappings, err := NewClient(root, "/dev", ConfigOptions{}).Mapped()
+	if err != nil || mappings != nil {
+		t.Fatalf("expected no mappings, got %v (%v)", mappings, err)
+	}
+}
+
+func TestMap(t *testing.T) {
+	root := fakeSysfs(t, map[string][3]string{"0": {"rbd", "foo", "-"}})
+	defer os.RemoveAll(root)
+
+	keyring := filepath.Join(root, "keyring")
+	if err := ioutil.WriteFile(keyring, []byte("[client.docker]\n\tkey = c2VjcmV0\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(root, "/dev", ConfigOptions{
+		Monitors:    []string{"10.0.0.1:6789", "10.0.0.2:6789"},
+		User:        "docker",
+		KeyringPath: keyring,
+	})
+
+	device, err := c.Map(context.Background(), "rbd", "foo")
+	if !IsExists(err) || device != "/dev/rbd0" {
+		t.Fatalf("expected existing mapping /dev/rbd0, got %q (%v)", device, err)
+	}
+	if spec := readFile(t, filepath.Join(root, "bus", "rbd", "add_single_major")); spec != "" {
+		t.Fatalf("expected an existing mapping not to be mapped again, got %q", spec)
+	}
+
+	// the kernel creates the device as a result of the write, which the
+	// fake tree can't do, so the device is expected not to be found
+	if _, err := c.Map(context.Background(), "ssd", "bar"); err == nil {
+		t.Fatal("expected an error when the mapped device does not appear")
+	}
+	expected := "10.0.0.1:6789,10.0.0.2:6789 name=docker,secret=c2VjcmV0 ssd bar -"
+	if spec := readFile(t, filepath.Join(root, "bus", "rbd", "add_single_major")); spec != expected {
+		t.Fatalf("expected %q to be written, got %q", expected, spec)
+	}
+}
+
+func TestMapWithoutConfig(t *testing.T) {
+	root := fakeSysfs(t, nil)
+	defer os.RemoveAll(root)
+
+	c := NewClient(root, "/dev", ConfigOptions{ConfPath: filepath.Join(root, "missing.conf")})
+	if _, err := c.Map(context.Background(), "rbd", "foo"); err == nil {
+		t.Fatal("expected an error without a Ceph configuration")
+	}
+}
+
+func TestUnmap(t *testing.T) {
+	root := fakeSysfs(t, map[string][3]string{"3": {"rbd", "foo", "-"}})
+	defer os.RemoveAll(root)
+
+	c := NewClient(root, "/dev", ConfigOptions{})
+	if err := c.Unmap(context.Background(), "/dev/rbd3"); err != nil {
+		t.Fatal(err)
+	}
+	if id := readFile(t, filepath.Join(root, "bus", "rbd", "remove_single_major")); id != "3" {
+		t.Fatalf("expected device id 3 to be written, got %q", id)
+	}
+
+	if err := c.Unmap(context.Background(), "/dev/rbd4"); !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	if err := c.Unmap(context.Background(), "/dev/sda"); err == nil || IsNotFound(err) {
+		t.Fatalf("expected an invalid device error, got %v", err)
+	}
+}
+
+func TestLegacyControlFiles(t *testing.T) {
+	root := fakeSysfs(t, map[string][3]string{"0": {"rbd", "foo", "-"}})
+	defer os.RemoveAll(root)
+	bus := filepath.Join(root, "bus", "rbd")
+	os.Remove(filepath.Join(bus, "remove_single_major"))
+	if err := ioutil.WriteFile(filepath.Join(bus, "remove"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewClient(root, "/dev", ConfigOptions{}).Unmap(context.Background(), "/dev/rbd0"); err != nil {
+		t.Fatal(err)
+	}
+	if id := readFile(t, filepath.Join(bus, "remove")); id != "0" {
+		t.Fatalf("expected device id 0 to be written, got %q", id)
+	}
+}
+
+func TestUnmapTimeout(t *testing.T) {
+	root := fakeSysfs(t, map[string][3]string{"0": {"rbd", "foo", "-"}})
+	defer os.RemoveAll(root)
+	// opening a fifo for writing blocks until it has a reader, like a
+	// write the kernel never completes
+	remove := filepath.Join(root, "bus", "rbd", "remove_single_major")
+	os.Remove(remove)
+	if err := syscall.Mkfifo(remove, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := NewClient(root, "/dev", ConfigOptions{}).Unmap(ctx, "/dev/rbd0")
+	if e, ok := err.(*Error); !ok || !e.Timeout() {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+}
+
+func TestSize(t *testing.T) {
+	root := fakeSysfs(t, map[string][3]string{"0": {"rbd", "foo", "-"}})
+	defer os.RemoveAll(root)
+	if err := ioutil.WriteFile(filepath.Join(root, "bus", "rbd", "devices", "0", "size"), []byte("1073741824\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient(root, "/dev", ConfigOptions{})
+	size, err := c.Size("/dev/rbd0")
+	if err != nil || size != 1073741824 {
+		t.Fatalf("expected size 1073741824, got %d (%v)", size, err)
+	}
+	if _, err := c.Size("/dev/rbd1"); !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestParseDiskUsage(t *testing.T) {
+	out := `{"images":[{"name":"foo","snapshot":"nightly","provisioned_size":1073741824,"used_size":52428800},` +
+		`{"name":"foo","provisioned_size":2147483648,"used_size":104857600}],` +
+		`"total_provisioned_size":3221225472,"total_used_size":157286400}`
+	provisioned, used, err := parseDiskUsage([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provisioned != 2147483648 || used != 157286400 {
+		t.Fatalf("expected 2147483648 bytes provisioned and 157286400 used, got %d and %d", provisioned, used)
+	}
+
+	if _, _, err := parseDiskUsage([]byte(`{"images":[]}`)); err == nil {
+		t.Fatal("expected an error without the image")
+	}
+}
+
+// fakeRbd puts an rbd command printing message on stderr and exiting with
+// status first in PATH, and returns the function restoring PATH.
+func fakeRbd(t *testing.T, status int, message string) func() {
+	dir, err := ioutil.TempDir("", "rbd-bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := fmt.Sprintf("#!/bin/sh\necho %q >&2\nexit %d\n", message, status)
+	if err := ioutil.WriteFile(filepath.Join(dir, "rbd"), []byte(script), 0755); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	return func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRunExitStatus(t *testing.T) {
+	cases := []struct {
+		status   int
+		message  string
+		expected error
+	}{
+		{int(syscall.EEXIST), "rbd: create error: (17) File exists", ErrExists},
+		{int(syscall.ENOENT), "rbd: error opening image foo: (2) No such file or directory", ErrNotFound},
+		{int(syscall.EBUSY), "rbd: error: image still has watchers", ErrBusy},
+		// only the exit status is trusted, not the wording of the message
+		{1, "rbd: create error: image already exists", nil},
+	}
+	for _, c := range cases {
+		restore := fakeRbd(t, c.status, c.message)
+		err := NewClient("/sys", "/dev", ConfigOptions{}).Create(context.Background(), "rbd", "foo", "--size", "1024")
+		restore()
+		e, ok := err.(*Error)
+		if !ok {
+			t.Fatalf("expected an rbd error for exit status %d, got %v", c.status, err)
+		}
+		if c.expected != nil && e.Err != c.expected {
+			t.Fatalf("expected %v for exit status %d, got %v", c.expected, c.status, e.Err)
+		}
+		if c.expected == nil && (IsExists(err) || IsNotFound(err) || IsBusy(err)) {
+			t.Fatalf("expected an untyped error for exit status %d, got %v", c.status, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package rbd
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Snapshot is a snapshot of an image.
+type Snapshot struct {
+	// ID is the id of the snapshot, increasing with each snapshot taken.
+	ID int64
+	// Name is the name of the snapshot.
+	Name string
+	// Size is the size in bytes of the image when the snapshot was taken.
+	Size int64
+	// Timestamp is when the snapshot was taken. It is zero for releases
+	// that don't report it.
+	Timestamp time.Time
+	// Protected snapshots can be cloned, and can't be removed.
+	Protected bool
+}
+
+// snapSpec returns the "pool/image@snap" naming a snapshot.
+func snapSpec(pool, image, snap string) string {
+	return pool + "/" + image + "@" + snap
+}
+
+// Snapshots returns the snapshots of the image, oldest first.
+func (c *Client) Snapshots(ctx context.Context, pool, image string) ([]Snapshot, error) {
+	out, err := run(ctx, "snap ls", pool+"/"+image, "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	snaps, err := parseSnapshots(out)
+	if err != nil {
+		return nil, &Error{Op: "snap ls", Name: pool + "/" + image, Err: err}
+	}
+	return snaps, nil
+}
+
+// parseSnapshots parses the output of `rbd snap ls --format json`. The
+// protection status is a string, and the timestamp is in ctime format in the
+// local time zone.
+func parseSnapshots(b []byte) ([]Snapshot, error) {
+	var ls []struct {
+		ID        int64  `json:"id"`
+		Name      string `json:"name"`
+		Size      int64  `json:"size"`
+		Protected string `json:"protected"`
+		Timestamp string `json:"timestamp"`
+	}
+	if err := json.Unmarshal(b, &ls); err != nil {
+		return nil, err
+	}
+	snaps := make([]Snapshot, 0, len(ls))
+	for _, s := range ls {
+		snap := Snapshot{
+			ID:        s.ID,
+			Name:      s.Name,
+			Size:      s.Size,
+			Protected: s.Protected == "true",
+		}
+		if s.Timestamp != "" {
+			t, err := time.ParseInLocation(time.ANSIC, s.Timestamp, time.Local)
+			if err != nil {
+				return nil, err
+			}
+			snap.Timestamp = t
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, nil
+}
+
+// CreateSnapshot takes a snapshot of the image. Writes that are still
+// buffered by the kernel or a mounted filesystem are not part of it.
+func (c *Client) CreateSnapshot(ctx context.Context, pool, image, snap string) error {
+	_, err := run(ctx, "snap create", snapSpec(pool, image, snap))
+	return err
+}
+
+// RemoveSnapshot removes a snapshot of the image, unprotecting it first. A
+// snapshot that still has clones can't be unprotected; ErrBusy is returned.
+func (c *Client) RemoveSnapshot(ctx context.Context, pool, image, snap string) error {
+	snaps, err := c.Snapshots(ctx, pool, image)
+	if err != nil {
+		return err
+	}
+	for _, s := range snaps {
+		if s.Name == snap && s.Protected {
+			if _, err := run(ctx, "snap unprotect", snapSpec(pool, image, snap)); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = run(ctx, "snap rm", snapSpec(pool, image, snap))
+	return err
+}
+
+// Rollback reverts the image to a snapshot. It rewrites the whole image, so
+// it may take a long time on large images, and must not be done while the
+// image is in use.
+func (c *Client) Rollback(ctx context.Context, pool, image, snap string) error {
+	_, err := run(ctx, "snap rollback", snapSpec(pool, image, snap))
+	return err
+}
+
+// Clone creates the image destPool/destImage as a copy on write clone of a
+// snapshot, protecting the snapshot first so it can't be removed while the
+// clone depends on it. Only snapshots of images with the layering feature
+// can be cloned.
+func (c *Client) Clone(ctx context.Context, pool, image, snap, destPool, destImage string, args ...string) error {
+	_, err := run(ctx, "snap protect", snapSpec(pool, image, snap))
+	if err != nil && !IsBusy(err) {
+		// rbd exits with EBUSY if the snapshot is already protected
+		return err
+	}
+	_, err = run(ctx, "clone", snapSpec(pool, image, snap), append([]string{destPool + "/" + destImage}, args...)...)
+	return err
+}
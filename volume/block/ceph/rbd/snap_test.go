@@ -0,0 +1,30 @@
+package rbd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSnapshots(t *testing.T) {
+	out := `[{"id":4,"name":"nightly","size":1073741824,"protected":"true","timestamp":"Tue Oct  3 13:46:12 2017"},` +
+		`{"id":7,"name":"before-upgrade","size":2147483648}]`
+	snaps, err := parseSnapshots([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Snapshot{
+		{ID: 4, Name: "nightly", Size: 1073741824, Protected: true, Timestamp: time.Date(2017, time.October, 3, 13, 46, 12, 0, time.Local)},
+		{ID: 7, Name: "before-upgrade", Size: 2147483648},
+	}
+	if !reflect.DeepEqual(snaps, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, snaps)
+	}
+
+	if snaps, err := parseSnapshots([]byte("[]")); err != nil || len(snaps) != 0 {
+		t.Fatalf("expected no snapshots, got %+v (%v)", snaps, err)
+	}
+	if _, err := parseSnapshots([]byte(`[{"name":"bad","timestamp":"yesterday"}]`)); err == nil {
+		t.Fatal("expected an error for an invalid timestamp")
+	}
+}
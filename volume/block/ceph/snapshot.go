@@ -0,0 +1,103 @@
+package ceph
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/volume"
+	"github.com/docker/docker/volume/block/ceph/rbd"
+	"golang.org/x/net/context"
+)
+
+// CreateSnapshot takes an RBD snapshot of the image of the volume. Writes
+// that are still buffered by the kernel or a mounted filesystem are not
+// part of it.
+func (b *Backend) CreateSnapshot(ctx context.Context, name, snapshot string, opts map[string]string) (volume.Snapshot, error) {
+	pool, image := poolAndImage(name, opts)
+	if err := b.rbd.CreateSnapshot(ctx, pool, image, snapshot); err != nil {
+		if rbd.IsNotFound(err) {
+			return volume.Snapshot{}, validationError{fmt.Errorf("Ceph volume '%s' has never been mounted, there is nothing to snapshot", name)}
+		}
+		if rbd.IsExists(err) {
+			return volume.Snapshot{}, validationError{fmt.Errorf("Ceph volume '%s' already has a snapshot named '%s'", name, snapshot)}
+		}
+		return volume.Snapshot{}, err
+	}
+
+	snaps, err := b.Snapshots(ctx, name, opts)
+	if err != nil {
+		return volume.Snapshot{}, err
+	}
+	for _, s := range snaps {
+		if s.Name == snapshot {
+			return s, nil
+		}
+	}
+	return volume.Snapshot{}, fmt.Errorf("snapshot '%s' of Ceph volume '%s' was taken but can't be found", snapshot, name)
+}
+
+// Snapshots lists the RBD snapshots of the image of the volume. A volume
+// that has never been mounted has no image, and no snapshots.
+func (b *Backend) Snapshots(ctx context.Context, name string, opts map[string]string) ([]volume.Snapshot, error) {
+	pool, image := poolAndImage(name, opts)
+	snaps, err := b.rbd.Snapshots(ctx, pool, image)
+	if err != nil {
+		if rbd.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ls := make([]volume.Snapshot, 0, len(snaps))
+	for _, s := range snaps {
+		ls = append(ls, volume.Snapshot{Name: s.Name, CreatedAt: s.Timestamp, Size: s.Size})
+	}
+	return ls, nil
+}
+
+// RemoveSnapshot removes an RBD snapshot of the image of the volume.
+// Snapshots that volumes were created from can't be removed while those
+// volumes exist.
+func (b *Backend) RemoveSnapshot(ctx context.Context, name, snapshot string, opts map[string]string) error {
+	pool, image := poolAndImage(name, opts)
+	if err := b.rbd.RemoveSnapshot(ctx, pool, image, snapshot); err != nil {
+		if rbd.IsNotFound(err) {
+			return validationError{fmt.Errorf("Ceph volume '%s' has no snapshot named '%s'", name, snapshot)}
+		}
+		if rbd.IsBusy(err) {
+			return validationError{fmt.Errorf("snapshot '%s' of Ceph volume '%s' has clones, remove them first", snapshot, name)}
+		}
+		return err
+	}
+	return nil
+}
+
+// RollbackSnapshot reverts the image of the volume to an RBD snapshot. The
+// image is locked so that no other host maps it meanwhile.
+func (b *Backend) RollbackSnapshot(ctx context.Context, name, snapshot string, opts map[string]string) error {
+	unlock, err := b.lockImage(ctx, name, opts)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	pool, image := poolAndImage(name, opts)
+	if err := b.rbd.Rollback(ctx, pool, image, snapshot); err != nil {
+		if rbd.IsNotFound(err) {
+			return validationError{fmt.Errorf("Ceph volume '%s' has no snapshot named '%s'", name, snapshot)}
+		}
+		return err
+	}
+	return nil
+}
+
+// Clone creates the image of the named volume as an RBD clone of a snapshot
+// of the image of the source volume, with the image features of the volume.
+func (b *Backend) Clone(ctx context.Context, source, snapshot string, sourceOpts map[string]string, name string, opts map[string]string) error {
+	srcPool, srcImage := poolAndImage(source, sourceOpts)
+	pool, image := poolAndImage(name, opts)
+	if err := b.rbd.Clone(ctx, srcPool, srcImage, snapshot, pool, image, featureArgs(opts)...); err != nil {
+		if rbd.IsNotFound(err) {
+			return validationError{fmt.Errorf("Ceph volume '%s' has no snapshot named '%s'", source, snapshot)}
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,406 @@
+package block
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/ctxexec"
+	"golang.org/x/net/context"
+)
+
+// FilesystemType returns the type of the filesystem on device, empty if it
+// has none. Encrypted devices have the CryptoLuksFsType type.
+func FilesystemType(ctx context.Context, device string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	// probe the device itself, the blkid cache may be stale
+	cmd := exec.Command("blkid", "-p", "-s", "TYPE", "-o", "value", device)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := ctxexec.Run(ctx, cmd); err != nil {
+		if ctxexec.IsTimeout(err) {
+			return "", err
+		}
+		if exitError, ok := err.(*exec.ExitError); ok && exitStatus(exitError) == 2 {
+			return "", nil
+		}
+		return "", fmt.Errorf("Failed to determine the filesystem on %s: %v - %s", device, err, strings.TrimRight(stderr.String(), "\n"))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Device is the block device a volume is attached to, and the filesystem on
+// it. Its methods are not safe for concurrent use.
+type Device struct {
+	// Path is the device the storage of the volume is attached to
+	Path string
+	// LuksPath is the opened LUKS mapping of an encrypted device, set by
+	// Setup
+	LuksPath string
+	// MapperName is the name the LUKS mapping is opened with
+	MapperName string
+	// Name is the name of the volume, used in messages and to look keys up
+	Name string
+	// Options are the options the volume was created with
+	Options *Options
+	// Keys stores the passphrases of encrypted volumes
+	Keys KeyProvider
+	// LegacyKey is used, with a warning, for encrypted devices that have
+	// no key stored, if set.
+	LegacyKey []byte
+	// Timeout and FsTimeout limit how long helper commands may run, the
+	// default timeouts are used if they are not set.
+	Timeout   time.Duration
+	FsTimeout time.Duration
+}
+
+// FilesystemDevice returns the device holding the filesystem: the LUKS
+// mapping of encrypted devices, the device itself otherwise.
+func (d *Device) FilesystemDevice() string {
+	if d.LuksPath != "" {
+		return d.LuksPath
+	}
+	return d.Path
+}
+
+func (d *Device) commandContext() (context.Context, context.CancelFunc) {
+	return withTimeout(d.Timeout, DefaultTimeout)
+}
+
+func (d *Device) fsContext() (context.Context, context.CancelFunc) {
+	return withTimeout(d.FsTimeout, DefaultFsTimeout)
+}
+
+// FilesystemType returns the type of the filesystem on the device.
+func (d *Device) FilesystemType() (string, error) {
+	ctx, cancel := d.commandContext()
+	defer cancel()
+	return FilesystemType(ctx, d.FilesystemDevice())
+}
+
+// Key returns the passphrase of the encrypted device.
+func (d *Device) Key() ([]byte, error) {
+	key, err := d.Keys.GetKey(d.Name)
+	if err == ErrKeyNotFound && d.LegacyKey != nil {
+		logrus.Warnf("No LUKS key stored for volume '%s', using the legacy key. Rotate the key to secure the volume", d.Name)
+		return d.LegacyKey, nil
+	}
+	return key, err
+}
+
+// Setup prepares the device to be mounted and returns the device holding
+// the filesystem. New devices are encrypted if the options say so and
+// formatted, encrypted devices are opened, and existing filesystems are
+// checked according to the fsck policy, and grown to the size of the device
+// if they are smaller.
+func (d *Device) Setup() (string, error) {
+	ctx, cancel := d.commandContext()
+	fsType, err := FilesystemType(ctx, d.Path)
+	cancel()
+	if err != nil {
+		return "", err
+	}
+
+	if d.Options.Encrypted {
+		switch fsType {
+		case "":
+			if err := d.luksFormat(); err != nil {
+				return "", err
+			}
+			fsType = CryptoLuksFsType
+		case CryptoLuksFsType:
+		default:
+			return "", fmt.Errorf("volume '%s' should be encrypted but device %s has a %s filesystem", d.Name, d.Path, fsType)
+		}
+	}
+
+	if fsType == CryptoLuksFsType {
+		if err := d.luksOpen(); err != nil {
+			return "", err
+		}
+		if fsType, err = d.FilesystemType(); err != nil {
+			return "", err
+		}
+	}
+
+	device := d.FilesystemDevice()
+	if fsType == "" {
+		return device, d.mkfs()
+	}
+	if err := d.fsck(fsType); err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(fsType, "ext") {
+		if err := d.growExt(); err != nil {
+			return "", err
+		}
+	}
+	return device, nil
+}
+
+// luksFormat encrypts the device with a newly generated key, which is stored
+// with the key provider first so that it is never lost.
+func (d *Device) luksFormat() error {
+	key, err := GenerateKey()
+	if err != nil {
+		return err
+	}
+	if err := d.Keys.SetKey(d.Name, key); err != nil {
+		return fmt.Errorf("Failed to store LUKS key of volume '%s': %v", d.Name, err)
+	}
+	ctx, cancel := d.commandContext()
+	defer cancel()
+	if err := LuksFormat(ctx, d.Path, key); err != nil {
+		logrus.Errorf("Failed to encrypt volume '%s' (device %s): %v", d.Name, d.Path, err)
+		return err
+	}
+	logrus.Infof("Encrypted volume '%s' (device %s)", d.Name, d.Path)
+	return nil
+}
+
+// luksOpen opens the LUKS mapping of the device, unless it is already open.
+func (d *Device) luksOpen() error {
+	mapperPath := filepath.Join(LuksDevMapperPath, d.MapperName)
+	if _, err := os.Stat(mapperPath); err == nil {
+		logrus.Infof("LUKS mapping %s of volume '%s' is already open", mapperPath, d.Name)
+		d.LuksPath = mapperPath
+		return nil
+	}
+	key, err := d.Key()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := d.commandContext()
+	defer cancel()
+	luksPath, err := LuksOpen(ctx, d.Path, d.MapperName, key, d.Options.Discard())
+	if err != nil {
+		logrus.Errorf("Failed to open encrypted volume '%s' (device %s): %v", d.Name, d.Path, err)
+		return err
+	}
+	d.LuksPath = luksPath
+	return nil
+}
+
+func (d *Device) mkfs() error {
+	device := d.FilesystemDevice()
+	fsType := d.Options.FilesystemType()
+	logrus.Infof("Creating %s filesystem on volume '%s' (device %s)", fsType, d.Name, device)
+	ctx, cancel := d.fsContext()
+	out, err := ctxexec.CombinedOutput(ctx, exec.Command("mkfs."+fsType, append(d.Options.MkfsArgs(), device)...))
+	cancel()
+	if err != nil {
+		logrus.Errorf("Failed to create %s filesystem on volume '%s' (device %s): %v - %s", fsType, d.Name, device, err, out)
+		return err
+	}
+	return nil
+}
+
+// fsck checks the filesystem according to the fsck policy. Only errors that
+// fsck could not correct fail the check.
+func (d *Device) fsck(fsType string) error {
+	policy := d.Options.FsckPolicy()
+	if policy == FsckSkip {
+		return nil
+	}
+	device := d.FilesystemDevice()
+	args := []string{"-a"}
+	if policy == FsckForce {
+		args = append(args, "-f")
+	}
+	ctx, cancel := d.fsContext()
+	out, err := ctxexec.CombinedOutput(ctx, exec.Command("fsck", append(args, device)...))
+	cancel()
+	if exitError, ok := err.(*exec.ExitError); ok && exitStatus(exitError) == 1 {
+		logrus.Warnf("Corrected errors in the %s filesystem of volume '%s': %s", fsType, d.Name, out)
+		return nil
+	}
+	if err != nil {
+		logrus.Errorf("Failed to check the %s filesystem of volume '%s' (device %s): %v - %s", fsType, d.Name, device, err, out)
+		return err
+	}
+	logrus.Infof("Checked the %s filesystem of volume '%s': %s", fsType, d.Name, out)
+	return nil
+}
+
+// growExt grows an unmounted ext filesystem that is smaller than its
+// device, which happens when the volume was resized while it was not
+// mounted.
+func (d *Device) growExt() error {
+	device := d.FilesystemDevice()
+	deviceSize, err := deviceSize(device)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := d.commandContext()
+	fsSize, err := extFilesystemSize(ctx, device)
+	cancel()
+	if err != nil {
+		return err
+	}
+	if fsSize >= deviceSize {
+		return nil
+	}
+
+	logrus.Infof("Growing the filesystem of volume '%s' from %d to %d bytes", d.Name, fsSize, deviceSize)
+	ctx, cancel = d.fsContext()
+	defer cancel()
+	// resize2fs refuses to work on filesystems that were not just checked
+	if out, err := ctxexec.CombinedOutput(ctx, exec.Command("e2fsck", "-f", "-p", device)); err != nil {
+		logrus.Errorf("Failed to check the filesystem of volume '%s' before growing it: %v - %s", d.Name, err, out)
+		return err
+	}
+	if out, err := ctxexec.CombinedOutput(ctx, exec.Command("resize2fs", device)); err != nil {
+		logrus.Errorf("Failed to grow the filesystem of volume '%s': %v - %s", d.Name, err, out)
+		return err
+	}
+	return nil
+}
+
+// Teardown closes the LUKS mapping of an encrypted device, whose filesystem
+// must not be mounted any more.
+func (d *Device) Teardown() error {
+	mapperPath := d.LuksPath
+	if mapperPath == "" {
+		// the mapping may have been opened by a previous daemon
+		mapperPath = filepath.Join(LuksDevMapperPath, d.MapperName)
+		if _, err := os.Stat(mapperPath); err != nil {
+			return nil
+		}
+	}
+	ctx, cancel := d.commandContext()
+	defer cancel()
+	if err := LuksClose(ctx, filepath.Base(mapperPath)); err != nil {
+		logrus.Errorf("Failed to close LUKS mapping %s of volume '%s': %v", mapperPath, d.Name, err)
+		return err
+	}
+	d.LuksPath = ""
+	return nil
+}
+
+// Grow grows the LUKS mapping and the filesystem of the device to the size
+// of the device. Filesystems that are not mounted are left alone; ext
+// filesystems are grown by Setup when they are next mounted.
+func (d *Device) Grow() error {
+	if d.LuksPath != "" {
+		key, err := d.Key()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := d.commandContext()
+		err = LuksResize(ctx, filepath.Base(d.LuksPath), key)
+		cancel()
+		if err != nil {
+			logrus.Errorf("Failed to resize LUKS mapping %s of volume '%s': %v", d.LuksPath, d.Name, err)
+			return err
+		}
+	}
+
+	device := d.FilesystemDevice()
+	l, mounted := FindMount(device)
+	if !mounted {
+		logrus.Infof("Filesystem of volume '%s' is not mounted, not growing it", d.Name)
+		return nil
+	}
+	fsType, err := d.FilesystemType()
+	if err != nil {
+		return err
+	}
+	var cmd *exec.Cmd
+	switch {
+	case strings.HasPrefix(fsType, "ext"):
+		cmd = l.Command("resize2fs", device)
+	case fsType == "xfs":
+		cmd = l.Command("xfs_growfs", l.Mountpoint)
+	case fsType == "btrfs":
+		cmd = l.Command("btrfs", "filesystem", "resize", "max", l.Mountpoint)
+	default:
+		return fmt.Errorf("growing %s filesystems is not supported", fsType)
+	}
+	ctx, cancel := d.fsContext()
+	out, err := ctxexec.CombinedOutput(ctx, cmd)
+	cancel()
+	if err != nil {
+		logrus.Errorf("Failed to grow the %s filesystem of volume '%s': %v - %s", fsType, d.Name, err, out)
+		return err
+	}
+	logrus.Infof("Grew the %s filesystem of volume '%s': %s", fsType, d.Name, out)
+	return nil
+}
+
+// Freeze freezes the filesystem of the device if it is mounted, and returns
+// the function thawing it.
+func (d *Device) Freeze() (func(), error) {
+	l, mounted := FindMount(d.FilesystemDevice())
+	if !mounted {
+		return func() {}, nil
+	}
+
+	ctx, cancel := d.commandContext()
+	out, err := ctxexec.CombinedOutput(ctx, l.Command("fsfreeze", "--freeze", l.Mountpoint))
+	cancel()
+	if err != nil {
+		logrus.Errorf("Failed to freeze the filesystem of volume '%s': %v - %s", d.Name, err, out)
+		return nil, err
+	}
+	return func() {
+		// the filesystem must be thawed however long the caller took
+		ctx, cancel := d.commandContext()
+		defer cancel()
+		if out, err := ctxexec.CombinedOutput(ctx, l.Command("fsfreeze", "--unfreeze", l.Mountpoint)); err != nil {
+			logrus.Errorf("Failed to thaw the filesystem of volume '%s': %v - %s", d.Name, err, out)
+		}
+	}, nil
+}
+
+// deviceSize returns the size of the block device, or regular file, at
+// path.
+func deviceSize(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return f.Seek(0, os.SEEK_END)
+}
+
+// extFilesystemSize returns the size of the ext filesystem on device, from
+// its superblock.
+func extFilesystemSize(ctx context.Context, device string) (int64, error) {
+	out, err := ctxexec.Output(ctx, exec.Command("dumpe2fs", "-h", device))
+	if err != nil {
+		return 0, fmt.Errorf("Failed to read the superblock of %s: %v", device, err)
+	}
+	return parseExtSuperblock(out)
+}
+
+func parseExtSuperblock(out []byte) (int64, error) {
+	var count, size int64
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		var err error
+		switch parts[0] {
+		case "Block count":
+			count, err = strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		case "Block size":
+			size, err = strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("invalid superblock field %q: %v", scanner.Text(), err)
+		}
+	}
+	if count == 0 || size == 0 {
+		return 0, fmt.Errorf("no block count and size in superblock")
+	}
+	return count * size, nil
+}
@@ -0,0 +1,102 @@
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+// newTestDevice returns a device backed by a regular file of size bytes,
+// which mkfs, blkid and fsck work on as well as on block devices.
+func newTestDevice(t *testing.T, dir string, size int64) *Device {
+	if _, err := exec.LookPath("mkfs.ext4"); err != nil {
+		t.Skip("mkfs.ext4 is not installed")
+	}
+	path := filepath.Join(dir, "device")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := f.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	return &Device{Path: path, Name: "testing", Options: &Options{}}
+}
+
+func TestDeviceSetup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "block-device-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := newTestDevice(t, dir, 32<<20)
+	if fsType, err := FilesystemType(context.Background(), d.Path); err != nil || fsType != "" {
+		t.Fatalf("expected no filesystem on a new device, got %q (%v)", fsType, err)
+	}
+	device, err := d.Setup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if device != d.Path {
+		t.Fatalf("expected filesystem on %s, got %s", d.Path, device)
+	}
+	if fsType, err := d.FilesystemType(); err != nil || fsType != "ext4" {
+		t.Fatalf("expected an ext4 filesystem, got %q (%v)", fsType, err)
+	}
+
+	// the device grew while the volume was not mounted
+	if err := os.Truncate(d.Path, 64<<20); err != nil {
+		t.Fatal(err)
+	}
+	d.Options.Fsck = FsckForce
+	if _, err := d.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	size, err := extFilesystemSize(context.Background(), d.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 64<<20 {
+		t.Fatalf("expected the filesystem to be grown to 64M, got %d bytes", size)
+	}
+}
+
+func TestDeviceSetupNotEncrypted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "block-device-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := newTestDevice(t, dir, 32<<20)
+	if _, err := d.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	d.Options.Encrypted = true
+	if _, err := d.Setup(); err == nil {
+		t.Fatal("expected an error setting an encrypted volume up on a plain filesystem")
+	}
+}
+
+func TestParseExtSuperblock(t *testing.T) {
+	out := "Filesystem volume name:   <none>\nBlock count:              16384\nReserved block count:     0\nBlock size:               4096\n"
+	size, err := parseExtSuperblock([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 16384*4096 {
+		t.Fatalf("expected %d bytes, got %d", 16384*4096, size)
+	}
+	if _, err := parseExtSuperblock([]byte("Block count: many\n")); err == nil {
+		t.Fatal("expected an error for an invalid block count")
+	}
+	if _, err := parseExtSuperblock(nil); err == nil {
+		t.Fatal("expected an error for an empty superblock")
+	}
+}
@@ -0,0 +1,787 @@
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/volume"
+	"github.com/docker/go-units"
+	"golang.org/x/net/context"
+)
+
+const (
+	// DefaultSize is the size of volumes created without the `size` option.
+	DefaultSize = 10 * units.GiB
+
+	// daemon volume options, prefixed by the name of the driver
+	timeoutOpt     = "timeout"
+	fsTimeoutOpt   = "fs-timeout"
+	keyProviderOpt = "luks-key-provider"
+
+	metadataFileSuffix  = ".json"
+	metadataPermissions = 0600
+
+	// statusTimeout limits how long Status waits for the usage of the
+	// filesystem of a volume
+	statusTimeout = 5 * time.Second
+)
+
+// New instantiates a volume driver storing its volumes with backend. The
+// driver keeps the metadata of its volumes, and mounts their filesystems, in
+// directories under scope, the daemon root, named after the driver. The daemon volume options prefixed
+// by the name of the driver set the timeouts of helper commands and the LUKS
+// key provider.
+func New(scope string, backend Backend, opts map[string]string) (*Root, error) {
+	name := backend.Name()
+	timeout, err := ParseTimeout(opts, name+"."+timeoutOpt, DefaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	fsTimeout, err := ParseTimeout(opts, name+"."+fsTimeoutOpt, DefaultFsTimeout)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := NewKeyProvider(opts[name+"."+keyProviderOpt], filepath.Join(scope, name+"_keys"), timeout)
+	if err != nil {
+		return nil, err
+	}
+	r := &Root{
+		path:      filepath.Join(scope, name+"_volumes"),
+		mountsDir: filepath.Join(scope, name+"_mounts"),
+		backend:   backend,
+		volumes:   make(map[string]*Volume),
+		keys:      keys,
+		timeout:   timeout,
+		fsTimeout: fsTimeout,
+	}
+	if err := os.MkdirAll(r.path, 0700); err != nil {
+		return nil, err
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.reconcile()
+	return r, nil
+}
+
+// ParseTimeout returns the duration of the timeout option key, or def if
+// it is not set.
+func ParseTimeout(opts map[string]string, key string, def time.Duration) (time.Duration, error) {
+	value, ok := opts[key]
+	if !ok {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a positive duration such as 90s", key, value)
+	}
+	return d, nil
+}
+
+// Root is a volume driver whose volumes are stored by a Backend.
+type Root struct {
+	m sync.Mutex
+	// path is the directory holding the volume metadata files
+	path string
+	// mountsDir is the directory the filesystems of volumes are mounted in
+	mountsDir string
+	backend   Backend
+	volumes   map[string]*Volume
+	// keys manages the passphrases of encrypted volumes
+	keys KeyProvider
+	// timeout and fsTimeout limit how long helper commands may run
+	timeout   time.Duration
+	fsTimeout time.Duration
+}
+
+// Name returns the name of the driver, which is the name of its backend.
+func (r *Root) Name() string {
+	return r.backend.Name()
+}
+
+// Scope returns the scope of the volumes, which are attached to this host.
+func (r *Root) Scope() string {
+	return volume.LocalScope
+}
+
+func (r *Root) commandContext() (context.Context, context.CancelFunc) {
+	return withTimeout(r.timeout, DefaultTimeout)
+}
+
+func (r *Root) fsContext() (context.Context, context.CancelFunc) {
+	return withTimeout(r.fsTimeout, DefaultFsTimeout)
+}
+
+// defaultSize returns the size of volumes created without the `size`
+// option.
+func (r *Root) defaultSize() int64 {
+	if s, ok := r.backend.(DefaultSizer); ok {
+		return s.DefaultSize()
+	}
+	return DefaultSize
+}
+
+// Create records a new volume. Its storage is created by the backend when
+// it is first mounted.
+func (r *Root) Create(name string, opts map[string]string) (volume.Volume, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if v, exists := r.volumes[name]; exists {
+		return v, nil
+	}
+	v := &Volume{
+		root:        r,
+		name:        name,
+		size:        r.defaultSize(),
+		opts:        &Options{},
+		backendOpts: make(map[string]string),
+		users:       make(map[string]int),
+	}
+	for key, value := range opts {
+		switch {
+		case key == "size":
+			size, err := units.RAMInBytes(value)
+			if err != nil {
+				return nil, validationError{fmt.Errorf("invalid size %q: %v", value, err)}
+			}
+			if size < units.MiB {
+				return nil, validationError{fmt.Errorf("invalid size %q: must be at least 1M", value)}
+			}
+			v.size = size
+		case IsOption(key):
+			if err := v.opts.Set(key, value); err != nil {
+				return nil, err
+			}
+		case r.backend.IsOption(key):
+			v.backendOpts[key] = value
+		default:
+			return nil, validationError{fmt.Errorf("invalid option key: %q", key)}
+		}
+	}
+	if err := r.backend.ValidateOptions(name, v.backendOpts); err != nil {
+		return nil, err
+	}
+	if err := r.checkStorageUnused(v.backendOpts); err != nil {
+		return nil, err
+	}
+	if err := v.save(); err != nil {
+		return nil, err
+	}
+	r.volumes[name] = v
+	return v, nil
+}
+
+// checkStorageUnused returns a validation error if another volume uses the
+// storage a volume created with the given backend options would use.
+// Callers must hold r.m.
+func (r *Root) checkStorageUnused(opts map[string]string) error {
+	identifier, ok := r.backend.(StorageIdentifier)
+	if !ok {
+		return nil
+	}
+	id := identifier.StorageID(opts)
+	for name, v := range r.volumes {
+		if identifier.StorageID(v.backendOpts) == id {
+			return validationError{fmt.Errorf("%s volume '%s' already uses %s", r.Name(), name, id)}
+		}
+	}
+	return nil
+}
+
+// Get looks up the volume for the given name and returns it if found
+func (r *Root) Get(name string) (volume.Volume, error) {
+	r.m.Lock()
+	v, exists := r.volumes[name]
+	r.m.Unlock()
+	if !exists {
+		return nil, fmt.Errorf("volume not found")
+	}
+	return v, nil
+}
+
+// List lists all the volumes
+func (r *Root) List() ([]volume.Volume, error) {
+	var ls []volume.Volume
+	r.m.Lock()
+	for _, v := range r.volumes {
+		ls = append(ls, v)
+	}
+	r.m.Unlock()
+	return ls, nil
+}
+
+// Remove destroys the storage of a volume that is not in use, and forgets
+// the volume.
+func (r *Root) Remove(v volume.Volume) error {
+	bv, ok := v.(*Volume)
+	if !ok {
+		return fmt.Errorf("unknown volume type")
+	}
+	bv.m.Lock()
+	defer bv.m.Unlock()
+	if bv.device != "" {
+		return validationError{fmt.Errorf("%s volume '%s' is in use", r.Name(), bv.name)}
+	}
+
+	ctx, cancel := r.commandContext()
+	err := r.backend.Remove(ctx, bv.name, bv.backendOpts)
+	cancel()
+	if err != nil {
+		logrus.Errorf("Failed to remove the storage of %s volume '%s': %v", r.Name(), bv.name, err)
+		return err
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := os.Remove(r.metadataPath(bv.name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	delete(r.volumes, bv.name)
+	return nil
+}
+
+// Volume is a volume stored by the backend of its driver.
+type Volume struct {
+	m    sync.Mutex
+	root *Root
+	name string
+	// size is the size of the storage in bytes
+	size int64
+	// opts are the filesystem options the volume was created with
+	opts *Options
+	// backendOpts are the options of the backend the volume was created with
+	backendOpts map[string]string
+	// device is the device the storage is attached to, empty when detached
+	device string
+	// luksDevice is the opened LUKS mapping of an encrypted device
+	luksDevice string
+	// users counts the Mount calls not yet matched by an Unmount call, per
+	// container id
+	users map[string]int
+}
+
+// Name returns the name of the volume
+func (v *Volume) Name() string {
+	return v.name
+}
+
+// DriverName returns the name of the driver of the volume
+func (v *Volume) DriverName() string {
+	return v.root.Name()
+}
+
+// Path returns the directory the filesystem of the volume is mounted on
+// while it is in use, which containers bind mount.
+func (v *Volume) Path() string {
+	return filepath.Join(v.root.mountsDir, strings.Replace(v.name, "/", "--", -1))
+}
+
+// blockDevice returns the device of the volume, for the filesystem
+// helpers.
+func (v *Volume) blockDevice() *Device {
+	var legacyKey []byte
+	if k, ok := v.root.backend.(LegacyKeyer); ok {
+		legacyKey = k.LegacyKey(v.name)
+	}
+	return &Device{
+		Path:       v.device,
+		LuksPath:   v.luksDevice,
+		MapperName: v.root.Name() + "-" + strings.Replace(v.name, "/", "--", -1),
+		Name:       v.name,
+		Options:    v.opts,
+		Keys:       v.root.keys,
+		LegacyKey:  legacyKey,
+		Timeout:    v.root.timeout,
+		FsTimeout:  v.root.fsTimeout,
+	}
+}
+
+// Mount creates and attaches the storage of the volume if needed, mounts
+// its filesystem on the host, and returns the mountpoint.
+func (v *Volume) Mount(id string) (string, error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	// Unmount is called if Mount fails, so the user is registered first
+	v.users[id]++
+	defer v.save()
+	if v.device == "" {
+		if err := v.attach(); err != nil {
+			return "", err
+		}
+	}
+
+	mountpoint := v.Path()
+	ctx, cancel := v.root.commandContext()
+	defer cancel()
+	if err := mountFilesystem(ctx, v.blockDevice().FilesystemDevice(), mountpoint, v.opts.MountOptions()); err != nil {
+		logrus.Errorf("Failed to mount %s volume '%s' on %s: %v", v.root.Name(), v.name, mountpoint, err)
+		return "", err
+	}
+	return mountpoint, nil
+}
+
+// attach creates and attaches the storage of the volume, and prepares its
+// filesystem. Callers must hold v.m.
+func (v *Volume) attach() error {
+	ctx, cancel := v.root.commandContext()
+	defer cancel()
+	if err := v.root.backend.Create(ctx, v.name, v.size, v.backendOpts); err != nil {
+		logrus.Errorf("Failed to create the storage of %s volume '%s': %v", v.root.Name(), v.name, err)
+		return err
+	}
+	device, err := v.root.backend.Attach(ctx, v.name, v.backendOpts)
+	if err != nil {
+		logrus.Errorf("Failed to attach %s volume '%s': %v", v.root.Name(), v.name, err)
+		return err
+	}
+	logrus.Infof("Attached %s volume '%s' to %s", v.root.Name(), v.name, device)
+	v.device = device
+
+	d := v.blockDevice()
+	_, err = d.Setup()
+	v.luksDevice = d.LuksPath
+	return err
+}
+
+// Unmount releases a Mount call, and detaches the storage of the volume
+// once it is not used any more.
+func (v *Volume) Unmount(id string) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if v.users[id] > 0 {
+		v.users[id]--
+		if v.users[id] == 0 {
+			delete(v.users, id)
+		}
+	} else {
+		logrus.Warnf("%s volume '%s' is being unmounted more times than it has been mounted by %s", v.root.Name(), v.name, id)
+	}
+	defer v.save()
+	if len(v.users) > 0 || v.device == "" {
+		return nil
+	}
+	return v.detach()
+}
+
+// detach unmounts the filesystem of the volume from the host, closes the
+// LUKS mapping, if any, and detaches the storage of the volume. Callers must
+// hold v.m.
+func (v *Volume) detach() error {
+	mountpoint := v.Path()
+	ctx, cancel := v.root.commandContext()
+	defer cancel()
+	if err := unmountFilesystem(ctx, mountpoint); err != nil {
+		logrus.Errorf("Failed to unmount %s volume '%s' from %s: %v", v.root.Name(), v.name, mountpoint, err)
+		return err
+	}
+	os.Remove(mountpoint)
+
+	d := v.blockDevice()
+	if err := d.Teardown(); err != nil {
+		return err
+	}
+	v.luksDevice = ""
+
+	if err := v.root.backend.Detach(ctx, v.name, v.device, v.backendOpts); err != nil {
+		logrus.Errorf("Failed to detach %s volume '%s' from %s: %v", v.root.Name(), v.name, v.device, err)
+		return err
+	}
+	logrus.Infof("Detached %s volume '%s' from %s", v.root.Name(), v.name, v.device)
+	v.device = ""
+	return nil
+}
+
+// Status returns the devices the volume is attached to, and its size,
+// filesystem, usage and users, shown by `docker volume inspect`.
+func (v *Volume) Status() map[string]interface{} {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	users := make(map[string]int)
+	refCount := 0
+	for id, count := range v.users {
+		users[id] = count
+		refCount += count
+	}
+	status := map[string]interface{}{
+		"Size":       v.size,
+		"Encrypted":  v.opts.Encrypted,
+		"Filesystem": v.opts.FilesystemType(),
+		"RefCount":   refCount,
+		"Users":      users,
+	}
+	for key, value := range v.backendOpts {
+		status[key] = value
+	}
+	if reporter, ok := v.root.backend.(StatusReporter); ok {
+		ctx, cancel := withTimeout(statusTimeout, statusTimeout)
+		for key, value := range reporter.Status(ctx, v.name, v.backendOpts) {
+			status[key] = value
+		}
+		cancel()
+	}
+	if v.device == "" {
+		return status
+	}
+
+	d := v.blockDevice()
+	status["Device"] = v.device
+	if v.luksDevice != "" {
+		status["LuksDevice"] = v.luksDevice
+	}
+	if fsType, err := d.FilesystemType(); err == nil && fsType != "" {
+		status["Filesystem"] = fsType
+	}
+	if l, ok := FindMount(d.FilesystemDevice()); ok {
+		if usage, err := volume.FilesystemUsage(l.Path(), statusTimeout); err == nil {
+			status["Used"] = usage.Used
+			status["Available"] = usage.Available
+		} else {
+			logrus.Debugf("Unable to get the usage of %s volume '%s': %v", v.root.Name(), v.name, err)
+		}
+	}
+	return status
+}
+
+// Resize changes the size of the storage of the volume to size bytes, if
+// the backend supports it. An attached volume is grown live, with its LUKS
+// mapping and its filesystem if it is mounted. Shrinking destroys the data
+// past the new size, so it is only done if force is set, and never while
+// the volume is attached.
+func (v *Volume) Resize(size int64, force bool) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	resizer, ok := v.root.backend.(Resizer)
+	if !ok {
+		return validationError{fmt.Errorf("%s volumes can't be resized", v.root.Name())}
+	}
+	if size < units.MiB {
+		return validationError{fmt.Errorf("invalid size %d: must be at least 1M", size)}
+	}
+	shrink := size < v.size
+	switch {
+	case size == v.size:
+		return nil
+	case shrink && !force:
+		return validationError{fmt.Errorf("shrinking %s volume '%s' from %s to %s destroys data past the new size, force the resize to shrink it", v.root.Name(), v.name, units.BytesSize(float64(v.size)), units.BytesSize(float64(size)))}
+	case shrink && v.device != "":
+		return validationError{fmt.Errorf("%s volume '%s' can't be shrunk while it is in use", v.root.Name(), v.name)}
+	}
+
+	ctx, cancel := v.root.commandContext()
+	err := resizer.Resize(ctx, v.name, size, v.device, v.backendOpts)
+	cancel()
+	if err != nil {
+		logrus.Errorf("Failed to resize %s volume '%s': %v", v.root.Name(), v.name, err)
+		return err
+	}
+	logrus.Infof("Resized %s volume '%s' from %s to %s", v.root.Name(), v.name, units.BytesSize(float64(v.size)), units.BytesSize(float64(size)))
+	v.size = size
+	v.save()
+
+	if v.device == "" || shrink {
+		return nil
+	}
+	return v.blockDevice().Grow()
+}
+
+// Usage returns the usage of the filesystem of the volume while it is
+// mounted. Otherwise the backend, if it can tell, reports the space used by
+// the storage of the volume; inodes are not known then.
+func (v *Volume) Usage() (*volume.Usage, error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if v.device != "" {
+		if l, ok := FindMount(v.blockDevice().FilesystemDevice()); ok {
+			u, err := volume.FilesystemUsage(l.Path(), statusTimeout)
+			if err != nil {
+				return nil, err
+			}
+			u.Reclaimable = v.root.reclaimsSpace()
+			return u, nil
+		}
+	}
+	reporter, ok := v.root.backend.(DiskUsageReporter)
+	if !ok {
+		return nil, volume.ErrNotSupported
+	}
+	ctx, cancel := v.root.commandContext()
+	defer cancel()
+	provisioned, used, err := reporter.DiskUsage(ctx, v.name, v.backendOpts)
+	if err != nil {
+		return nil, err
+	}
+	if provisioned == 0 {
+		// the storage is created when the volume is first mounted
+		return &volume.Usage{Size: v.size}, nil
+	}
+	return &volume.Usage{Size: provisioned, Used: used, Available: provisioned - used, Reclaimable: v.root.reclaimsSpace()}, nil
+}
+
+// reclaimsSpace returns whether removing volumes frees the space their
+// storage uses.
+func (r *Root) reclaimsSpace() bool {
+	reclaimer, ok := r.backend.(SpaceReclaimer)
+	return ok && reclaimer.ReclaimsSpace()
+}
+
+// Unlock breaks the lock held on the storage of the volume, if the backend
+// locks it. Locks of hosts that still appear to use the volume are only
+// broken if force is set.
+func (v *Volume) Unlock(force bool) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	unlocker, ok := v.root.backend.(Unlocker)
+	if !ok {
+		return volume.ErrNotSupported
+	}
+	ctx, cancel := v.root.commandContext()
+	defer cancel()
+	return unlocker.Unlock(ctx, v.name, v.device, force, v.backendOpts)
+}
+
+// RotateKey replaces the LUKS passphrase of an encrypted volume with a newly
+// generated one. The volume is attached for the rotation if needed.
+func (v *Volume) RotateKey() error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if v.device == "" {
+		ctx, cancel := v.root.commandContext()
+		device, err := v.root.backend.Attach(ctx, v.name, v.backendOpts)
+		cancel()
+		if err != nil {
+			return err
+		}
+		v.device = device
+		defer func() {
+			ctx, cancel := v.root.commandContext()
+			defer cancel()
+			if err := v.root.backend.Detach(ctx, v.name, device, v.backendOpts); err != nil {
+				logrus.Errorf("Failed to detach %s volume '%s' from %s: %v", v.root.Name(), v.name, device, err)
+			}
+			v.device = ""
+		}()
+	}
+
+	ctx, cancel := v.root.commandContext()
+	defer cancel()
+	fsType, err := FilesystemType(ctx, v.device)
+	if err != nil {
+		return err
+	}
+	if fsType != CryptoLuksFsType {
+		return validationError{fmt.Errorf("%s volume '%s' is not encrypted", v.root.Name(), v.name)}
+	}
+	return RotateKey(ctx, v.blockDevice())
+}
+
+// RotateKey replaces the LUKS passphrase of the encrypted device d with a
+// newly generated one, stored with the key provider of d. The old
+// passphrase is restored if the new one can't be stored.
+func RotateKey(ctx context.Context, d *Device) error {
+	oldKey, err := d.Key()
+	if err != nil {
+		return err
+	}
+	newKey, err := GenerateKey()
+	if err != nil {
+		return err
+	}
+	if err := LuksChangeKey(ctx, d.Path, oldKey, newKey); err != nil {
+		return err
+	}
+	if err := d.Keys.SetKey(d.Name, newKey); err != nil {
+		logrus.Errorf("Failed to store new LUKS key of volume '%s', restoring the old key: %v", d.Name, err)
+		if restoreErr := LuksChangeKey(ctx, d.Path, newKey, oldKey); restoreErr != nil {
+			logrus.Errorf("Failed to restore the old LUKS key of volume '%s': %v", d.Name, restoreErr)
+		}
+		return err
+	}
+	logrus.Infof("Rotated LUKS key of volume '%s'", d.Name)
+	return nil
+}
+
+// volumeMetadata is the on-disk representation of a Volume, written whenever
+// its state changes so that a restarted daemon picks volumes, and the
+// devices they are attached to, up where it left off.
+type volumeMetadata struct {
+	Name           string
+	Size           int64
+	Options        *Options          `json:",omitempty"`
+	BackendOptions map[string]string `json:",omitempty"`
+	Device         string            `json:",omitempty"`
+	LuksDevice     string            `json:",omitempty"`
+	Users          map[string]int    `json:",omitempty"`
+}
+
+// metadataPath returns the path of the metadata file for the named volume.
+func (r *Root) metadataPath(name string) string {
+	return filepath.Join(r.path, url.QueryEscape(name)+metadataFileSuffix)
+}
+
+// save persists the state of the volume. Callers must hold v.m.
+func (v *Volume) save() error {
+	b, err := json.Marshal(&volumeMetadata{
+		Name:           v.name,
+		Size:           v.size,
+		Options:        v.opts,
+		BackendOptions: v.backendOpts,
+		Device:         v.device,
+		LuksDevice:     v.luksDevice,
+		Users:          v.users,
+	})
+	if err != nil {
+		return err
+	}
+	if err := ioutils.AtomicWriteFile(v.root.metadataPath(v.name), b, metadataPermissions); err != nil {
+		logrus.Errorf("Failed to save metadata of %s volume '%s': %v", v.root.Name(), v.name, err)
+		return err
+	}
+	return nil
+}
+
+// reconcile compares the loaded volumes with the storage the backend, if it
+// can tell, finds attached to the host. Volumes that are no longer attached
+// are marked detached, attached volumes that nobody uses any more are
+// detached, and storage attached for volumes the driver has no record of is
+// adopted as new volumes, so that it is detached once their containers stop.
+func (r *Root) reconcile() {
+	reconciler, ok := r.backend.(Reconciler)
+	if !ok {
+		return
+	}
+	known := make(map[string]map[string]string)
+	for name, v := range r.volumes {
+		known[name] = v.backendOpts
+	}
+	attached, err := reconciler.Attached(known)
+	if err != nil {
+		logrus.Warnf("Unable to list the attached %s volumes, not reconciling them: %v", r.Name(), err)
+		return
+	}
+
+	for name, v := range r.volumes {
+		device, isAttached := attached[name]
+		delete(attached, name)
+		if !isAttached {
+			if v.device != "" {
+				logrus.Infof("%s volume '%s' is no longer attached to %s", r.Name(), name, v.device)
+			}
+			v.device, v.luksDevice = "", ""
+			v.users = make(map[string]int)
+			v.save()
+			continue
+		}
+
+		v.device = device
+		v.findLuksDevice()
+		if _, mounted := FindMount(v.blockDevice().FilesystemDevice()); len(v.users) > 0 || mounted {
+			logrus.Infof("Adopting attachment of %s volume '%s' to %s", r.Name(), name, device)
+		} else {
+			logrus.Infof("Detaching stale attachment of %s volume '%s' to %s", r.Name(), name, device)
+			if err := v.detach(); err != nil {
+				logrus.Warnf("Failed to detach stale attachment of %s volume '%s': %v", r.Name(), name, err)
+			}
+		}
+		v.save()
+	}
+
+	for name, device := range attached {
+		logrus.Infof("Adopting unknown attachment of %s volume '%s' to %s", r.Name(), name, device)
+		v := &Volume{
+			root:        r,
+			name:        name,
+			size:        r.defaultSize(),
+			opts:        &Options{},
+			backendOpts: make(map[string]string),
+			device:      device,
+			users:       make(map[string]int),
+		}
+		v.findLuksDevice()
+		r.volumes[name] = v
+		v.save()
+	}
+}
+
+// findLuksDevice sets the LUKS mapping of the volume if it is open but
+// unknown, such as after the daemon restarted. Callers must hold v.m, or
+// otherwise have exclusive access to v.
+func (v *Volume) findLuksDevice() {
+	if v.luksDevice != "" {
+		if _, err := os.Stat(v.luksDevice); err == nil {
+			return
+		}
+	}
+	v.luksDevice = ""
+	mapperPath := filepath.Join(LuksDevMapperPath, v.blockDevice().MapperName)
+	if _, err := os.Stat(mapperPath); err == nil {
+		v.luksDevice = mapperPath
+	}
+}
+
+// load reads all the volume metadata files found in the driver directory.
+func (r *Root) load() error {
+	files, err := ioutil.ReadDir(r.path)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), metadataFileSuffix) {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(r.path, f.Name()))
+		if err != nil {
+			return err
+		}
+		var meta volumeMetadata
+		if err := json.Unmarshal(b, &meta); err != nil {
+			logrus.Errorf("Ignoring invalid %s volume metadata file %s: %v", r.Name(), f.Name(), err)
+			continue
+		}
+		if meta.Options == nil {
+			meta.Options = &Options{}
+		}
+		if meta.BackendOptions == nil {
+			meta.BackendOptions = make(map[string]string)
+		}
+		if meta.Users == nil {
+			meta.Users = make(map[string]int)
+		}
+		if meta.Device != "" {
+			if _, err := os.Stat(meta.Device); err != nil {
+				// the host was restarted, or the device detached behind
+				// the back of the daemon
+				logrus.Infof("%s volume '%s' is no longer attached to %s", r.Name(), meta.Name, meta.Device)
+				meta.Device, meta.LuksDevice = "", ""
+				meta.Users = make(map[string]int)
+			}
+		}
+		r.volumes[meta.Name] = &Volume{
+			root:        r,
+			name:        meta.Name,
+			size:        meta.Size,
+			opts:        meta.Options,
+			backendOpts: meta.BackendOptions,
+			device:      meta.Device,
+			luksDevice:  meta.LuksDevice,
+			users:       meta.Users,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/volume"
+	"golang.org/x/net/context"
+)
+
+// fakeBackend records the storage it is asked to attach and detach, and
+// accepts the `tier` option.
+type fakeBackend struct {
+	detached []string
+}
+
+func (b *fakeBackend) Name() string {
+	return "fake"
+}
+
+func (b *fakeBackend) IsOption(key string) bool {
+	return key == "tier"
+}
+
+func (b *fakeBackend) ValidateOptions(name string, opts map[string]string) error {
+	if tier, ok := opts["tier"]; ok && tier != "ssd" && tier != "hdd" {
+		return validationError{os.ErrInvalid}
+	}
+	return nil
+}
+
+func (b *fakeBackend) Create(ctx context.Context, name string, size int64, opts map[string]string) error {
+	return nil
+}
+
+func (b *fakeBackend) Attach(ctx context.Context, name string, opts map[string]string) (string, error) {
+	return "/dev/fake-" + name, nil
+}
+
+func (b *fakeBackend) Detach(ctx context.Context, name, device string, opts map[string]string) error {
+	b.detached = append(b.detached, name)
+	return nil
+}
+
+func (b *fakeBackend) Remove(ctx context.Context, name string, opts map[string]string) error {
+	return nil
+}
+
+// fakeSnapshotBackend also takes snapshots, and reports attached storage.
+type fakeSnapshotBackend struct {
+	fakeBackend
+	attached   map[string]string
+	clones     []string
+	rolledBack []string
+}
+
+func (b *fakeSnapshotBackend) Attached(volumes map[string]map[string]string) (map[string]string, error) {
+	return b.attached, nil
+}
+
+func (b *fakeSnapshotBackend) CreateSnapshot(ctx context.Context, name, snapshot string, opts map[string]string) (volume.Snapshot, error) {
+	return volume.Snapshot{Name: snapshot}, nil
+}
+
+func (b *fakeSnapshotBackend) Snapshots(ctx context.Context, name string, opts map[string]string) ([]volume.Snapshot, error) {
+	return nil, nil
+}
+
+func (b *fakeSnapshotBackend) RemoveSnapshot(ctx context.Context, name, snapshot string, opts map[string]string) error {
+	return nil
+}
+
+func (b *fakeSnapshotBackend) RollbackSnapshot(ctx context.Context, name, snapshot string, opts map[string]string) error {
+	b.rolledBack = append(b.rolledBack, name+"@"+snapshot)
+	return nil
+}
+
+func (b *fakeSnapshotBackend) Clone(ctx context.Context, source, snapshot string, sourceOpts map[string]string, name string, opts map[string]string) error {
+	b.clones = append(b.clones, source+"@"+snapshot+"->"+name+":"+opts["tier"])
+	return nil
+}
+
+// fakeTierBackend stores the volumes of each tier on the same storage.
+type fakeTierBackend struct {
+	fakeBackend
+}
+
+func (b *fakeTierBackend) StorageID(opts map[string]string) string {
+	return "tier " + opts["tier"]
+}
+
+// fakeUsageBackend reports the usage of volumes.
+type fakeUsageBackend struct {
+	fakeBackend
+}
+
+func (b *fakeUsageBackend) DiskUsage(ctx context.Context, name string, opts map[string]string) (int64, int64, error) {
+	return 10 << 20, 4 << 20, nil
+}
+
+// fakeReclaimingBackend also frees the space of removed volumes.
+type fakeReclaimingBackend struct {
+	fakeUsageBackend
+}
+
+func (b *fakeReclaimingBackend) ReclaimsSpace() bool {
+	return true
+}
+
+// newTestRoot returns a driver using backend, rooted in a new temporary
+// directory that the caller removes.
+func newTestRoot(t *testing.T, backend Backend) (*Root, string) {
+	dir, err := ioutil.TempDir("", "block-driver-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(dir, backend, nil)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return r, dir
+}
+
+func TestCreateFromSnapshot(t *testing.T) {
+	backend := &fakeSnapshotBackend{}
+	r, dir := newTestRoot(t, backend)
+	defer os.RemoveAll(dir)
+
+	src, err := r.Create("source", map[string]string{"size": "5G", "fs": "xfs", "tier": "ssd", "encrypted": "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.keys.SetKey("source", []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range []map[string]string{
+		{"fs": "ext4"},
+		{"size": "10G"},
+		{"tier": "tape"},
+	} {
+		if _, err := r.CreateFromSnapshot("clone", src, "snap", opts); err == nil {
+			t.Fatalf("expected an error for %v", opts)
+		} else if _, ok := err.(validationError); !ok {
+			t.Fatalf("expected a validation error for %v, got %v", opts, err)
+		}
+	}
+	if _, err := r.CreateFromSnapshot("source", src, "snap", nil); err == nil {
+		t.Fatal("expected an error creating a volume that exists")
+	}
+	if len(backend.clones) != 0 {
+		t.Fatalf("expected no clones, got %v", backend.clones)
+	}
+
+	v, err := r.CreateFromSnapshot("clone", src, "snap", map[string]string{"tier": "hdd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"source@snap->clone:hdd"}; !reflect.DeepEqual(backend.clones, expected) {
+		t.Fatalf("expected clones %v, got %v", expected, backend.clones)
+	}
+	status := v.Status()
+	if status["Size"] != int64(5<<30) || status["Filesystem"] != "xfs" || status["Encrypted"] != true || status["tier"] != "hdd" {
+		t.Fatalf("expected the size and options of the source, got %v", status)
+	}
+	if key, err := r.keys.GetKey("clone"); err != nil || string(key) != "secret" {
+		t.Fatalf("expected the key of the source to be copied, got %q (%v)", key, err)
+	}
+}
+
+func TestRollbackSnapshotInUse(t *testing.T) {
+	backend := &fakeSnapshotBackend{}
+	r, dir := newTestRoot(t, backend)
+	defer os.RemoveAll(dir)
+
+	vol, err := r.Create("testing", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := vol.(*Volume)
+	v.device = "/dev/fake-testing"
+	if err := v.RollbackSnapshot("snap"); err == nil {
+		t.Fatal("expected an error rolling back a volume in use")
+	} else if _, ok := err.(validationError); !ok {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	v.device = ""
+	if err := v.RollbackSnapshot("snap"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"testing@snap"}; !reflect.DeepEqual(backend.rolledBack, expected) {
+		t.Fatalf("expected rollbacks %v, got %v", expected, backend.rolledBack)
+	}
+}
+
+func TestUnsupportedCapabilities(t *testing.T) {
+	r, dir := newTestRoot(t, &fakeBackend{})
+	defer os.RemoveAll(dir)
+
+	vol, err := r.Create("testing", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := vol.(*Volume)
+	if _, err := v.Snapshots(); err != volume.ErrNotSupported {
+		t.Fatalf("expected snapshots not to be supported, got %v", err)
+	}
+	if _, err := r.CreateFromSnapshot("clone", v, "snap", nil); err != volume.ErrNotSupported {
+		t.Fatalf("expected clones not to be supported, got %v", err)
+	}
+	if err := v.Unlock(false); err != volume.ErrNotSupported {
+		t.Fatalf("expected unlocking not to be supported, got %v", err)
+	}
+	if _, err := v.Usage(); err != volume.ErrNotSupported {
+		t.Fatalf("expected the usage not to be known, got %v", err)
+	}
+}
+
+func TestCreateStorageInUse(t *testing.T) {
+	r, dir := newTestRoot(t, &fakeTierBackend{})
+	defer os.RemoveAll(dir)
+
+	if _, err := r.Create("fast", map[string]string{"tier": "ssd"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Create("slow", map[string]string{"tier": "hdd"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Create("other", map[string]string{"tier": "ssd"}); err == nil {
+		t.Fatal("expected an error creating a volume on the storage of another")
+	} else if _, ok := err.(validationError); !ok {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if _, err := r.Get("other"); err == nil {
+		t.Fatal("expected the volume not to be created")
+	}
+	// creating a volume that exists returns it
+	if _, err := r.Create("fast", map[string]string{"tier": "ssd"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUsageReclaimable(t *testing.T) {
+	for _, c := range []struct {
+		backend     Backend
+		reclaimable bool
+	}{
+		{&fakeUsageBackend{}, false},
+		{&fakeReclaimingBackend{}, true},
+	} {
+		r, dir := newTestRoot(t, c.backend)
+		defer os.RemoveAll(dir)
+
+		vol, err := r.Create("testing", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := vol.(*Volume).Usage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Size != 10<<20 || u.Used != 4<<20 || u.Reclaimable != c.reclaimable {
+			t.Fatalf("expected 4M used of 10M, reclaimable %v, got %+v", c.reclaimable, u)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	backend := &fakeSnapshotBackend{}
+	r, dir := newTestRoot(t, backend)
+	defer os.RemoveAll(dir)
+
+	// the daemon forgets devices that no longer exist when it loads the
+	// volumes, so the devices of the test are files
+	devices := make(map[string]string)
+	for _, name := range []string{"used", "stale", "gone", "unknown"} {
+		devices[name] = filepath.Join(dir, "dev-"+name)
+		if err := ioutil.WriteFile(devices[name], nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"used", "stale", "gone"} {
+		vol, err := r.Create(name, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := vol.(*Volume)
+		v.device = devices[name]
+		if name != "stale" {
+			v.users["abc"] = 1
+		}
+		if err := v.save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a new driver finds what is still attached
+	backend.attached = map[string]string{
+		"used":    devices["used"],
+		"stale":   devices["stale"],
+		"unknown": devices["unknown"],
+	}
+	r, err := New(dir, backend, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attached := make(map[string]interface{})
+	for _, name := range []string{"used", "stale", "gone", "unknown"} {
+		v, err := r.Get(name)
+		if err != nil {
+			t.Fatalf("expected volume %s to exist: %v", name, err)
+		}
+		attached[name] = v.Status()["Device"]
+	}
+	expected := map[string]interface{}{
+		"used":    devices["used"],
+		"stale":   nil,
+		"gone":    nil,
+		"unknown": devices["unknown"],
+	}
+	if !reflect.DeepEqual(attached, expected) {
+		t.Fatalf("expected devices %v, got %v", expected, attached)
+	}
+	if !reflect.DeepEqual(backend.detached, []string{"stale"}) {
+		t.Fatalf("expected the stale attachment to be detached, got %v", backend.detached)
+	}
+}
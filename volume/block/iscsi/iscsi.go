@@ -0,0 +1,307 @@
+// Package iscsi implements a block volume backend attaching volumes to LUNs
+// of iSCSI targets with open-iscsi. The LUNs are provisioned on the targets
+// beforehand: creating a volume does not allocate storage, and removing it
+// leaves the data on the LUN.
+package iscsi
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/ctxexec"
+	"golang.org/x/net/context"
+)
+
+const (
+	// Name is the name of the driver using the backend.
+	Name = "iscsi"
+
+	// defaultPort is the port of portals given without one
+	defaultPort = "3260"
+	// errSessionExists is the exit status of `iscsiadm --login` when the
+	// session is already logged in
+	errSessionExists = 15
+)
+
+// sysfsSessions is where the kernel lists the iSCSI sessions
+var sysfsSessions = "/sys/class/iscsi_session"
+
+var validOpts = map[string]bool{
+	"portal": true, // address of the target portal, host[:port]
+	"target": true, // IQN of the target
+	"lun":    true, // LUN of the volume, 0 if not set
+}
+
+type validationError struct {
+	error
+}
+
+func (validationError) IsValidationError() bool {
+	return true
+}
+
+// Backend logs in to targets when their LUNs are attached, and out when
+// none of them are attached any more.
+type Backend struct {
+	m sync.Mutex
+	// sessions counts the attached volumes per target session
+	sessions map[string]int
+}
+
+// New returns the iSCSI backend.
+func New() *Backend {
+	return &Backend{sessions: make(map[string]int)}
+}
+
+// Name returns the name of the driver.
+func (b *Backend) Name() string {
+	return Name
+}
+
+// IsOption returns whether key is the `portal`, `target` or `lun` option.
+func (b *Backend) IsOption(key string) bool {
+	return validOpts[key]
+}
+
+// ValidateOptions checks that the LUN of the volume is given.
+func (b *Backend) ValidateOptions(name string, opts map[string]string) error {
+	if opts["portal"] == "" || opts["target"] == "" {
+		return validationError{fmt.Errorf("iSCSI volume '%s' needs the portal and target options", name)}
+	}
+	if !strings.HasPrefix(opts["target"], "iqn.") && !strings.HasPrefix(opts["target"], "eui.") {
+		return validationError{fmt.Errorf("invalid iSCSI target %q, expected an iqn. or eui. name", opts["target"])}
+	}
+	if lun, ok := opts["lun"]; ok {
+		if n, err := strconv.Atoi(lun); err != nil || n < 0 {
+			return validationError{fmt.Errorf("invalid LUN %q", lun)}
+		}
+	}
+	return nil
+}
+
+// parseLUN returns the portal, with its port, the target and the LUN of a
+// volume.
+func parseLUN(opts map[string]string) (string, string, string) {
+	portal := opts["portal"]
+	if !strings.Contains(portal, ":") || strings.HasSuffix(portal, "]") {
+		portal += ":" + defaultPort
+	}
+	lun, _ := strconv.Atoi(opts["lun"])
+	return portal, opts["target"], strconv.Itoa(lun)
+}
+
+// StorageID returns the LUN a volume is attached to, so that two volumes
+// are not attached to the same.
+func (b *Backend) StorageID(opts map[string]string) string {
+	portal, target, lun := parseLUN(opts)
+	return fmt.Sprintf("LUN %s of iSCSI target %s at %s", lun, strings.ToLower(target), strings.ToLower(portal))
+}
+
+// resolvePortal returns the addresses of the host of a portal, one of which
+// the sessions logged in to the portal are connected to, and its port.
+func resolvePortal(portal string) ([]net.IP, string, error) {
+	host, port, err := net.SplitHostPort(portal)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid iSCSI portal %q: %v", portal, err)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, port, nil
+	}
+	addrs, err := net.LookupHost(host)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to resolve iSCSI portal %s: %v", host, err)
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips, port, nil
+}
+
+// findDevice returns the disk of a LUN of a session logged in to a target
+// at one of the addresses of its portal, or an empty path if the kernel
+// hasn't found it yet. Disks are looked up in sysfs rather than by their
+// udev link, which is named after the address of the session instead of
+// the portal the volume was created with.
+func findDevice(addrs []net.IP, port, target, lun string) (string, error) {
+	sessions, err := filepath.Glob(filepath.Join(sysfsSessions, "session*"))
+	if err != nil {
+		return "", err
+	}
+	for _, session := range sessions {
+		if name, err := readAttr(session, "targetname"); err != nil || name != target {
+			continue
+		}
+		if !connectedTo(session, addrs, port) {
+			continue
+		}
+		disks, err := filepath.Glob(filepath.Join(session, "device", "target*", "*:"+lun, "block", "*"))
+		if err != nil {
+			return "", err
+		}
+		if len(disks) > 0 {
+			return filepath.Join("/dev", filepath.Base(disks[0])), nil
+		}
+	}
+	return "", nil
+}
+
+// connectedTo returns whether a session is connected to one of the given
+// addresses, at port.
+func connectedTo(session string, addrs []net.IP, port string) bool {
+	conns, err := filepath.Glob(filepath.Join(session, "device", "connection*", "iscsi_connection", "connection*"))
+	if err != nil {
+		return false
+	}
+	for _, conn := range conns {
+		if p, err := readAttr(conn, "persistent_port"); err != nil || p != port {
+			continue
+		}
+		address, err := readAttr(conn, "persistent_address")
+		if err != nil {
+			continue
+		}
+		ip := net.ParseIP(address)
+		for _, addr := range addrs {
+			if ip != nil && ip.Equal(addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readAttr returns the value of a sysfs attribute.
+func readAttr(dir, name string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// Create does nothing, LUNs are provisioned on the targets.
+func (b *Backend) Create(ctx context.Context, name string, size int64, opts map[string]string) error {
+	return nil
+}
+
+// Attach logs in to the target of the volume, if needed, and returns the
+// disk of its LUN once the kernel has found it.
+func (b *Backend) Attach(ctx context.Context, name string, opts map[string]string) (string, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	portal, target, lun := parseLUN(opts)
+	session := portal + "," + target
+	if b.sessions[session] == 0 {
+		if err := iscsiadm(ctx, "--mode", "node", "--targetname", target, "--portal", portal, "--op", "new"); err != nil {
+			return "", err
+		}
+		err := iscsiadm(ctx, "--mode", "node", "--targetname", target, "--portal", portal, "--login")
+		if e, ok := err.(*execError); ok && e.status == errSessionExists {
+			err = nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	addrs, port, err := resolvePortal(portal)
+	if err != nil {
+		return "", err
+	}
+	device, err := waitForDevice(ctx, addrs, port, target, lun)
+	if err != nil {
+		return "", err
+	}
+	b.sessions[session]++
+	return device, nil
+}
+
+// waitForDevice waits for the kernel to find the disk of a LUN, and for
+// udev to create its device, and returns the device.
+func waitForDevice(ctx context.Context, addrs []net.IP, port, target, lun string) (string, error) {
+	for {
+		device, err := findDevice(addrs, port, target, lun)
+		if err != nil {
+			return "", err
+		}
+		if device != "" {
+			if _, err := os.Stat(device); err == nil {
+				return device, nil
+			} else if !os.IsNotExist(err) {
+				return "", err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("timed out waiting for the disk of LUN %s of iSCSI target %s to appear", lun, target)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Detach logs out of the target of the volume once none of its LUNs are
+// attached.
+func (b *Backend) Detach(ctx context.Context, name, device string, opts map[string]string) error {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	portal, target, _ := parseLUN(opts)
+	session := portal + "," + target
+	if b.sessions[session] == 0 {
+		// sessions logged in to before the daemon restarted may still be
+		// used by other volumes, they are left alone
+		logrus.Debugf("Not logging out of iSCSI target %s at %s, it was logged in to by a previous daemon", target, portal)
+		return nil
+	}
+	b.sessions[session]--
+	if b.sessions[session] > 0 {
+		return nil
+	}
+	delete(b.sessions, session)
+	return iscsiadm(ctx, "--mode", "node", "--targetname", target, "--portal", portal, "--logout")
+}
+
+// Remove does nothing, the data of the volume is left on its LUN.
+func (b *Backend) Remove(ctx context.Context, name string, opts map[string]string) error {
+	return nil
+}
+
+// execError is the error of an iscsiadm command that failed.
+type execError struct {
+	args   []string
+	status int
+	stderr string
+}
+
+func (e *execError) Error() string {
+	return fmt.Sprintf("iscsiadm %s failed with exit status %d - %s", strings.Join(e.args, " "), e.status, e.stderr)
+}
+
+func iscsiadm(ctx context.Context, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("iscsiadm", args...)
+	cmd.Stderr = &stderr
+	err := ctxexec.Run(ctx, cmd)
+	if exitError, ok := err.(*exec.ExitError); ok {
+		status := -1
+		if ws, ok := exitError.Sys().(syscall.WaitStatus); ok {
+			status = ws.ExitStatus()
+		}
+		return &execError{args: args, status: status, stderr: strings.TrimSpace(stderr.String())}
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package iscsi
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateOptions(t *testing.T) {
+	b := New()
+	valid := map[string]string{"portal": "10.0.0.5", "target": "iqn.2016-04.com.example:storage", "lun": "3"}
+	if err := b.ValidateOptions("foo", valid); err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []map[string]string{
+		{"target": "iqn.2016-04.com.example:storage"},
+		{"portal": "10.0.0.5"},
+		{"portal": "10.0.0.5", "target": "storage"},
+		{"portal": "10.0.0.5", "target": "iqn.2016-04.com.example:storage", "lun": "-1"},
+	} {
+		if err := b.ValidateOptions("foo", opts); err == nil {
+			t.Fatalf("expected an error for %v", opts)
+		} else if _, ok := err.(validationError); !ok {
+			t.Fatalf("expected a validation error for %v, got %v", opts, err)
+		}
+	}
+}
+
+func TestStorageID(t *testing.T) {
+	b := New()
+	for _, opts := range []map[string]string{
+		{"portal": "Storage.example.com:3260", "target": "iqn.2016-04.com.example:storage", "lun": "0"},
+		{"portal": "storage.example.com", "target": "iqn.2016-04.com.example:storage"},
+	} {
+		if id := b.StorageID(opts); id != "LUN 0 of iSCSI target iqn.2016-04.com.example:storage at storage.example.com:3260" {
+			t.Fatalf("unexpected storage of %v: %s", opts, id)
+		}
+	}
+	if b.StorageID(map[string]string{"portal": "storage.example.com", "target": "iqn.2016-04.com.example:storage", "lun": "1"}) == b.StorageID(map[string]string{"portal": "storage.example.com", "target": "iqn.2016-04.com.example:storage"}) {
+		t.Fatal("expected LUNs 0 and 1 to be different storage")
+	}
+}
+
+// writeSession creates the sysfs entries of a session logged in to target
+// at address:port, with the disk of a LUN.
+func writeSession(t *testing.T, root, session, target, address, port, lun, disk string) {
+	dir := filepath.Join(root, session)
+	conn := filepath.Join(dir, "device", "connection1:0", "iscsi_connection", "connection1:0")
+	block := filepath.Join(dir, "device", "target2:0:0", "2:0:0:"+lun, "block", disk)
+	for _, d := range []string{conn, block} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, value := range map[string]string{
+		filepath.Join(dir, "targetname"):          target + "\n",
+		filepath.Join(conn, "persistent_address"): address + "\n",
+		filepath.Join(conn, "persistent_port"):    port + "\n",
+	} {
+		if err := ioutil.WriteFile(path, []byte(value), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindDevice(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "iscsi-sysfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	defer func(dir string) { sysfsSessions = dir }(sysfsSessions)
+	sysfsSessions = tmp
+
+	target := "iqn.2016-04.com.example:storage"
+	writeSession(t, tmp, "session1", target, "10.0.0.5", "3260", "11", "sdb")
+	writeSession(t, tmp, "session2", target, "10.0.0.6", "3260", "1", "sdc")
+	writeSession(t, tmp, "session3", target, "fd00::5", "3260", "1", "sdd")
+	writeSession(t, tmp, "session4", "iqn.2016-04.com.example:other", "10.0.0.5", "3260", "1", "sde")
+
+	cases := []struct {
+		addrs    []string
+		port     string
+		lun      string
+		expected string
+	}{
+		// a portal resolving to several addresses
+		{[]string{"10.0.0.5", "10.0.0.6"}, "3260", "1", "/dev/sdc"},
+		{[]string{"10.0.0.5"}, "3260", "11", "/dev/sdb"},
+		{[]string{"fd00:0::5"}, "3260", "1", "/dev/sdd"},
+		{[]string{"10.0.0.5"}, "3260", "1", ""},
+		{[]string{"10.0.0.6"}, "3261", "1", ""},
+	}
+	for _, c := range cases {
+		var addrs []net.IP
+		for _, a := range c.addrs {
+			addrs = append(addrs, net.ParseIP(a))
+		}
+		device, err := findDevice(addrs, c.port, target, c.lun)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if device != c.expected {
+			t.Fatalf("expected %q for LUN %s at %v:%s, got %q", c.expected, c.lun, c.addrs, c.port, device)
+		}
+	}
+}
+
+func TestResolvePortal(t *testing.T) {
+	addrs, port, err := resolvePortal("[fd00::5]:3261")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || !addrs[0].Equal(net.ParseIP("fd00::5")) || port != "3261" {
+		t.Fatalf("unexpected addresses %v and port %s", addrs, port)
+	}
+	addrs, port, err = resolvePortal("localhost:3260")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) == 0 || port != "3260" {
+		t.Fatalf("unexpected addresses %v and port %s", addrs, port)
+	}
+}
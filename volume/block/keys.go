@@ -0,0 +1,188 @@
+package block
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/pkg/ctxexec"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/plugins"
+)
+
+const (
+	// keyProviderPluginType is the type plugins providing LUKS keys implement
+	keyProviderPluginType = "LuksKeyProvider"
+	// luksKeyBytes is the number of random bytes in a generated LUKS key
+	luksKeyBytes = 32
+)
+
+// ErrKeyNotFound is returned by key providers that have no key for a volume.
+var ErrKeyNotFound = errors.New("no LUKS key found")
+
+// KeyProvider stores and retrieves the passphrases of encrypted volumes.
+// Keys are never removed: drivers that keep the storage of removed volumes
+// leave the data encrypted with the key in place.
+type KeyProvider interface {
+	// GetKey returns the passphrase of the named volume, or ErrKeyNotFound.
+	GetKey(name string) ([]byte, error)
+	// SetKey stores the passphrase of the named volume, replacing any
+	// existing one.
+	SetKey(name string, key []byte) error
+}
+
+// NewKeyProvider returns the key provider described by spec, which is one of
+// `file:<directory>`, `command:<path>` or `plugin:<name>`. An empty spec
+// selects the key file directory dir. Key commands are killed after timeout.
+func NewKeyProvider(spec, dir string, timeout time.Duration) (KeyProvider, error) {
+	if spec == "" {
+		spec = "file:" + dir
+	}
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid LUKS key provider %q, expected file:<directory>, command:<path> or plugin:<name>", spec)
+	}
+	switch parts[0] {
+	case "file":
+		if err := os.MkdirAll(parts[1], 0700); err != nil {
+			return nil, err
+		}
+		return &fileKeyProvider{dir: parts[1]}, nil
+	case "command":
+		return &commandKeyProvider{path: parts[1], timeout: timeout}, nil
+	case "plugin":
+		return &pluginKeyProvider{name: parts[1]}, nil
+	}
+	return nil, fmt.Errorf("unknown LUKS key provider %q", parts[0])
+}
+
+// GenerateKey returns a new random passphrase.
+func GenerateKey() ([]byte, error) {
+	b := make([]byte, luksKeyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	key := make([]byte, hex.EncodedLen(len(b)))
+	hex.Encode(key, b)
+	return key, nil
+}
+
+// fileKeyProvider keeps one key file per volume in a local directory.
+type fileKeyProvider struct {
+	dir string
+}
+
+func (p *fileKeyProvider) path(name string) string {
+	return filepath.Join(p.dir, url.QueryEscape(name)+".key")
+}
+
+func (p *fileKeyProvider) GetKey(name string) ([]byte, error) {
+	key, err := ioutil.ReadFile(p.path(name))
+	if os.IsNotExist(err) {
+		return nil, ErrKeyNotFound
+	}
+	return key, err
+}
+
+func (p *fileKeyProvider) SetKey(name string, key []byte) error {
+	return ioutils.AtomicWriteFile(p.path(name), key, 0600)
+}
+
+// commandKeyProvider runs an external command to manage keys. The command is
+// invoked as `<path> get|set <volume name>`; `get` prints the key on
+// stdout, `set` reads it from stdin. An exit status of 2 from `get` means the
+// command has no key for the volume.
+type commandKeyProvider struct {
+	path    string
+	timeout time.Duration
+}
+
+func (p *commandKeyProvider) run(action, name string, stdin []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(p.path, action, name)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	ctx, cancel := withTimeout(p.timeout, DefaultTimeout)
+	defer cancel()
+	if err := ctxexec.Run(ctx, cmd); err != nil {
+		if ctxexec.IsTimeout(err) {
+			return nil, err
+		}
+		if exitError, ok := err.(*exec.ExitError); ok && action == "get" && exitStatus(exitError) == 2 {
+			return nil, ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("LUKS key command %s %s failed: %v - %s", p.path, action, err, strings.TrimRight(stderr.String(), "\n"))
+	}
+	return stdout.Bytes(), nil
+}
+
+func (p *commandKeyProvider) GetKey(name string) ([]byte, error) {
+	return p.run("get", name, nil)
+}
+
+func (p *commandKeyProvider) SetKey(name string, key []byte) error {
+	_, err := p.run("set", name, key)
+	return err
+}
+
+// pluginKeyProvider delegates key management to a plugin implementing the
+// LuksKeyProvider protocol.
+type pluginKeyProvider struct {
+	name string
+}
+
+type keyProviderRequest struct {
+	Name string
+	Key  []byte `json:",omitempty"`
+}
+
+type keyProviderResponse struct {
+	Key      []byte
+	NotFound bool
+	Err      string
+}
+
+func (p *pluginKeyProvider) call(method, name string, key []byte) ([]byte, error) {
+	pl, err := plugins.Get(p.name, keyProviderPluginType)
+	if err != nil {
+		return nil, err
+	}
+	var resp keyProviderResponse
+	if err := pl.Client().Call(keyProviderPluginType+"."+method, keyProviderRequest{Name: name, Key: key}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.NotFound {
+		return nil, ErrKeyNotFound
+	}
+	if resp.Err != "" {
+		return nil, errors.New(resp.Err)
+	}
+	return resp.Key, nil
+}
+
+func (p *pluginKeyProvider) GetKey(name string) ([]byte, error) {
+	return p.call("GetKey", name, nil)
+}
+
+func (p *pluginKeyProvider) SetKey(name string, key []byte) error {
+	_, err := p.call("SetKey", name, key)
+	return err
+}
+
+func exitStatus(err *exec.ExitError) int {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok {
+		return status.ExitStatus()
+	}
+	return -1
+}
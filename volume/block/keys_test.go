@@ -0,0 +1,115 @@
+package block
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/ctxexec"
+)
+
+func TestNewKeyProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "block-keys-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keysDir := filepath.Join(dir, "keys")
+	p, err := NewKeyProvider("", keysDir, DefaultTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp, ok := p.(*fileKeyProvider); !ok || fp.dir != keysDir {
+		t.Fatalf("expected a file key provider in the default directory, got %+v", p)
+	}
+	if p, err := NewKeyProvider("command:/usr/local/bin/keys", keysDir, DefaultTimeout); err != nil {
+		t.Fatal(err)
+	} else if _, ok := p.(*commandKeyProvider); !ok {
+		t.Fatalf("expected a command key provider, got %T", p)
+	}
+	if p, err := NewKeyProvider("plugin:vault", keysDir, DefaultTimeout); err != nil {
+		t.Fatal(err)
+	} else if _, ok := p.(*pluginKeyProvider); !ok {
+		t.Fatalf("expected a plugin key provider, got %T", p)
+	}
+	for _, spec := range []string{"vault", "plugin:", "safe:/keys"} {
+		if _, err := NewKeyProvider(spec, keysDir, DefaultTimeout); err == nil {
+			t.Fatalf("expected an error for key provider %q", spec)
+		}
+	}
+}
+
+func TestFileKeyProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "block-keys-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := &fileKeyProvider{dir: dir}
+	if _, err := p.GetKey("ssd/testing"); err != ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 2*luksKeyBytes {
+		t.Fatalf("expected a %d characters key, got %q", 2*luksKeyBytes, key)
+	}
+	if err := p.SetKey("ssd/testing", key); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := p.GetKey("ssd/testing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, key) {
+		t.Fatalf("expected key %q, got %q", key, stored)
+	}
+}
+
+func TestCommandKeyProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "block-keys-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	script := filepath.Join(dir, "keys")
+	content := `#!/bin/sh
+key="` + dir + `/$(echo "$2" | tr / _)"
+case "$1" in
+get) [ -f "$key" ] || exit 2; cat "$key" ;;
+set) cat > "$key" ;;
+hang) sleep 10 ;;
+*) exit 1 ;;
+esac
+`
+	if err := ioutil.WriteFile(script, []byte(content), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &commandKeyProvider{path: script}
+	if _, err := p.GetKey("ssd/testing"); err != ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+	if err := p.SetKey("ssd/testing", []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+	key, err := p.GetKey("ssd/testing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(key) != "secret" {
+		t.Fatalf("expected key secret, got %q", key)
+	}
+
+	if _, err := (&commandKeyProvider{path: script, timeout: 50 * time.Millisecond}).run("hang", "ssd/testing", nil); !ctxexec.IsTimeout(err) {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+// +build linux,cgo
+
+// Package loop implements a block volume backend storing each volume in a
+// file on the host, attached through a loop device. It needs no storage
+// infrastructure, which makes it useful to try block volumes out and to test
+// them.
+package loop
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/docker/docker/pkg/loopback"
+	"golang.org/x/net/context"
+)
+
+const (
+	// Name is the name of the driver using the backend.
+	Name = "loop"
+
+	// directoryOpt is the daemon volume option setting where backing files
+	// are kept
+	directoryOpt = "loop.directory"
+	// filesDirName is the directory under the daemon root where backing
+	// files are kept by default
+	filesDirName = "loop_files"
+	fileSuffix   = ".img"
+)
+
+type validationError struct {
+	error
+}
+
+func (validationError) IsValidationError() bool {
+	return true
+}
+
+// Backend keeps the backing files of volumes in a directory.
+type Backend struct {
+	m   sync.Mutex
+	dir string
+	// devices are the open loop devices of attached volumes. Devices are
+	// attached with autoclear, so they go away once closed and no longer
+	// mounted.
+	devices map[string]*os.File
+}
+
+// New returns the loop backend, keeping backing files in the directory set
+// by the loop.directory daemon volume option, or under scope, the daemon
+// root.
+func New(scope string, opts map[string]string) (*Backend, error) {
+	dir := opts[directoryOpt]
+	if dir == "" {
+		dir = filepath.Join(scope, filesDirName)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Backend{dir: dir, devices: make(map[string]*os.File)}, nil
+}
+
+// Name returns the name of the driver.
+func (b *Backend) Name() string {
+	return Name
+}
+
+// IsOption returns whether key is the `sparse` option, which is set to
+// false to allocate the whole backing file when the volume is created.
+func (b *Backend) IsOption(key string) bool {
+	return key == "sparse"
+}
+
+// ValidateOptions validates the `sparse` option.
+func (b *Backend) ValidateOptions(name string, opts map[string]string) error {
+	if value, ok := opts["sparse"]; ok {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return validationError{fmt.Errorf("invalid value %q for sparse option: %v", value, err)}
+		}
+	}
+	return nil
+}
+
+// isSparse returns whether backing files are sparse, which they are unless
+// the `sparse` option is false.
+func isSparse(opts map[string]string) bool {
+	sparse, err := strconv.ParseBool(opts["sparse"])
+	return err != nil || sparse
+}
+
+func (b *Backend) path(name string) string {
+	return filepath.Join(b.dir, url.QueryEscape(name)+fileSuffix)
+}
+
+// Create creates the backing file of the volume, unless it exists.
+func (b *Backend) Create(ctx context.Context, name string, size int64, opts map[string]string) error {
+	f, err := os.OpenFile(b.path(name), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if isSparse(opts) {
+		err = f.Truncate(size)
+	} else {
+		err = syscall.Fallocate(int(f.Fd()), 0, 0, size)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("Failed to allocate %d bytes for %s: %v", size, f.Name(), err)
+	}
+	return nil
+}
+
+// Attach attaches the backing file of the volume to a loop device, or finds
+// the device it is already attached to.
+func (b *Backend) Attach(ctx context.Context, name string, opts map[string]string) (string, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if device, ok := b.devices[name]; ok {
+		return device.Name(), nil
+	}
+	f, err := os.Open(b.path(name))
+	if err != nil {
+		return "", err
+	}
+	// the device may have been attached before the daemon restarted
+	device := loopback.FindLoopDeviceFor(f)
+	f.Close()
+	if device == nil {
+		if device, err = loopback.AttachLoopDevice(b.path(name)); err != nil {
+			return "", fmt.Errorf("Failed to attach %s to a loop device: %v", b.path(name), err)
+		}
+	}
+	b.devices[name] = device
+	return device.Name(), nil
+}
+
+// Detach closes the loop device of the volume, which the kernel releases
+// once it is no longer used.
+func (b *Backend) Detach(ctx context.Context, name, device string, opts map[string]string) error {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	f, ok := b.devices[name]
+	if !ok {
+		return nil
+	}
+	delete(b.devices, name)
+	return f.Close()
+}
+
+// Remove removes the backing file of the volume.
+func (b *Backend) Remove(ctx context.Context, name string, opts map[string]string) error {
+	if err := os.Remove(b.path(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// DiskUsage returns the size of the backing file of the volume and the
+// space allocated to it, which is less for sparse files.
+func (b *Backend) DiskUsage(ctx context.Context, name string, opts map[string]string) (int64, int64, error) {
+	fi, err := os.Stat(b.path(name))
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, fmt.Errorf("unable to get the allocated size of %s", b.path(name))
+	}
+	return fi.Size(), st.Blocks * 512, nil
+}
+
+// ReclaimsSpace returns true, removing the backing file of a volume frees
+// its space.
+func (b *Backend) ReclaimsSpace() bool {
+	return true
+}
+
+// Resize changes the size of the backing file of the volume, and has the
+// loop device it is attached to pick the new size up.
+func (b *Backend) Resize(ctx context.Context, name string, size int64, attached string, opts map[string]string) error {
+	if err := os.Truncate(b.path(name), size); err != nil {
+		if os.IsNotExist(err) {
+			// the file is created with the new size when first mounted
+			return nil
+		}
+		return err
+	}
+	if attached == "" {
+		return nil
+	}
+
+	b.m.Lock()
+	defer b.m.Unlock()
+	device, ok := b.devices[name]
+	if !ok {
+		// the device was attached before the daemon restarted
+		f, err := os.OpenFile(attached, os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		device = f
+	}
+	return loopback.SetCapacity(device)
+}
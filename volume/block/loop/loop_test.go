@@ -0,0 +1,128 @@
+// +build linux,cgo
+
+package loop
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/volume"
+	"github.com/docker/docker/volume/block"
+)
+
+// TestVolumeLifecycle runs a volume through the whole block volume flow,
+// which needs loop devices and so root.
+func TestVolumeLifecycle(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("attaching loop devices requires root")
+	}
+	if _, err := os.Stat("/dev/loop-control"); err != nil {
+		t.Skip("loop devices are not available")
+	}
+	if _, err := exec.LookPath("mkfs.ext4"); err != nil {
+		t.Skip("mkfs.ext4 is not installed")
+	}
+	dir, err := ioutil.TempDir("", "loop-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	backend, err := New(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := block.New(dir, backend, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Create("bad", map[string]string{"sparse": "maybe"}); err == nil {
+		t.Fatal("expected an error for an invalid sparse option")
+	}
+	v, err := r.Create("testing", map[string]string{"size": "32M", "mount-opts": "noatime"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mountpoint, err := v.Mount("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mountpoint != v.Path() {
+		t.Fatalf("expected the volume to be mounted on %s, got %s", v.Path(), mountpoint)
+	}
+	status := v.Status()
+	device, _ := status["Device"].(string)
+	if !strings.HasPrefix(device, "/dev/loop") || status["Filesystem"] != "ext4" || status["RefCount"] != 1 {
+		t.Fatalf("unexpected status %v", status)
+	}
+	if l, ok := block.FindMount(device); !ok || l.Mountpoint != mountpoint {
+		t.Fatalf("expected %s to be mounted on %s", device, mountpoint)
+	}
+	mounts, err := ioutil.ReadFile("/proc/self/mounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(mounts), "\n") {
+		if strings.HasPrefix(line, device+" ") && !strings.Contains(line, "noatime") {
+			t.Fatalf("expected the mount-opts option to be applied, got %s", line)
+		}
+	}
+	if err := r.Remove(v); err == nil {
+		t.Fatal("expected an error removing a volume in use")
+	}
+
+	if err := v.Unmount("abc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.(volume.Resizer).Resize(64<<20, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v.Status()["Device"]; ok {
+		t.Fatal("expected the volume to be detached")
+	}
+	if _, ok := block.FindMount(device); ok {
+		t.Fatalf("expected %s to be unmounted", device)
+	}
+	// the space allocated to the backing file is freed with the volume
+	u, err := v.(volume.SizeReporter).Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Size != 64<<20 || u.Used <= 0 || u.Used >= u.Size || !u.Reclaimable {
+		t.Fatalf("expected the usage of a sparse reclaimable 64M file, got %+v", u)
+	}
+
+	// a new driver picks the volume up, and grows its filesystem on mount
+	r, err = block.New(dir, backend, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err = r.Get("testing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Mount("def"); err != nil {
+		t.Fatal(err)
+	}
+	device, _ = v.Status()["Device"].(string)
+	out, err := exec.Command("dumpe2fs", "-h", device).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "Block count:              65536") {
+		t.Fatalf("expected the filesystem to be grown to 64M, got %s", out)
+	}
+	if err := v.Unmount("def"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Remove(v); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(backend.path("testing")); !os.IsNotExist(err) {
+		t.Fatalf("expected the backing file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package block
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/pkg/ctxexec"
+	"golang.org/x/net/context"
+)
+
+// cryptsetup runs cryptsetup with the given key on stdin, and includes its
+// error output in the returned error.
+func cryptsetup(ctx context.Context, key []byte, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("cryptsetup", args...)
+	cmd.Stdin = bytes.NewReader(key)
+	cmd.Stderr = &stderr
+	if err := ctxexec.Run(ctx, cmd); err != nil {
+		if ctxexec.IsTimeout(err) {
+			return err
+		}
+		return fmt.Errorf("cryptsetup %s failed: %v - %s", args[0], err, strings.TrimRight(stderr.String(), "\n"))
+	}
+	return nil
+}
+
+// LuksFormat encrypts the device with key, destroying its contents.
+func LuksFormat(ctx context.Context, device string, key []byte) error {
+	return cryptsetup(ctx, key, "luksFormat", "--batch-mode", "--key-file=-", device)
+}
+
+// LuksOpen opens the encrypted device as /dev/mapper/<name>, which it
+// returns. Discards are passed down to the device if discard is set.
+func LuksOpen(ctx context.Context, device, name string, key []byte, discard bool) (string, error) {
+	args := []string{"luksOpen", "--key-file=-"}
+	if discard {
+		args = append(args, "--allow-discards")
+	}
+	if err := cryptsetup(ctx, key, append(args, device, name)...); err != nil {
+		return "", err
+	}
+	return filepath.Join(LuksDevMapperPath, name), nil
+}
+
+// LuksClose closes the mapping /dev/mapper/<name>.
+func LuksClose(ctx context.Context, name string) error {
+	return cryptsetup(ctx, nil, "luksClose", name)
+}
+
+// LuksResize grows the mapping /dev/mapper/<name> to the size of its
+// device.
+func LuksResize(ctx context.Context, name string, key []byte) error {
+	return cryptsetup(ctx, key, "resize", "--key-file=-", name)
+}
+
+// LuksChangeKey replaces the oldKey passphrase of the encrypted device by
+// newKey. The old key is passed on stdin and the new one through a pipe so
+// that neither touches the disk.
+func LuksChangeKey(ctx context.Context, device string, oldKey, newKey []byte) error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("cryptsetup", "luksChangeKey", "--batch-mode", "--key-file=-", device, "/dev/fd/3")
+	cmd.Stdin = bytes.NewReader(oldKey)
+	cmd.Stderr = &stderr
+	cmd.ExtraFiles = []*os.File{r}
+	if err := cmd.Start(); err != nil {
+		w.Close()
+		return err
+	}
+	_, err = w.Write(newKey)
+	w.Close()
+	if waitErr := ctxexec.Wait(ctx, cmd); waitErr != nil {
+		if ctxexec.IsTimeout(waitErr) {
+			return waitErr
+		}
+		return fmt.Errorf("cryptsetup luksChangeKey on %s failed: %v - %s", device, waitErr, strings.TrimRight(stderr.String(), "\n"))
+	}
+	return err
+}
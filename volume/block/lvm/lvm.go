@@ -0,0 +1,211 @@
+// Package lvm implements a block volume backend storing each volume in a
+// thin logical volume of an LVM thin pool.
+package lvm
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/pkg/ctxexec"
+	"golang.org/x/net/context"
+)
+
+const (
+	// Name is the name of the driver using the backend.
+	Name = "lvm"
+
+	// daemon volume options setting the default volume group and thin pool
+	volumeGroupOpt = "lvm.volume-group"
+	thinPoolOpt    = "lvm.thin-pool"
+
+	// lvPrefix is prepended to the names of volumes to name their logical
+	// volumes, so that they are recognisable among the others of the group
+	lvPrefix = "docker-"
+)
+
+var validOpts = map[string]bool{
+	"volume-group": true, // volume group of the thin pool
+	"thin-pool":    true, // thin pool the logical volume is created in
+}
+
+type validationError struct {
+	error
+}
+
+func (validationError) IsValidationError() bool {
+	return true
+}
+
+// Backend creates the logical volumes of volumes in thin pools.
+type Backend struct {
+	volumeGroup string
+	thinPool    string
+}
+
+// New returns the LVM backend. The lvm.volume-group and lvm.thin-pool daemon
+// volume options set the thin pool used for volumes created without the
+// `volume-group` and `thin-pool` options.
+func New(opts map[string]string) *Backend {
+	return &Backend{
+		volumeGroup: opts[volumeGroupOpt],
+		thinPool:    opts[thinPoolOpt],
+	}
+}
+
+// Name returns the name of the driver.
+func (b *Backend) Name() string {
+	return Name
+}
+
+// IsOption returns whether key is the `volume-group` or `thin-pool`
+// option.
+func (b *Backend) IsOption(key string) bool {
+	return validOpts[key]
+}
+
+// ValidateOptions checks that the volume has a thin pool, from its options
+// or the daemon volume options.
+func (b *Backend) ValidateOptions(name string, opts map[string]string) error {
+	vg, pool := b.pool(opts)
+	if vg == "" {
+		return validationError{fmt.Errorf("no volume group for LVM volume '%s', set the volume-group option or the %s daemon volume option", name, volumeGroupOpt)}
+	}
+	if pool == "" {
+		return validationError{fmt.Errorf("no thin pool for LVM volume '%s', set the thin-pool option or the %s daemon volume option", name, thinPoolOpt)}
+	}
+	for _, s := range []string{vg, pool} {
+		if strings.ContainsAny(s, "/ ") {
+			return validationError{fmt.Errorf("invalid LVM name %q", s)}
+		}
+	}
+	return nil
+}
+
+// pool returns the volume group and thin pool of a volume.
+func (b *Backend) pool(opts map[string]string) (string, string) {
+	vg, pool := opts["volume-group"], opts["thin-pool"]
+	if vg == "" {
+		vg = b.volumeGroup
+	}
+	if pool == "" {
+		pool = b.thinPool
+	}
+	return vg, pool
+}
+
+// lvSpec returns the "group/lv" name of the logical volume of the named
+// volume.
+func (b *Backend) lvSpec(name string, opts map[string]string) string {
+	vg, _ := b.pool(opts)
+	return vg + "/" + lvPrefix + name
+}
+
+// Create creates the thin logical volume of the volume, unless it exists.
+func (b *Backend) Create(ctx context.Context, name string, size int64, opts map[string]string) error {
+	vg, pool := b.pool(opts)
+	err := run(ctx, "lvcreate", "--virtualsize", fmt.Sprintf("%db", size), "--thin", vg+"/"+pool, "--name", lvPrefix+name)
+	if err != nil && strings.Contains(err.Error(), "already exists") {
+		return nil
+	}
+	return err
+}
+
+// Attach activates the logical volume of the volume.
+func (b *Backend) Attach(ctx context.Context, name string, opts map[string]string) (string, error) {
+	spec := b.lvSpec(name, opts)
+	// thin logical volumes may be flagged to be skipped on activation
+	if err := run(ctx, "lvchange", "--activate", "y", "--ignoreactivationskip", spec); err != nil {
+		return "", err
+	}
+	return filepath.Join("/dev", spec), nil
+}
+
+// Detach deactivates the logical volume of the volume.
+func (b *Backend) Detach(ctx context.Context, name, device string, opts map[string]string) error {
+	return run(ctx, "lvchange", "--activate", "n", b.lvSpec(name, opts))
+}
+
+// Remove removes the logical volume of the volume, releasing its blocks to
+// the thin pool.
+func (b *Backend) Remove(ctx context.Context, name string, opts map[string]string) error {
+	err := run(ctx, "lvremove", "--force", b.lvSpec(name, opts))
+	if err != nil && strings.Contains(err.Error(), "Failed to find logical volume") {
+		return nil
+	}
+	return err
+}
+
+// Resize changes the virtual size of the logical volume of the volume,
+// which active devices reflect right away.
+func (b *Backend) Resize(ctx context.Context, name string, size int64, attached string, opts map[string]string) error {
+	err := run(ctx, "lvresize", "--force", "--size", fmt.Sprintf("%db", size), b.lvSpec(name, opts))
+	if err != nil && strings.Contains(err.Error(), "Failed to find logical volume") {
+		// the logical volume is created with the new size when first mounted
+		return nil
+	}
+	return err
+}
+
+// DiskUsage returns the virtual size of the logical volume of the volume and
+// the space it uses in its thin pool.
+func (b *Backend) DiskUsage(ctx context.Context, name string, opts map[string]string) (int64, int64, error) {
+	out, err := output(ctx, "lvs", "--noheadings", "--units", "b", "--nosuffix", "--options", "lv_size,data_percent", b.lvSpec(name, opts))
+	if err != nil {
+		if strings.Contains(err.Error(), "Failed to find logical volume") {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+	return parseUsage(out)
+}
+
+// parseUsage parses the size and data percentage lvs reports for a thin
+// logical volume into its size and the space it uses, in bytes.
+func parseUsage(out string) (int64, int64, error) {
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected lvs output %q", out)
+	}
+	size, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid size in lvs output %q: %v", out, err)
+	}
+	percent, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid data percentage in lvs output %q: %v", out, err)
+	}
+	return size, int64(float64(size) * percent / 100), nil
+}
+
+// ReclaimsSpace returns true, removing the logical volume of a volume
+// releases its blocks to the thin pool.
+func (b *Backend) ReclaimsSpace() bool {
+	return true
+}
+
+// run runs an LVM command, and includes its error output in the returned
+// error.
+func run(ctx context.Context, name string, args ...string) error {
+	_, err := output(ctx, name, args...)
+	return err
+}
+
+// output runs an LVM command and returns its output, including its error
+// output in the returned error.
+func output(ctx context.Context, name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := ctxexec.Run(ctx, cmd); err != nil {
+		if ctxexec.IsTimeout(err) {
+			return "", err
+		}
+		return "", fmt.Errorf("%s %s failed: %v - %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,124 @@
+package lvm
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/docker/docker/volume"
+	"github.com/docker/docker/volume/block"
+)
+
+func TestValidateOptions(t *testing.T) {
+	b := New(nil)
+	if err := b.ValidateOptions("foo", map[string]string{"volume-group": "vg0", "thin-pool": "pool0"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []map[string]string{
+		{},
+		{"volume-group": "vg0"},
+		{"thin-pool": "pool0"},
+		{"volume-group": "vg/0", "thin-pool": "pool0"},
+		{"volume-group": "vg0", "thin-pool": "pool 0"},
+	} {
+		if err := b.ValidateOptions("foo", opts); err == nil {
+			t.Fatalf("expected an error for %v", opts)
+		} else if _, ok := err.(validationError); !ok {
+			t.Fatalf("expected a validation error for %v, got %v", opts, err)
+		}
+	}
+
+	// the daemon volume options set the default thin pool
+	b = New(map[string]string{volumeGroupOpt: "vg0", thinPoolOpt: "pool0"})
+	if err := b.ValidateOptions("foo", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ValidateOptions("foo", map[string]string{"thin-pool": "pool1"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLvSpec(t *testing.T) {
+	b := New(map[string]string{volumeGroupOpt: "vg0", thinPoolOpt: "pool0"})
+	cases := []struct {
+		opts     map[string]string
+		expected string
+	}{
+		{nil, "vg0/docker-foo"},
+		{map[string]string{"thin-pool": "pool1"}, "vg0/docker-foo"},
+		{map[string]string{"volume-group": "vg1", "thin-pool": "pool1"}, "vg1/docker-foo"},
+	}
+	for _, c := range cases {
+		if spec := b.lvSpec("foo", c.opts); spec != c.expected {
+			t.Fatalf("expected %s for %v, got %s", c.expected, c.opts, spec)
+		}
+	}
+}
+
+func TestParseUsage(t *testing.T) {
+	size, used, err := parseUsage("  10737418240 12.50\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 10737418240 || used != 1342177280 {
+		t.Fatalf("expected 10G with 1.25G used, got %d with %d used", size, used)
+	}
+	for _, out := range []string{"", "10737418240", "10G 12.50", "10737418240 most"} {
+		if _, _, err := parseUsage(out); err == nil {
+			t.Fatalf("expected an error parsing %q", out)
+		}
+	}
+}
+
+// TestVolumeLifecycle runs a volume through the whole block volume flow. It
+// needs root and a thin pool to create logical volumes in, named by the
+// DOCKER_TEST_LVM_VG and DOCKER_TEST_LVM_POOL environment variables.
+func TestVolumeLifecycle(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("creating logical volumes requires root")
+	}
+	vg, pool := os.Getenv("DOCKER_TEST_LVM_VG"), os.Getenv("DOCKER_TEST_LVM_POOL")
+	if vg == "" || pool == "" {
+		t.Skip("DOCKER_TEST_LVM_VG and DOCKER_TEST_LVM_POOL are not set")
+	}
+	for _, cmd := range []string{"lvcreate", "mkfs.ext4"} {
+		if _, err := exec.LookPath(cmd); err != nil {
+			t.Skipf("%s is not installed", cmd)
+		}
+	}
+	dir, err := ioutil.TempDir("", "lvm-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := block.New(dir, New(map[string]string{volumeGroupOpt: vg, thinPoolOpt: pool}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := r.Create("testing", map[string]string{"size": "32M"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Remove(v)
+
+	if _, err := v.Mount("abc"); err != nil {
+		t.Fatal(err)
+	}
+	if status := v.Status(); status["Device"] != "/dev/"+vg+"/docker-testing" {
+		t.Fatalf("unexpected status %v", status)
+	}
+	if err := v.(volume.Resizer).Resize(64<<20, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Unmount("abc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Remove(v); err != nil {
+		t.Fatal(err)
+	}
+	if err := exec.Command("lvs", vg+"/docker-testing").Run(); err == nil {
+		t.Fatal("expected the logical volume to be removed")
+	}
+}
@@ -0,0 +1,141 @@
+package block
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/pkg/ctxexec"
+	"github.com/docker/docker/pkg/mount"
+	"golang.org/x/net/context"
+)
+
+// mountsDirPermissions are the permissions of the directories filesystems
+// are mounted on.
+const mountsDirPermissions = 0755
+
+// MountLocation is where the filesystem of a volume is mounted.
+type MountLocation struct {
+	procRoot string
+	// pid is a process in the mount namespace of the mount, empty for the
+	// namespace of the daemon
+	pid string
+	// Mountpoint is the mount point in that namespace.
+	Mountpoint string
+}
+
+// Path returns a path the mount can be reached at from the daemon, through
+// the root of the process if it is in another mount namespace.
+func (l *MountLocation) Path() string {
+	if l.pid == "" {
+		return l.Mountpoint
+	}
+	return filepath.Join(l.procRoot, l.pid, "root", l.Mountpoint)
+}
+
+// Command returns a command that runs in the mount namespace of the mount,
+// for tools that check where the filesystem they work on is mounted.
+func (l *MountLocation) Command(name string, arg ...string) *exec.Cmd {
+	if l.pid == "" {
+		return exec.Command(name, arg...)
+	}
+	args := []string{"--mount=" + filepath.Join(l.procRoot, l.pid, "ns", "mnt"), "--", name}
+	return exec.Command("nsenter", append(args, arg...)...)
+}
+
+// FindMount returns where the filesystem on device is mounted. Volumes are
+// mounted on the host, but containers started by daemons that mounted them
+// in the mount namespace of the container may still run, so when the device
+// is not mounted on the host the mounts of every process are searched.
+func FindMount(device string) (*MountLocation, bool) {
+	return findMount(procRoot, device)
+}
+
+func findMount(procRoot, device string) (*MountLocation, bool) {
+	if mountpoint, ok := findMountIn(filepath.Join(procRoot, "self", "mounts"), device); ok {
+		return &MountLocation{procRoot: procRoot, Mountpoint: mountpoint}, true
+	}
+	dirs, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return nil, false
+	}
+	for _, d := range dirs {
+		if _, err := strconv.Atoi(d.Name()); err != nil {
+			continue
+		}
+		if mountpoint, ok := findMountIn(filepath.Join(procRoot, d.Name(), "mounts"), device); ok {
+			return &MountLocation{procRoot: procRoot, pid: d.Name(), Mountpoint: mountpoint}, true
+		}
+	}
+	return nil, false
+}
+
+// findMountIn returns where device is mounted according to the given
+// /proc/<pid>/mounts file.
+func findMountIn(path, device string) (string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && unescapeMountField(fields[0]) == device {
+			return unescapeMountField(fields[1]), true
+		}
+	}
+	return "", false
+}
+
+// unescapeMountField decodes the octal escapes the kernel uses for white
+// space and backslashes in the fields of /proc/<pid>/mounts.
+func unescapeMountField(s string) string {
+	return strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`).Replace(s)
+}
+
+// mountFilesystem mounts the filesystem on device on mountpoint with the
+// given options, unless something is already mounted there. Containers bind
+// mount the mountpoint.
+func mountFilesystem(ctx context.Context, device, mountpoint string, opts []string) error {
+	if mounted, err := mount.Mounted(mountpoint); err != nil || mounted {
+		return err
+	}
+	if err := os.MkdirAll(mountpoint, mountsDirPermissions); err != nil {
+		return err
+	}
+	args := []string{device, mountpoint}
+	if len(opts) > 0 {
+		args = append(args, "-o", strings.Join(opts, ","))
+	}
+	return runMount(ctx, "mount", args...)
+}
+
+// unmountFilesystem unmounts the filesystem mounted on mountpoint, if any.
+func unmountFilesystem(ctx context.Context, mountpoint string) error {
+	if mounted, err := mount.Mounted(mountpoint); err != nil || !mounted {
+		return err
+	}
+	return runMount(ctx, "umount", mountpoint)
+}
+
+// runMount runs mount(8) or umount(8), and includes its error output in the
+// returned error.
+func runMount(ctx context.Context, name string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = &stderr
+	if err := ctxexec.Run(ctx, cmd); err != nil {
+		if ctxexec.IsTimeout(err) {
+			return err
+		}
+		return fmt.Errorf("%s %s failed: %v - %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package block
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindMount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "block-mount-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mounts := map[string]string{
+		"self": "/dev/sda1 / ext4 rw 0 0\n/dev/rbd1 /mnt/ceph\\040data xfs rw 0 0\n",
+		"42":   "/dev/sda1 / ext4 rw 0 0\n/dev/mapper/ssd--foo /data ext4 rw 0 0\n",
+		"fs":   "",
+	}
+	for pid, content := range mounts {
+		if err := os.MkdirAll(filepath.Join(dir, pid), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, pid, "mounts"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l, found := findMount(dir, "/dev/rbd1")
+	if !found || l.Path() != "/mnt/ceph data" {
+		t.Fatalf("expected host mount, got %+v (%v)", l, found)
+	}
+	if args := l.Command("xfs_growfs", l.Mountpoint).Args; !reflect.DeepEqual(args, []string{"xfs_growfs", "/mnt/ceph data"}) {
+		t.Fatalf("unexpected command %v", args)
+	}
+
+	l, found = findMount(dir, "/dev/mapper/ssd--foo")
+	if !found || l.Path() != filepath.Join(dir, "42", "root", "data") {
+		t.Fatalf("expected container mount, got %+v (%v)", l, found)
+	}
+	expected := []string{"nsenter", "--mount=" + filepath.Join(dir, "42", "ns", "mnt"), "--", "resize2fs", "/dev/mapper/ssd--foo"}
+	if args := l.Command("resize2fs", "/dev/mapper/ssd--foo").Args; !reflect.DeepEqual(args, expected) {
+		t.Fatalf("unexpected command %v", args)
+	}
+
+	if _, found := findMount(dir, "/dev/rbd2"); found {
+		t.Fatal("expected /dev/rbd2 not to be mounted")
+	}
+}
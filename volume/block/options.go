@@ -0,0 +1,193 @@
+package block
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultFsType is the filesystem created on new devices unless the
+	// `fs` option is given.
+	DefaultFsType = "ext4"
+
+	// FsckPreen repairs the problems fsck can fix without help before a
+	// filesystem is mounted. It is the default fsck policy.
+	FsckPreen = "preen"
+	// FsckForce checks filesystems even if they are marked clean.
+	FsckForce = "force"
+	// FsckSkip mounts filesystems without checking them.
+	FsckSkip = "skip"
+)
+
+var (
+	validOpts = map[string]bool{
+		"fs":         true, // filesystem created on new devices
+		"mkfs-opts":  true, // options passed to mkfs, replacing the defaults
+		"encrypted":  true, // encrypt new devices with LUKS
+		"fsck":       true, // fsck policy: preen, force or skip
+		"discard":    true, // pass discards down to the device
+		"mount-opts": true, // options the filesystem is mounted with
+	}
+
+	validFsTypes = map[string]bool{
+		"ext2":  true,
+		"ext3":  true,
+		"ext4":  true,
+		"xfs":   true,
+		"btrfs": true,
+	}
+
+	validFsckPolicies = map[string]bool{
+		FsckPreen: true,
+		FsckForce: true,
+		FsckSkip:  true,
+	}
+
+	// reservedMountOpts are set by the daemon, from the mode of the mount
+	// point in the container.
+	reservedMountOpts = map[string]bool{
+		"rw":      true,
+		"ro":      true,
+		"bind":    true,
+		"rbind":   true,
+		"remount": true,
+	}
+
+	// defaultMkfsOpts are the options used to create a filesystem when the
+	// `mkfs-opts` option is not given. Discards are skipped as the storage
+	// of new devices is usually thin provisioned or zeroed anyway.
+	defaultMkfsOpts = map[string][]string{
+		"ext2": {"-m0", "-E", "nodiscard,lazy_itable_init=0,packed_meta_blocks=1"},
+		"ext3": {"-m0", "-E", "nodiscard,lazy_itable_init=0,lazy_journal_init=0,packed_meta_blocks=1"},
+		"ext4": {"-m0", "-E", "nodiscard,lazy_itable_init=0,lazy_journal_init=0,packed_meta_blocks=1"},
+		"xfs":  {"-K"},
+	}
+)
+
+type validationError struct {
+	error
+}
+
+func (validationError) IsValidationError() bool {
+	return true
+}
+
+// Options control how the filesystem of a block volume is created, checked
+// and mounted. They are given to `docker volume create` and persisted by
+// drivers with the rest of the metadata of the volume.
+type Options struct {
+	// FsType is the filesystem created on new devices
+	FsType string `json:",omitempty"`
+	// MkfsOpts replaces the default options passed to mkfs
+	MkfsOpts []string `json:",omitempty"`
+	// Encrypted new devices are formatted with LUKS using a generated key
+	Encrypted bool `json:",omitempty"`
+	// Fsck is the fsck policy, preen if not set
+	Fsck string `json:",omitempty"`
+	// NoDiscard disables passing discards down to the device
+	NoDiscard bool `json:",omitempty"`
+	// MountOpts are the options the filesystem is mounted with
+	MountOpts []string `json:",omitempty"`
+}
+
+// IsOption returns whether key is one of the options common to all block
+// volumes.
+func IsOption(key string) bool {
+	return validOpts[key]
+}
+
+// Set validates and sets the option key to value. Errors are validation
+// errors.
+func (o *Options) Set(key, value string) error {
+	switch key {
+	case "fs":
+		if !validFsTypes[value] {
+			return validationError{fmt.Errorf("unsupported filesystem %q, supported filesystems are %s", value, joinKeys(validFsTypes))}
+		}
+		o.FsType = value
+	case "mkfs-opts":
+		o.MkfsOpts = strings.Fields(value)
+	case "encrypted":
+		encrypted, err := strconv.ParseBool(value)
+		if err != nil {
+			return validationError{fmt.Errorf("invalid value %q for encrypted option: %v", value, err)}
+		}
+		o.Encrypted = encrypted
+	case "fsck":
+		if !validFsckPolicies[value] {
+			return validationError{fmt.Errorf("invalid fsck policy %q, supported policies are %s", value, joinKeys(validFsckPolicies))}
+		}
+		o.Fsck = value
+	case "discard":
+		discard, err := strconv.ParseBool(value)
+		if err != nil {
+			return validationError{fmt.Errorf("invalid value %q for discard option: %v", value, err)}
+		}
+		o.NoDiscard = !discard
+	case "mount-opts":
+		o.MountOpts = nil
+		for _, opt := range strings.Split(value, ",") {
+			opt = strings.TrimSpace(opt)
+			if opt == "" {
+				continue
+			}
+			if reservedMountOpts[opt] {
+				return validationError{fmt.Errorf("mount option %q is set from the mount mode of the volume", opt)}
+			}
+			o.MountOpts = append(o.MountOpts, opt)
+		}
+	default:
+		return validationError{fmt.Errorf("invalid option key: %q", key)}
+	}
+	return nil
+}
+
+// FilesystemType returns the filesystem to create, defaulting to ext4 for
+// volumes whose metadata predates the option.
+func (o *Options) FilesystemType() string {
+	if o == nil || o.FsType == "" {
+		return DefaultFsType
+	}
+	return o.FsType
+}
+
+// MkfsArgs returns the arguments to pass to mkfs.<fs> before the device.
+func (o *Options) MkfsArgs() []string {
+	if o.MkfsOpts != nil {
+		return o.MkfsOpts
+	}
+	return defaultMkfsOpts[o.FilesystemType()]
+}
+
+// FsckPolicy returns the fsck policy.
+func (o *Options) FsckPolicy() string {
+	if o.Fsck == "" {
+		return FsckPreen
+	}
+	return o.Fsck
+}
+
+// Discard returns whether discards are passed down to the device.
+func (o *Options) Discard() bool {
+	return !o.NoDiscard
+}
+
+// MountOptions returns the options the filesystem is mounted with.
+func (o *Options) MountOptions() []string {
+	var opts []string
+	if o.Discard() {
+		opts = append(opts, "discard")
+	}
+	return append(opts, o.MountOpts...)
+}
+
+func joinKeys(m map[string]bool) string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
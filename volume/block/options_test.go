@@ -0,0 +1,74 @@
+package block
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOptionsSet(t *testing.T) {
+	var o Options
+	for key, value := range map[string]string{
+		"fs":         "xfs",
+		"mkfs-opts":  "-f  -K",
+		"encrypted":  "true",
+		"fsck":       "force",
+		"discard":    "false",
+		"mount-opts": "noatime, nodiratime,,data=ordered",
+	} {
+		if !IsOption(key) {
+			t.Fatalf("expected %s to be an option", key)
+		}
+		if err := o.Set(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := Options{
+		FsType:    "xfs",
+		MkfsOpts:  []string{"-f", "-K"},
+		Encrypted: true,
+		Fsck:      FsckForce,
+		NoDiscard: true,
+		MountOpts: []string{"noatime", "nodiratime", "data=ordered"},
+	}
+	if !reflect.DeepEqual(o, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, o)
+	}
+	if args := o.MkfsArgs(); !reflect.DeepEqual(args, expected.MkfsOpts) {
+		t.Fatalf("expected %v, got %v", expected.MkfsOpts, args)
+	}
+	if opts := o.MountOptions(); !reflect.DeepEqual(opts, expected.MountOpts) {
+		t.Fatalf("expected %v, got %v", expected.MountOpts, opts)
+	}
+}
+
+func TestOptionsDefaults(t *testing.T) {
+	var o Options
+	if o.FilesystemType() != "ext4" || o.FsckPolicy() != FsckPreen || !o.Discard() {
+		t.Fatalf("unexpected defaults: %+v", o)
+	}
+	if args := o.MkfsArgs(); !reflect.DeepEqual(args, defaultMkfsOpts["ext4"]) {
+		t.Fatalf("expected default ext4 mkfs options, got %v", args)
+	}
+	if opts := o.MountOptions(); !reflect.DeepEqual(opts, []string{"discard"}) {
+		t.Fatalf("expected discard mount option, got %v", opts)
+	}
+}
+
+func TestOptionsSetInvalid(t *testing.T) {
+	cases := map[string]string{
+		"unknown":    "value",
+		"fs":         "ntfs",
+		"encrypted":  "maybe",
+		"fsck":       "sometimes",
+		"discard":    "often",
+		"mount-opts": "noatime,ro",
+	}
+	for key, value := range cases {
+		var o Options
+		if err := o.Set(key, value); err == nil {
+			t.Fatalf("expected an error for %s=%s", key, value)
+		} else if _, ok := err.(validationError); !ok {
+			t.Fatalf("expected a validation error for %s=%s, got %T", key, value, err)
+		}
+	}
+}
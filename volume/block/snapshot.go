@@ -0,0 +1,180 @@
+package block
+
+import (
+	"fmt"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/volume"
+)
+
+// snapshotter returns the backend of the volume if it supports snapshots.
+func (v *Volume) snapshotter() (Snapshotter, error) {
+	s, ok := v.root.backend.(Snapshotter)
+	if !ok {
+		return nil, volume.ErrNotSupported
+	}
+	return s, nil
+}
+
+// CreateSnapshot takes a snapshot of the storage of the volume. If freeze is
+// set and the volume is mounted, its filesystem is frozen while the snapshot
+// is taken, so that the snapshot is consistent; otherwise a mounted
+// filesystem may have to be repaired when the snapshot is used.
+func (v *Volume) CreateSnapshot(name string, freeze bool) (volume.Snapshot, error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	s, err := v.snapshotter()
+	if err != nil {
+		return volume.Snapshot{}, err
+	}
+	if freeze && v.device != "" {
+		thaw, err := v.blockDevice().Freeze()
+		if err != nil {
+			return volume.Snapshot{}, err
+		}
+		defer thaw()
+	}
+
+	ctx, cancel := v.root.commandContext()
+	defer cancel()
+	snap, err := s.CreateSnapshot(ctx, v.name, name, v.backendOpts)
+	if err != nil {
+		logrus.Errorf("Failed to snapshot %s volume '%s': %v", v.root.Name(), v.name, err)
+		return volume.Snapshot{}, err
+	}
+	logrus.Infof("Took snapshot '%s' of %s volume '%s'", name, v.root.Name(), v.name)
+	return snap, nil
+}
+
+// Snapshots lists the snapshots of the storage of the volume.
+func (v *Volume) Snapshots() ([]volume.Snapshot, error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	s, err := v.snapshotter()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := v.root.commandContext()
+	defer cancel()
+	return s.Snapshots(ctx, v.name, v.backendOpts)
+}
+
+// RemoveSnapshot removes a snapshot of the storage of the volume.
+func (v *Volume) RemoveSnapshot(name string) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	s, err := v.snapshotter()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := v.root.commandContext()
+	defer cancel()
+	if err := s.RemoveSnapshot(ctx, v.name, name, v.backendOpts); err != nil {
+		logrus.Errorf("Failed to remove snapshot '%s' of %s volume '%s': %v", name, v.root.Name(), v.name, err)
+		return err
+	}
+	logrus.Infof("Removed snapshot '%s' of %s volume '%s'", name, v.root.Name(), v.name)
+	return nil
+}
+
+// RollbackSnapshot reverts the storage of the volume to a snapshot. The
+// storage is rewritten underneath any user, so the volume must not be
+// attached.
+func (v *Volume) RollbackSnapshot(name string) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	s, err := v.snapshotter()
+	if err != nil {
+		return err
+	}
+	if v.device != "" {
+		return validationError{fmt.Errorf("%s volume '%s' can't be rolled back while it is in use", v.root.Name(), v.name)}
+	}
+	// the whole storage may be rewritten
+	ctx, cancel := v.root.fsContext()
+	defer cancel()
+	if err := s.RollbackSnapshot(ctx, v.name, name, v.backendOpts); err != nil {
+		logrus.Errorf("Failed to roll %s volume '%s' back to snapshot '%s': %v", v.root.Name(), v.name, name, err)
+		return err
+	}
+	logrus.Infof("Rolled %s volume '%s' back to snapshot '%s'", v.root.Name(), v.name, name)
+	return nil
+}
+
+// CreateFromSnapshot creates a volume whose storage is created right away
+// from a snapshot of the source volume. The volume has the size and options
+// of the source volume; only the options of the backend can be overridden.
+// The LUKS key of an encrypted source is copied to the new volume, so
+// sources still using a legacy key must have it rotated first.
+func (r *Root) CreateFromSnapshot(name string, source volume.Volume, snapshot string, opts map[string]string) (volume.Volume, error) {
+	s, ok := r.backend.(Snapshotter)
+	if !ok {
+		return nil, volume.ErrNotSupported
+	}
+	src, ok := source.(*Volume)
+	if !ok || src.root != r {
+		return nil, fmt.Errorf("volume '%s' is not a %s volume", source.Name(), r.Name())
+	}
+
+	src.m.Lock()
+	defer src.m.Unlock()
+
+	backendOpts := make(map[string]string)
+	for key, value := range src.backendOpts {
+		backendOpts[key] = value
+	}
+	for key, value := range opts {
+		if !r.backend.IsOption(key) {
+			return nil, validationError{fmt.Errorf("invalid option key %q for a volume created from a snapshot, only the options of the %s driver are supported", key, r.Name())}
+		}
+		backendOpts[key] = value
+	}
+	if err := r.backend.ValidateOptions(name, backendOpts); err != nil {
+		return nil, err
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if _, exists := r.volumes[name]; exists {
+		return nil, validationError{fmt.Errorf("%s volume '%s' already exists", r.Name(), name)}
+	}
+	if err := r.checkStorageUnused(backendOpts); err != nil {
+		return nil, err
+	}
+
+	key, err := r.keys.GetKey(src.name)
+	if err != nil && err != ErrKeyNotFound {
+		return nil, err
+	}
+	ctx, cancel := r.commandContext()
+	defer cancel()
+	if err := s.Clone(ctx, src.name, snapshot, src.backendOpts, name, backendOpts); err != nil {
+		logrus.Errorf("Failed to create %s volume '%s' from snapshot '%s' of '%s': %v", r.Name(), name, snapshot, src.name, err)
+		return nil, err
+	}
+	if key != nil {
+		if err := r.keys.SetKey(name, key); err != nil {
+			return nil, fmt.Errorf("Failed to store LUKS key of %s volume '%s': %v", r.Name(), name, err)
+		}
+	}
+
+	o := *src.opts
+	v := &Volume{
+		root:        r,
+		name:        name,
+		size:        src.size,
+		opts:        &o,
+		backendOpts: backendOpts,
+		users:       make(map[string]int),
+	}
+	if err := v.save(); err != nil {
+		return nil, err
+	}
+	r.volumes[name] = v
+	logrus.Infof("Created %s volume '%s' from snapshot '%s' of '%s'", r.Name(), name, snapshot, src.name)
+	return v, nil
+}
@@ -2,6 +2,7 @@ package volumedrivers
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
@@ -29,6 +30,24 @@ func (a *volumeDriverAdapter) Create(name string, opts map[string]string) (volum
 	}
 	return &volumeAdapter{
 		proxy:      a.proxy,
+		driver:     a,
+		name:       name,
+		driverName: a.name,
+	}, nil
+}
+
+// CreateFromSnapshot creates a volume from a snapshot of the source volume,
+// if the driver has the Clone capability.
+func (a *volumeDriverAdapter) CreateFromSnapshot(name string, source volume.Volume, snapshot string, opts map[string]string) (volume.Volume, error) {
+	if !a.getCapabilities().Clone {
+		return nil, volume.ErrNotSupported
+	}
+	if err := a.proxy.CreateFromSnapshot(name, source.Name(), snapshot, opts); err != nil {
+		return nil, err
+	}
+	return &volumeAdapter{
+		proxy:      a.proxy,
+		driver:     a,
 		name:       name,
 		driverName: a.name,
 	}, nil
@@ -48,9 +67,11 @@ func (a *volumeDriverAdapter) List() ([]volume.Volume, error) {
 	for _, vp := range ls {
 		out = append(out, &volumeAdapter{
 			proxy:      a.proxy,
+			driver:     a,
 			name:       vp.Name,
 			driverName: a.name,
 			eMount:     vp.Mountpoint,
+			status:     vp.Status,
 		})
 	}
 	return out, nil
@@ -69,6 +90,7 @@ func (a *volumeDriverAdapter) Get(name string) (volume.Volume, error) {
 
 	return &volumeAdapter{
 		proxy:      a.proxy,
+		driver:     a,
 		name:       v.Name,
 		driverName: a.Name(),
 		eMount:     v.Mountpoint,
@@ -103,6 +125,11 @@ func (a *volumeDriverAdapter) getCapabilities() volume.Capability {
 		logrus.Warnf("Volume driver %q returned an invalid scope: %q", a.Name(), cap.Scope)
 		cap.Scope = volume.LocalScope
 	}
+	for _, mode := range cap.AccessModes {
+		if mode != volume.AccessReadWrite && mode != volume.AccessReadOnly {
+			logrus.Warnf("Volume driver %q returned an invalid access mode: %q", a.Name(), mode)
+		}
+	}
 
 	a.capabilities = &cap
 	return cap
@@ -110,6 +137,7 @@ func (a *volumeDriverAdapter) getCapabilities() volume.Capability {
 
 type volumeAdapter struct {
 	proxy      *volumeDriverProxy
+	driver     *volumeDriverAdapter
 	name       string
 	driverName string
 	eMount     string // ephemeral host volume path
@@ -143,10 +171,43 @@ func (a *volumeAdapter) CachedPath() string {
 
 func (a *volumeAdapter) Mount(id string) (string, error) {
 	var err error
-	a.eMount, err = a.proxy.Mount(a.name, id)
+	a.eMount, err = a.proxy.Mount(a.name, id, nil)
+	return a.eMount, err
+}
+
+// MountWithOptions mounts the volume for a container, refusing access modes
+// the driver doesn't support. The options are passed to drivers with the
+// MountOptions capability.
+func (a *volumeAdapter) MountWithOptions(id string, opts volume.MountOptions) (string, error) {
+	cap := a.driver.getCapabilities()
+	mode := volume.AccessReadWrite
+	if opts.ReadOnly {
+		mode = volume.AccessReadOnly
+	}
+	if !supportsAccessMode(cap, mode) {
+		return "", fmt.Errorf("volume driver %s does not support %s mounts of volume %s", a.driverName, mode, a.name)
+	}
+	var options *volume.MountOptions
+	if cap.MountOptions {
+		options = &opts
+	}
+	var err error
+	a.eMount, err = a.proxy.Mount(a.name, id, options)
 	return a.eMount, err
 }
 
+func supportsAccessMode(cap volume.Capability, mode string) bool {
+	if len(cap.AccessModes) == 0 {
+		return true
+	}
+	for _, m := range cap.AccessModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *volumeAdapter) Unmount(id string) error {
 	err := a.proxy.Unmount(a.name, id)
 	if err == nil {
@@ -162,3 +223,72 @@ func (a *volumeAdapter) Status() map[string]interface{} {
 	}
 	return out
 }
+
+// Resize changes the size of the volume, if the driver has the Resize
+// capability.
+func (a *volumeAdapter) Resize(size int64, force bool) error {
+	if !a.driver.getCapabilities().Resize {
+		return volume.ErrNotSupported
+	}
+	return a.proxy.Resize(a.name, size, force)
+}
+
+// CreateSnapshot takes a snapshot of the volume, if the driver has the
+// Snapshot capability.
+func (a *volumeAdapter) CreateSnapshot(name string, freeze bool) (volume.Snapshot, error) {
+	if !a.driver.getCapabilities().Snapshot {
+		return volume.Snapshot{}, volume.ErrNotSupported
+	}
+	info, err := a.proxy.CreateSnapshot(a.name, name, freeze)
+	if err != nil {
+		return volume.Snapshot{}, err
+	}
+	// the driver may not describe the snapshot
+	if info == nil {
+		return volume.Snapshot{Name: name}, nil
+	}
+	return *info, nil
+}
+
+// Snapshots lists the snapshots of the volume, if the driver has the
+// Snapshot capability.
+func (a *volumeAdapter) Snapshots() ([]volume.Snapshot, error) {
+	if !a.driver.getCapabilities().Snapshot {
+		return nil, volume.ErrNotSupported
+	}
+	return a.proxy.ListSnapshots(a.name)
+}
+
+// RemoveSnapshot removes a snapshot of the volume, if the driver has the
+// Snapshot capability.
+func (a *volumeAdapter) RemoveSnapshot(name string) error {
+	if !a.driver.getCapabilities().Snapshot {
+		return volume.ErrNotSupported
+	}
+	return a.proxy.RemoveSnapshot(a.name, name)
+}
+
+// RollbackSnapshot reverts the volume to a snapshot, if the driver has the
+// Snapshot capability.
+func (a *volumeAdapter) RollbackSnapshot(name string) error {
+	if !a.driver.getCapabilities().Snapshot {
+		return volume.ErrNotSupported
+	}
+	return a.proxy.RollbackSnapshot(a.name, name)
+}
+
+// Usage returns the space used by the volume, if the driver has the Usage
+// capability.
+func (a *volumeAdapter) Usage() (*volume.Usage, error) {
+	if !a.driver.getCapabilities().Usage {
+		return nil, volume.ErrNotSupported
+	}
+	usage, err := a.proxy.Usage(a.name)
+	if err != nil {
+		return nil, err
+	}
+	if usage == nil {
+		return nil, fmt.Errorf("volume driver %s returned no usage for volume %s", a.driverName, a.name)
+	}
+	return usage, nil
+}
@@ -0,0 +1,152 @@
+package volumedrivers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/docker/docker/pkg/plugins"
+	"github.com/docker/docker/volume"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+func newTestAdapter(t *testing.T, mux *http.ServeMux) (*volumeDriverAdapter, func()) {
+	server := httptest.NewServer(mux)
+	u, _ := url.Parse(server.URL)
+	client, err := plugins.NewClient("tcp://"+u.Host, &tlsconfig.Options{InsecureSkipVerify: true})
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return &volumeDriverAdapter{name: "fake", proxy: &volumeDriverProxy{client}}, server.Close
+}
+
+func handle(mux *http.ServeMux, method string, resp interface{}, req interface{}) {
+	mux.HandleFunc("/VolumeDriver."+method, func(w http.ResponseWriter, r *http.Request) {
+		if req != nil {
+			json.NewDecoder(r.Body).Decode(req)
+		}
+		w.Header().Set("Content-Type", "application/vnd.docker.plugins.v1+json")
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func TestVolumeAdapterV1Driver(t *testing.T) {
+	mux := http.NewServeMux()
+	var mountReq map[string]interface{}
+	handle(mux, "Create", map[string]string{}, nil)
+	handle(mux, "Capabilities", map[string]interface{}{"Capabilities": map[string]string{"Scope": "local"}}, nil)
+	handle(mux, "Mount", map[string]string{"Mountpoint": "/mnt/vol"}, &mountReq)
+	a, done := newTestAdapter(t, mux)
+	defer done()
+
+	v, err := a.Create("vol", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := v.(volume.OptionsMounter).MountWithOptions("123", volume.MountOptions{ReadOnly: true, ContainerID: "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/mnt/vol" {
+		t.Fatalf("expected mountpoint /mnt/vol, got %s", path)
+	}
+	if mountReq["Name"] != "vol" || mountReq["ID"] != "123" || mountReq["Options"] != nil {
+		t.Fatalf("unexpected mount request: %v", mountReq)
+	}
+
+	if err := v.(volume.Resizer).Resize(1024, false); err != volume.ErrNotSupported {
+		t.Fatalf("expected resize to be unsupported, got %v", err)
+	}
+	if _, err := v.(volume.Snapshotter).CreateSnapshot("snap", false); err != volume.ErrNotSupported {
+		t.Fatalf("expected snapshots to be unsupported, got %v", err)
+	}
+	if _, err := v.(volume.SizeReporter).Usage(); err != volume.ErrNotSupported {
+		t.Fatalf("expected usage to be unsupported, got %v", err)
+	}
+	if _, err := a.CreateFromSnapshot("clone", v, "snap", nil); err != volume.ErrNotSupported {
+		t.Fatalf("expected clones to be unsupported, got %v", err)
+	}
+}
+
+func TestVolumeAdapterV2Driver(t *testing.T) {
+	mux := http.NewServeMux()
+	var (
+		mountReq  volumeDriverProxyMountRequest
+		resizeReq volumeDriverProxyResizeRequest
+		cloneReq  volumeDriverProxyCreateFromSnapshotRequest
+	)
+	handle(mux, "Create", map[string]string{}, nil)
+	handle(mux, "Capabilities", map[string]interface{}{"Capabilities": volume.Capability{
+		Scope:        "global",
+		Resize:       true,
+		Snapshot:     true,
+		Clone:        true,
+		Usage:        true,
+		MountOptions: true,
+		AccessModes:  []string{volume.AccessReadOnly},
+	}}, nil)
+	handle(mux, "Mount", map[string]string{"Mountpoint": "/mnt/vol"}, &mountReq)
+	handle(mux, "Resize", map[string]string{}, &resizeReq)
+	handle(mux, "CreateSnapshot", map[string]interface{}{"Info": volume.Snapshot{Name: "snap"}}, nil)
+	handle(mux, "ListSnapshots", map[string]interface{}{"Snapshots": []volume.Snapshot{{Name: "snap"}}}, nil)
+	handle(mux, "Usage", map[string]interface{}{"Usage": volume.Usage{Used: 42}}, nil)
+	handle(mux, "CreateFromSnapshot", map[string]string{}, &cloneReq)
+	a, done := newTestAdapter(t, mux)
+	defer done()
+
+	v, err := a.Create("vol", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := v.(volume.OptionsMounter)
+	if _, err := m.MountWithOptions("123", volume.MountOptions{ContainerID: "abc"}); err == nil {
+		t.Fatal("expected read-write mount to be refused")
+	}
+	if _, err := m.MountWithOptions("123", volume.MountOptions{ReadOnly: true, ContainerID: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if mountReq.Options == nil || !mountReq.Options.ReadOnly || mountReq.Options.ContainerID != "abc" {
+		t.Fatalf("expected mount options to be passed, got %+v", mountReq.Options)
+	}
+
+	if err := v.(volume.Resizer).Resize(1024, true); err != nil {
+		t.Fatal(err)
+	}
+	if resizeReq.Name != "vol" || resizeReq.Size != 1024 || !resizeReq.Force {
+		t.Fatalf("unexpected resize request: %+v", resizeReq)
+	}
+
+	snap, err := v.(volume.Snapshotter).CreateSnapshot("snap", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Name != "snap" {
+		t.Fatalf("unexpected snapshot: %+v", snap)
+	}
+	snaps, err := v.(volume.Snapshotter).Snapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 1 || snaps[0].Name != "snap" {
+		t.Fatalf("unexpected snapshots: %+v", snaps)
+	}
+
+	usage, err := v.(volume.SizeReporter).Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Used != 42 {
+		t.Fatalf("expected 42 bytes used, got %d", usage.Used)
+	}
+
+	clone, err := a.CreateFromSnapshot("clone", v, "snap", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.Name() != "clone" || cloneReq.Source != "vol" || cloneReq.Snapshot != "snap" {
+		t.Fatalf("unexpected clone request: %+v", cloneReq)
+	}
+}
@@ -34,8 +34,9 @@ type volumeDriver interface {
 	Remove(name string) (err error)
 	// Get the mountpoint of the given volume
 	Path(name string) (mountpoint string, err error)
-	// Mount the given volume and return the mountpoint
-	Mount(name, id string) (mountpoint string, err error)
+	// Mount the given volume and return the mountpoint. Options are only
+	// passed to drivers with the MountOptions capability.
+	Mount(name, id string, options *volume.MountOptions) (mountpoint string, err error)
 	// Unmount the given volume
 	Unmount(name, id string) (err error)
 	// List lists all the volumes known to the driver
@@ -44,6 +45,24 @@ type volumeDriver interface {
 	Get(name string) (volume *proxyVolume, err error)
 	// Capabilities gets the list of capabilities of the driver
 	Capabilities() (capabilities volume.Capability, err error)
+
+	// The following are only called on drivers advertising the matching
+	// capability.
+
+	// Resize changes the size of the given volume to size bytes
+	Resize(name string, size int64, force bool) (err error)
+	// CreateSnapshot takes a snapshot of the given volume
+	CreateSnapshot(name, snapshot string, freeze bool) (info *volume.Snapshot, err error)
+	// ListSnapshots lists the snapshots of the given volume
+	ListSnapshots(name string) (snapshots []volume.Snapshot, err error)
+	// RemoveSnapshot removes a snapshot of the given volume
+	RemoveSnapshot(name, snapshot string) (err error)
+	// RollbackSnapshot reverts the given volume to one of its snapshots
+	RollbackSnapshot(name, snapshot string) (err error)
+	// CreateFromSnapshot creates a volume from a snapshot of the source volume
+	CreateFromSnapshot(name, source, snapshot string, opts map[string]string) (err error)
+	// Usage returns the space used by the given volume
+	Usage(name string) (usage *volume.Usage, err error)
 }
 
 type driverExtpoint struct {
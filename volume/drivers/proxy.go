@@ -99,8 +99,9 @@ func (pp *volumeDriverProxy) Path(name string) (mountpoint string, err error) {
 }
 
 type volumeDriverProxyMountRequest struct {
-	Name string
-	ID   string
+	Name    string
+	ID      string
+	Options *volume.MountOptions
 }
 
 type volumeDriverProxyMountResponse struct {
@@ -108,7 +109,7 @@ type volumeDriverProxyMountResponse struct {
 	Err        string
 }
 
-func (pp *volumeDriverProxy) Mount(name string, id string) (mountpoint string, err error) {
+func (pp *volumeDriverProxy) Mount(name string, id string, options *volume.MountOptions) (mountpoint string, err error) {
 	var (
 		req volumeDriverProxyMountRequest
 		ret volumeDriverProxyMountResponse
@@ -116,6 +117,7 @@ func (pp *volumeDriverProxy) Mount(name string, id string) (mountpoint string, e
 
 	req.Name = name
 	req.ID = id
+	req.Options = options
 	if err = pp.Call("VolumeDriver.Mount", req, &ret); err != nil {
 		return
 	}
@@ -239,3 +241,212 @@ func (pp *volumeDriverProxy) Capabilities() (capabilities volume.Capability, err
 
 	return
 }
+
+type volumeDriverProxyResizeRequest struct {
+	Name  string
+	Size  int64
+	Force bool
+}
+
+type volumeDriverProxyResizeResponse struct {
+	Err string
+}
+
+func (pp *volumeDriverProxy) Resize(name string, size int64, force bool) (err error) {
+	var (
+		req volumeDriverProxyResizeRequest
+		ret volumeDriverProxyResizeResponse
+	)
+
+	req.Name = name
+	req.Size = size
+	req.Force = force
+	if err = pp.Call("VolumeDriver.Resize", req, &ret); err != nil {
+		return
+	}
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
+
+type volumeDriverProxyCreateSnapshotRequest struct {
+	Name     string
+	Snapshot string
+	Freeze   bool
+}
+
+type volumeDriverProxyCreateSnapshotResponse struct {
+	Info *volume.Snapshot
+	Err  string
+}
+
+func (pp *volumeDriverProxy) CreateSnapshot(name string, snapshot string, freeze bool) (info *volume.Snapshot, err error) {
+	var (
+		req volumeDriverProxyCreateSnapshotRequest
+		ret volumeDriverProxyCreateSnapshotResponse
+	)
+
+	req.Name = name
+	req.Snapshot = snapshot
+	req.Freeze = freeze
+	if err = pp.Call("VolumeDriver.CreateSnapshot", req, &ret); err != nil {
+		return
+	}
+
+	info = ret.Info
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
+
+type volumeDriverProxyListSnapshotsRequest struct {
+	Name string
+}
+
+type volumeDriverProxyListSnapshotsResponse struct {
+	Snapshots []volume.Snapshot
+	Err       string
+}
+
+func (pp *volumeDriverProxy) ListSnapshots(name string) (snapshots []volume.Snapshot, err error) {
+	var (
+		req volumeDriverProxyListSnapshotsRequest
+		ret volumeDriverProxyListSnapshotsResponse
+	)
+
+	req.Name = name
+	if err = pp.Call("VolumeDriver.ListSnapshots", req, &ret); err != nil {
+		return
+	}
+
+	snapshots = ret.Snapshots
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
+
+type volumeDriverProxyRemoveSnapshotRequest struct {
+	Name     string
+	Snapshot string
+}
+
+type volumeDriverProxyRemoveSnapshotResponse struct {
+	Err string
+}
+
+func (pp *volumeDriverProxy) RemoveSnapshot(name string, snapshot string) (err error) {
+	var (
+		req volumeDriverProxyRemoveSnapshotRequest
+		ret volumeDriverProxyRemoveSnapshotResponse
+	)
+
+	req.Name = name
+	req.Snapshot = snapshot
+	if err = pp.Call("VolumeDriver.RemoveSnapshot", req, &ret); err != nil {
+		return
+	}
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
+
+type volumeDriverProxyRollbackSnapshotRequest struct {
+	Name     string
+	Snapshot string
+}
+
+type volumeDriverProxyRollbackSnapshotResponse struct {
+	Err string
+}
+
+func (pp *volumeDriverProxy) RollbackSnapshot(name string, snapshot string) (err error) {
+	var (
+		req volumeDriverProxyRollbackSnapshotRequest
+		ret volumeDriverProxyRollbackSnapshotResponse
+	)
+
+	req.Name = name
+	req.Snapshot = snapshot
+	if err = pp.Call("VolumeDriver.RollbackSnapshot", req, &ret); err != nil {
+		return
+	}
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
+
+type volumeDriverProxyCreateFromSnapshotRequest struct {
+	Name     string
+	Source   string
+	Snapshot string
+	Opts     map[string]string
+}
+
+type volumeDriverProxyCreateFromSnapshotResponse struct {
+	Err string
+}
+
+func (pp *volumeDriverProxy) CreateFromSnapshot(name string, source string, snapshot string, opts map[string]string) (err error) {
+	var (
+		req volumeDriverProxyCreateFromSnapshotRequest
+		ret volumeDriverProxyCreateFromSnapshotResponse
+	)
+
+	req.Name = name
+	req.Source = source
+	req.Snapshot = snapshot
+	req.Opts = opts
+	if err = pp.Call("VolumeDriver.CreateFromSnapshot", req, &ret); err != nil {
+		return
+	}
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
+
+type volumeDriverProxyUsageRequest struct {
+	Name string
+}
+
+type volumeDriverProxyUsageResponse struct {
+	Usage *volume.Usage
+	Err   string
+}
+
+func (pp *volumeDriverProxy) Usage(name string) (usage *volume.Usage, err error) {
+	var (
+		req volumeDriverProxyUsageRequest
+		ret volumeDriverProxyUsageResponse
+	)
+
+	req.Name = name
+	if err = pp.Call("VolumeDriver.Usage", req, &ret); err != nil {
+		return
+	}
+
+	usage = ret.Usage
+
+	if ret.Err != "" {
+		err = errors.New(ret.Err)
+	}
+
+	return
+}
@@ -11,8 +11,10 @@ import (
 	"path/filepath"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/directory"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/utils"
@@ -25,6 +27,12 @@ import (
 const (
 	VolumeDataPathName = "_data"
 	volumesPathName    = "volumes"
+
+	// usageCacheTime is how long the usage of a volume is reused before its
+	// data directory is walked again
+	usageCacheTime = 30 * time.Second
+	// usageTimeout limits how long the filesystem of a volume is queried
+	usageTimeout = 5 * time.Second
 )
 
 var (
@@ -65,6 +73,7 @@ func New(scope string, rootUID, rootGID int) (*Root, error) {
 		volumes: make(map[string]*localVolume),
 		rootUID: rootUID,
 		rootGID: rootGID,
+		quota:   newProjectQuota(rootDirectory),
 	}
 
 	dirs, err := ioutil.ReadDir(rootDirectory)
@@ -97,6 +106,7 @@ func New(scope string, rootUID, rootGID int) (*Root, error) {
 			}
 			if !reflect.DeepEqual(opts, optsConfig{}) {
 				v.opts = &opts
+				r.loadQuota(v)
 			}
 
 			// unmount anything that may still be mounted (for example, from an unclean shutdown)
@@ -122,6 +132,9 @@ type Root struct {
 	volumes map[string]*localVolume
 	rootUID int
 	rootGID int
+	// quota sets the project quotas of volumes with a size limit, it is
+	// nil if the filesystem of the volumes doesn't support them
+	quota *projectQuota
 }
 
 // List lists all the volumes
@@ -186,6 +199,9 @@ func (r *Root) Create(name string, opts map[string]string) (volume.Volume, error
 		if err = setOpts(v, opts); err != nil {
 			return nil, err
 		}
+		if err = r.setupQuota(v); err != nil {
+			return nil, err
+		}
 		var b []byte
 		b, err = json.Marshal(v.opts)
 		if err != nil {
@@ -228,6 +244,7 @@ func (r *Root) Remove(v volume.Volume) error {
 	if err := removePath(realPath); err != nil {
 		return err
 	}
+	r.removeQuota(lv)
 
 	delete(r.volumes, lv.name)
 	return removePath(filepath.Dir(lv.path))
@@ -280,6 +297,16 @@ type localVolume struct {
 	opts *optsConfig
 	// active refcounts the active mounts
 	active activeMount
+	// quota is the project quota control of volumes whose size is limited
+	// by a project quota
+	quota *projectQuota
+
+	// usage is the last usage computed, at usageTime. It is guarded by
+	// usageM rather than m, so that walking a large volume doesn't hold
+	// mounts up.
+	usageM    sync.Mutex
+	usage     *volume.Usage
+	usageTime time.Time
 }
 
 // Name returns the name of the given Volume.
@@ -320,7 +347,7 @@ func (v *localVolume) Unmount(id string) error {
 	if v.opts != nil {
 		v.active.count--
 		if v.active.count == 0 {
-			if err := mount.Unmount(v.path); err != nil {
+			if err := v.unmount(); err != nil {
 				v.active.count++
 				return err
 			}
@@ -339,6 +366,41 @@ func validateOpts(opts map[string]string) error {
 	return nil
 }
 
+// Status returns the size limit of the volume, how it is enforced, and the
+// space used, for volumes created with a size.
 func (v *localVolume) Status() map[string]interface{} {
-	return nil
+	return v.quotaStatus()
+}
+
+// Usage walks the data directory of the volume to find the space and inodes
+// it uses, reusing the result of the last walk for usageCacheTime. The size
+// of the storage is that of the filesystem the directory is on. The usage of
+// volumes with a size limit is that of their quota or loopback filesystem.
+func (v *localVolume) Usage() (*volume.Usage, error) {
+	if v.hasSizeLimit() {
+		return v.quotaUsage()
+	}
+
+	v.usageM.Lock()
+	defer v.usageM.Unlock()
+
+	if v.usage != nil && time.Since(v.usageTime) < usageCacheTime {
+		u := *v.usage
+		return &u, nil
+	}
+	used, inodes, err := directory.Usage(v.path)
+	if err != nil {
+		return nil, err
+	}
+	u := volume.Usage{Used: used, InodesUsed: inodes, Reclaimable: v.opts == nil}
+	if fs, err := volume.FilesystemUsage(v.path, usageTimeout); err == nil {
+		u.Size = fs.Size
+		u.Available = fs.Available
+		u.Inodes = fs.Inodes
+	} else {
+		logrus.Debugf("Unable to get the filesystem usage of local volume '%s': %v", v.name, err)
+	}
+	v.usage = &u
+	v.usageTime = time.Now()
+	return &u, nil
 }
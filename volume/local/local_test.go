@@ -326,3 +326,39 @@ func TestRealodNoOpts(t *testing.T) {
 		}
 	}
 }
+
+func TestUsage(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "local-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := r.Create("data", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(v.Path(), "file"), make([]byte, 8192), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := v.(*localVolume).Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.InodesUsed != 2 || usage.Used <= 0 {
+		t.Fatalf("expected 2 inodes and some bytes used, got %+v", usage)
+	}
+
+	// the usage is cached
+	if err := ioutil.WriteFile(filepath.Join(v.Path(), "other"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cached, err := v.(*localVolume).Usage(); err != nil || cached.InodesUsed != 2 {
+		t.Fatalf("expected the cached usage, got %+v (%v)", cached, err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/docker/docker/pkg/mount"
+	"github.com/docker/go-units"
 )
 
 var (
@@ -20,6 +21,7 @@ var (
 		"type":   true, // specify the filesystem type for mount, e.g. nfs
 		"o":      true, // generic mount options
 		"device": true, // device to mount from
+		"size":   true, // size limit of a plain volume, e.g. 10G
 	}
 )
 
@@ -27,6 +29,11 @@ type optsConfig struct {
 	MountType   string
 	MountOpts   string
 	MountDevice string
+	// Size is the size limit of the volume in bytes, enforced by Quota,
+	// either quotaProject or quotaLoop.
+	Size      int64  `json:",omitempty"`
+	Quota     string `json:",omitempty"`
+	ProjectID uint32 `json:",omitempty"`
 }
 
 // scopedPath verifies that the path where the volume is located
@@ -58,12 +65,37 @@ func setOpts(v *localVolume, opts map[string]string) error {
 		MountOpts:   opts["o"],
 		MountDevice: opts["device"],
 	}
+	if value, ok := opts["size"]; ok {
+		if v.opts.MountType != "" || v.opts.MountOpts != "" || v.opts.MountDevice != "" {
+			return validationError{fmt.Errorf("the size option can't be combined with the type, o and device options")}
+		}
+		size, err := units.RAMInBytes(value)
+		if err != nil || size <= 0 {
+			return validationError{fmt.Errorf("invalid size %q, expected a positive size such as 10G", value)}
+		}
+		v.opts.Size = size
+	}
 	return nil
 }
 
+// hasSizeLimit returns whether the volume was created with a size limit.
+func (v *localVolume) hasSizeLimit() bool {
+	return v.opts != nil && v.opts.Size > 0
+}
+
 func (v *localVolume) mount() error {
+	if v.hasSizeLimit() {
+		return v.mountQuota()
+	}
 	if v.opts.MountDevice == "" {
 		return fmt.Errorf("missing device in volume options")
 	}
 	return mount.Mount(v.opts.MountDevice, v.path, v.opts.MountType, v.opts.MountOpts)
 }
+
+func (v *localVolume) unmount() error {
+	if v.hasSizeLimit() {
+		return v.unmountQuota()
+	}
+	return mount.Unmount(v.path)
+}
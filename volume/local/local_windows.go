@@ -29,6 +29,16 @@ func setOpts(v *localVolume, opts map[string]string) error {
 	return nil
 }
 
+// hasSizeLimit returns false, volumes can't be created with options on
+// this platform.
+func (v *localVolume) hasSizeLimit() bool {
+	return false
+}
+
 func (v *localVolume) mount() error {
 	return nil
 }
+
+func (v *localVolume) unmount() error {
+	return nil
+}
@@ -0,0 +1,362 @@
+package local
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/mount"
+	"github.com/docker/docker/volume"
+)
+
+const (
+	// quotaProject limits the size of a volume with an XFS project quota
+	// on its data directory
+	quotaProject = "project"
+	// quotaLoop limits the size of a volume by keeping its data in an ext4
+	// filesystem image, mounted through a loop device
+	quotaLoop = "loop"
+
+	loopImageName         = "disk.img"
+	backingFsBlockDevName = "backingFsBlockDev"
+
+	xfsSuperMagic = 0x58465342
+
+	// quotactl commands and flags, from linux/dqblk_xfs.h and
+	// linux/quota.h
+	qXGetQuota   = 0x5803
+	qXSetQLim    = 0x5804
+	qXGetQStat   = 0x5805
+	prjQuota     = 2
+	fsDquotVer   = 1
+	fsProjQuota  = 2
+	fsDqBSoft    = 1 << 2
+	fsDqBHard    = 1 << 3
+	fsQuotaPdqEn = 1 << 5
+
+	// ioctls getting and setting the project of a file, from linux/fs.h
+	fsIocFsGetXattr     = 0x801c581f
+	fsIocFsSetXattr     = 0x401c5820
+	fsXflagProjInherit  = 0x200
+	quotaBasicBlockSize = 512
+)
+
+// fsDiskQuota is struct fs_disk_quota of linux/dqblk_xfs.h.
+type fsDiskQuota struct {
+	Version      int8
+	Flags        int8
+	Fieldmask    uint16
+	ID           uint32
+	BlkHardlimit uint64
+	BlkSoftlimit uint64
+	InoHardlimit uint64
+	InoSoftlimit uint64
+	Bcount       uint64
+	Icount       uint64
+	Itimer       int32
+	Btimer       int32
+	Iwarns       uint16
+	Bwarns       uint16
+	Padding2     int32
+	RtbHardlimit uint64
+	RtbSoftlimit uint64
+	Rtbcount     uint64
+	Rtbtimer     int32
+	Rtbwarns     uint16
+	Padding3     int16
+	Padding4     [8]byte
+}
+
+// fsXattr is struct fsxattr of linux/fs.h.
+type fsXattr struct {
+	Xflags     uint32
+	Extsize    uint32
+	Nextents   uint32
+	Projid     uint32
+	Cowextsize uint32
+	Pad        [8]byte
+}
+
+// projectQuota limits the size of the data directories of volumes with XFS
+// project quotas. Every volume gets its own project, numbered from the
+// project of the volumes directory, plus one.
+type projectQuota struct {
+	m sync.Mutex
+	// backingFsBlockDev is a block device node of the filesystem of the
+	// volumes, which quotactl needs
+	backingFsBlockDev string
+	nextProjectID     uint32
+}
+
+// newProjectQuota returns the project quota control of the volumes in home,
+// or nil if their filesystem is not XFS with enforced project quotas.
+func newProjectQuota(home string) *projectQuota {
+	var buf syscall.Statfs_t
+	if err := syscall.Statfs(home, &buf); err != nil || buf.Type != xfsSuperMagic {
+		return nil
+	}
+	backingFsBlockDev, err := makeBackingFsBlockDev(home)
+	if err != nil {
+		logrus.Warnf("Unable to use the project quotas of %s, sized local volumes use loopback filesystems: %v", home, err)
+		return nil
+	}
+	if err := checkProjectQuotaEnforced(backingFsBlockDev); err != nil {
+		logrus.Infof("Project quotas are not enforced on %s, sized local volumes use loopback filesystems: %v", home, err)
+		return nil
+	}
+	id, err := getProjectID(home)
+	if err != nil {
+		logrus.Warnf("Unable to use the project quotas of %s, sized local volumes use loopback filesystems: %v", home, err)
+		return nil
+	}
+	return &projectQuota{backingFsBlockDev: backingFsBlockDev, nextProjectID: id + 1}
+}
+
+// makeBackingFsBlockDev creates a block device node of the filesystem of
+// home in home.
+func makeBackingFsBlockDev(home string) (string, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(home, &stat); err != nil {
+		return "", err
+	}
+	path := filepath.Join(home, backingFsBlockDevName)
+	if err := syscall.Unlink(path); err != nil && err != syscall.ENOENT {
+		return "", fmt.Errorf("failed to remove %s: %v", path, err)
+	}
+	if err := syscall.Mknod(path, syscall.S_IFBLK|0600, int(stat.Dev)); err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	return path, nil
+}
+
+func quotactl(cmd int, special string, id uint32, addr unsafe.Pointer) error {
+	specialBytes, err := syscall.BytePtrFromString(special)
+	if err != nil {
+		return err
+	}
+	if _, _, errno := syscall.Syscall6(syscall.SYS_QUOTACTL, uintptr(cmd<<8|prjQuota), uintptr(unsafe.Pointer(specialBytes)), uintptr(id), uintptr(addr), 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// checkProjectQuotaEnforced returns an error unless the filesystem of the
+// device enforces project quotas.
+func checkProjectQuotaEnforced(backingFsBlockDev string) error {
+	// struct fs_quota_stat starts with a byte of version and, aligned,
+	// 16 bits of flags
+	var stat [128]byte
+	if err := quotactl(qXGetQStat, backingFsBlockDev, 0, unsafe.Pointer(&stat[0])); err != nil {
+		return err
+	}
+	if flags := *(*uint16)(unsafe.Pointer(&stat[2])); flags&fsQuotaPdqEn == 0 {
+		return fmt.Errorf("mount the filesystem with the pquota option")
+	}
+	return nil
+}
+
+func getProjectID(path string) (uint32, error) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer dir.Close()
+	var attr fsXattr
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dir.Fd(), fsIocFsGetXattr, uintptr(unsafe.Pointer(&attr))); errno != 0 {
+		return 0, fmt.Errorf("failed to get the project of %s: %v", path, errno)
+	}
+	return attr.Projid, nil
+}
+
+// setProjectID puts the directory at path in the project, which the files
+// created in it inherit.
+func setProjectID(path string, id uint32) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	var attr fsXattr
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dir.Fd(), fsIocFsGetXattr, uintptr(unsafe.Pointer(&attr))); errno != 0 {
+		return fmt.Errorf("failed to get the project of %s: %v", path, errno)
+	}
+	attr.Projid = id
+	attr.Xflags |= fsXflagProjInherit
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dir.Fd(), fsIocFsSetXattr, uintptr(unsafe.Pointer(&attr))); errno != 0 {
+		return fmt.Errorf("failed to set the project of %s: %v", path, errno)
+	}
+	return nil
+}
+
+// setLimit limits the space used by the project to size bytes, or lifts
+// the limit if size is 0.
+func (q *projectQuota) setLimit(id uint32, size int64) error {
+	d := fsDiskQuota{
+		Version:      fsDquotVer,
+		Flags:        fsProjQuota,
+		Fieldmask:    fsDqBSoft | fsDqBHard,
+		ID:           id,
+		BlkHardlimit: uint64(size) / quotaBasicBlockSize,
+		BlkSoftlimit: uint64(size) / quotaBasicBlockSize,
+	}
+	if err := quotactl(qXSetQLim, q.backingFsBlockDev, id, unsafe.Pointer(&d)); err != nil {
+		return fmt.Errorf("failed to set the quota of project %d: %v", id, err)
+	}
+	return nil
+}
+
+// usage returns the space and inodes used by the project.
+func (q *projectQuota) usage(id uint32) (int64, int64, error) {
+	var d fsDiskQuota
+	if err := quotactl(qXGetQuota, q.backingFsBlockDev, id, unsafe.Pointer(&d)); err != nil {
+		return 0, 0, fmt.Errorf("failed to get the quota of project %d: %v", id, err)
+	}
+	return int64(d.Bcount) * quotaBasicBlockSize, int64(d.Icount), nil
+}
+
+// setup puts the directory at path in a new project limited to size bytes,
+// and returns the id of the project.
+func (q *projectQuota) setup(path string, size int64) (uint32, error) {
+	q.m.Lock()
+	defer q.m.Unlock()
+	id := q.nextProjectID
+	if err := setProjectID(path, id); err != nil {
+		return 0, err
+	}
+	if err := q.setLimit(id, size); err != nil {
+		return 0, err
+	}
+	q.nextProjectID++
+	return id, nil
+}
+
+// reserve records that the project is used by an existing volume.
+func (q *projectQuota) reserve(id uint32) {
+	q.m.Lock()
+	if id >= q.nextProjectID {
+		q.nextProjectID = id + 1
+	}
+	q.m.Unlock()
+}
+
+// setupQuota enforces the size limit of a new volume, with a project quota
+// if the filesystem of the volumes supports them, and with a loopback image
+// otherwise.
+func (r *Root) setupQuota(v *localVolume) error {
+	if !v.hasSizeLimit() {
+		return nil
+	}
+	if r.quota != nil {
+		id, err := r.quota.setup(v.path, v.opts.Size)
+		if err != nil {
+			logrus.Errorf("Failed to set the quota of local volume '%s': %v", v.name, err)
+			return err
+		}
+		v.opts.Quota = quotaProject
+		v.opts.ProjectID = id
+		v.quota = r.quota
+		return nil
+	}
+	return r.setupLoopQuota(v)
+}
+
+// loadQuota restores the size limit of a volume found when the driver
+// starts.
+func (r *Root) loadQuota(v *localVolume) {
+	if !v.hasSizeLimit() || v.opts.Quota != quotaProject {
+		return
+	}
+	if r.quota == nil {
+		logrus.Warnf("Project quotas are no longer enforced on %s, the size of local volume '%s' is not limited", r.path, v.name)
+		return
+	}
+	r.quota.reserve(v.opts.ProjectID)
+	v.quota = r.quota
+}
+
+// removeQuota lifts the project quota of a removed volume. Loopback images
+// are removed with the directory of the volume.
+func (r *Root) removeQuota(v *localVolume) {
+	if v.quota == nil {
+		return
+	}
+	if err := v.quota.setLimit(v.opts.ProjectID, 0); err != nil {
+		logrus.Warnf("Failed to lift the quota of removed local volume '%s': %v", v.name, err)
+	}
+}
+
+// loopImage returns the path of the loopback image of the volume, next to
+// its data directory.
+func (v *localVolume) loopImage() string {
+	return filepath.Join(filepath.Dir(v.path), loopImageName)
+}
+
+// mountQuota mounts the loopback image of the volume on its data directory.
+// Volumes limited by a project quota need no mount.
+func (v *localVolume) mountQuota() error {
+	if v.opts.Quota != quotaLoop {
+		return nil
+	}
+	return v.mountLoopImage()
+}
+
+func (v *localVolume) unmountQuota() error {
+	if v.opts.Quota != quotaLoop {
+		return nil
+	}
+	return mount.Unmount(v.path)
+}
+
+// quotaUsage returns the usage of a volume with a size limit, from its
+// project quota or, while it is mounted, its loopback filesystem.
+func (v *localVolume) quotaUsage() (*volume.Usage, error) {
+	switch v.opts.Quota {
+	case quotaProject:
+		if v.quota == nil {
+			return nil, fmt.Errorf("project quotas are not enforced on the filesystem of local volume '%s'", v.name)
+		}
+		used, inodes, err := v.quota.usage(v.opts.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		u := &volume.Usage{Size: v.opts.Size, Used: used, InodesUsed: inodes, Reclaimable: true}
+		if used < v.opts.Size {
+			u.Available = v.opts.Size - used
+		}
+		return u, nil
+	case quotaLoop:
+		v.m.Lock()
+		mounted := v.active.mounted
+		v.m.Unlock()
+		if !mounted {
+			return nil, fmt.Errorf("the usage of local volume '%s' is only known while it is mounted", v.name)
+		}
+		u, err := volume.FilesystemUsage(v.path, usageTimeout)
+		if err != nil {
+			return nil, err
+		}
+		u.Reclaimable = true
+		return u, nil
+	}
+	return nil, fmt.Errorf("unknown quota %q of local volume '%s'", v.opts.Quota, v.name)
+}
+
+// quotaStatus returns the size limit of a volume, how it is enforced and,
+// when known, the space used.
+func (v *localVolume) quotaStatus() map[string]interface{} {
+	if !v.hasSizeLimit() {
+		return nil
+	}
+	status := map[string]interface{}{
+		"Size":  v.opts.Size,
+		"Quota": v.opts.Quota,
+	}
+	if u, err := v.quotaUsage(); err == nil {
+		status["Used"] = u.Used
+	}
+	return status
+}
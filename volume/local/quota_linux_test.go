@@ -0,0 +1,33 @@
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateWithInvalidSize(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "local-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []map[string]string{
+		{"size": "big"},
+		{"size": "0"},
+		{"size": "10M", "device": "tmpfs", "type": "tmpfs"},
+	} {
+		if _, err := r.Create("test", opts); err == nil {
+			t.Fatalf("expected an error creating a volume with %v", opts)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(rootDir, volumesPathName, "test")); !os.IsNotExist(err) {
+		t.Fatalf("expected the directory of the volume to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+// +build linux,cgo
+
+package local
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/ctxexec"
+	"github.com/docker/docker/pkg/loopback"
+	"github.com/docker/docker/pkg/mount"
+	"golang.org/x/net/context"
+)
+
+// mkfsTimeout limits how long creating the filesystem of a loopback image
+// may take
+const mkfsTimeout = 10 * time.Minute
+
+// setupLoopQuota limits the size of a new volume with a loopback image.
+func (r *Root) setupLoopQuota(v *localVolume) error {
+	if err := createLoopImage(v.loopImage(), v.opts.Size, r.rootUID, r.rootGID); err != nil {
+		logrus.Errorf("Failed to create the loopback image of local volume '%s': %v", v.name, err)
+		return err
+	}
+	v.opts.Quota = quotaLoop
+	return nil
+}
+
+// createLoopImage creates a sparse image of size bytes holding an ext4
+// filesystem, whose root is owned by uid and gid.
+func createLoopImage(path string, size int64, uid, gid int) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	err = f.Truncate(size)
+	f.Close()
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), mkfsTimeout)
+	defer cancel()
+	rootOwner := "root_owner=" + strconv.Itoa(uid) + ":" + strconv.Itoa(gid)
+	if out, err := ctxexec.CombinedOutput(ctx, exec.Command("mkfs.ext4", "-q", "-F", "-m", "0", "-E", rootOwner, path)); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("mkfs.ext4 failed: %v - %s", err, out)
+	}
+	return nil
+}
+
+// mountLoopImage mounts the loopback image of the volume on its data
+// directory.
+func (v *localVolume) mountLoopImage() error {
+	loop, err := loopback.AttachLoopDevice(v.loopImage())
+	if err != nil {
+		return err
+	}
+	// the device is attached with autoclear, it is detached once closed
+	// and unmounted
+	defer loop.Close()
+	return mount.Mount(loop.Name(), v.path, "ext4", "")
+}
@@ -0,0 +1,93 @@
+// +build linux,cgo
+
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/pkg/mount"
+)
+
+func TestCreateWithSizeLoop(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("loopback images need root")
+	}
+	if _, err := exec.LookPath("mkfs.ext4"); err != nil {
+		t.Skip("mkfs.ext4 is not installed")
+	}
+	rootDir, err := ioutil.TempDir("", "local-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.quota != nil {
+		t.Skip("the volumes are limited by project quotas")
+	}
+	vol, err := r.Create("test", map[string]string{"size": "16M"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := vol.(*localVolume)
+	if v.opts.Quota != quotaLoop || v.opts.Size != 16*1024*1024 {
+		t.Fatalf("expected a 16M loopback volume, got %+v", v.opts)
+	}
+
+	dir, err := v.Mount("1234")
+	if err != nil {
+		t.Skipf("unable to mount a loopback image: %v", err)
+	}
+	if mounted, err := mount.Mounted(dir); err != nil || !mounted {
+		t.Fatalf("expected the image to be mounted on %s: %v", dir, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "file"), make([]byte, 1024*1024), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "big"), make([]byte, 32*1024*1024), 0644); err == nil {
+		t.Fatal("expected writing more than the size of the volume to fail")
+	}
+	os.Remove(filepath.Join(dir, "big"))
+
+	status := v.Status()
+	if status["Quota"] != quotaLoop || status["Size"] != int64(16*1024*1024) {
+		t.Fatalf("unexpected status %v", status)
+	}
+	if used, ok := status["Used"].(int64); !ok || used < 1024*1024 {
+		t.Fatalf("expected at least 1M used, got %v", status)
+	}
+
+	if err := v.Unmount("1234"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Usage(); err == nil {
+		t.Fatal("expected no usage for an unmounted loopback volume")
+	}
+
+	r, err = New(rootDir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, exists := r.volumes["test"]
+	if !exists {
+		t.Fatal("missing volume on restart")
+	}
+	if !reflect.DeepEqual(v.opts, v2.opts) {
+		t.Fatalf("expected options %+v on restart, got %+v", v.opts, v2.opts)
+	}
+
+	if err := r.Remove(v2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(v.loopImage()); !os.IsNotExist(err) {
+		t.Fatalf("expected the loopback image to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+// +build linux,!cgo
+
+package local
+
+import (
+	"errors"
+	"fmt"
+)
+
+// errLoopNotSupported is returned mounting the loopback image of a volume,
+// which needs cgo.
+var errLoopNotSupported = errors.New("loopback images are not supported by this build of the daemon")
+
+// setupLoopQuota rejects the size limit of a new volume: without cgo, it is
+// only enforced with project quotas.
+func (r *Root) setupLoopQuota(v *localVolume) error {
+	return validationError{fmt.Errorf("the size option needs project quotas enforced on the XFS filesystem of %s, loopback images are not supported by this build of the daemon", r.path)}
+}
+
+func (v *localVolume) mountLoopImage() error {
+	return errLoopNotSupported
+}
@@ -0,0 +1,34 @@
+// +build linux,!cgo
+
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateWithSizeNoLoop(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "local-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	r, err := New(rootDir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.quota != nil {
+		t.Skip("the volumes are limited by project quotas")
+	}
+	if _, err := r.Create("test", map[string]string{"size": "16M"}); err == nil {
+		t.Fatal("expected an error creating a sized volume without project quotas")
+	} else if _, ok := err.(validationError); !ok {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(rootDir, volumesPathName, "test")); !os.IsNotExist(err) {
+		t.Fatalf("expected the directory of the volume to be removed, got %v", err)
+	}
+}
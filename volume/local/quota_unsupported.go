@@ -0,0 +1,47 @@
+// +build !linux
+
+package local
+
+import (
+	"errors"
+
+	"github.com/docker/docker/volume"
+)
+
+var errSizeNotSupported = errors.New("the size option is not supported on this platform")
+
+// projectQuota is not supported on this platform.
+type projectQuota struct{}
+
+func newProjectQuota(home string) *projectQuota {
+	return nil
+}
+
+func (r *Root) setupQuota(v *localVolume) error {
+	if v.hasSizeLimit() {
+		return validationError{errSizeNotSupported}
+	}
+	return nil
+}
+
+func (r *Root) loadQuota(v *localVolume) {
+}
+
+func (r *Root) removeQuota(v *localVolume) {
+}
+
+func (v *localVolume) mountQuota() error {
+	return errSizeNotSupported
+}
+
+func (v *localVolume) unmountQuota() error {
+	return nil
+}
+
+func (v *localVolume) quotaUsage() (*volume.Usage, error) {
+	return nil, errSizeNotSupported
+}
+
+func (v *localVolume) quotaStatus() map[string]interface{} {
+	return nil
+}
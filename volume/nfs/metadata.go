@@ -0,0 +1,184 @@
+package nfsvolumedriver
+
+import (
+	b64 "encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/mount"
+)
+
+const (
+	// metadataDirName is the directory under the daemon root where the
+	// driver keeps one metadata file per volume.
+	metadataDirName     = "nfs_volumes"
+	metadataFileSuffix  = ".json"
+	metadataPermissions = 0600
+)
+
+// volumeMetadata is the on-disk representation of a Volume.
+type volumeMetadata struct {
+	Name          string
+	Source        string
+	HostDirectory string
+	// Users counts the unmatched Mount calls per container id
+	Users map[string]int `json:",omitempty"`
+	// UsedCount is the number of users recorded by earlier versions, which
+	// didn't track them by id
+	UsedCount int `json:",omitempty"`
+	// Options are the options the volume was created with
+	Options *volumeOptions `json:",omitempty"`
+}
+
+// metadataPath returns the path of the metadata file for the named volume.
+// Volume names are base64 encoded and may contain '/', so they are escaped.
+func (r *Root) metadataPath(name string) string {
+	return filepath.Join(r.path, strings.Replace(name, "/", "_", -1)+metadataFileSuffix)
+}
+
+// save persists the state of the volume. Callers must hold v.m.
+func (v *Volume) save() error {
+	if v.root == nil {
+		return nil
+	}
+	b, err := json.Marshal(&volumeMetadata{
+		Name:          v.name,
+		Source:        v.source,
+		HostDirectory: v.hostDirectory,
+		Users:         v.users,
+		Options:       v.opts,
+	})
+	if err != nil {
+		return err
+	}
+	if err := ioutils.AtomicWriteFile(v.root.metadataPath(v.name), b, metadataPermissions); err != nil {
+		logrus.Errorf("Failed to save metadata of NFS volume '%s': %v", v.name, err)
+		return err
+	}
+	return nil
+}
+
+// load reads all the volume metadata files found in the driver directory.
+func (r *Root) load() error {
+	files, err := ioutil.ReadDir(r.path)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), metadataFileSuffix) {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(r.path, f.Name()))
+		if err != nil {
+			return err
+		}
+		var meta volumeMetadata
+		if err := json.Unmarshal(b, &meta); err != nil {
+			logrus.Errorf("Ignoring invalid NFS volume metadata file %s: %v", f.Name(), err)
+			continue
+		}
+		if meta.Users == nil {
+			meta.Users = make(map[string]int)
+			if meta.UsedCount > 0 {
+				meta.Users[""] = meta.UsedCount
+			}
+		}
+		if meta.Options == nil {
+			meta.Options = &volumeOptions{}
+		}
+		r.volumes[meta.Name] = &Volume{
+			root:          r,
+			driverName:    r.Name(),
+			name:          meta.Name,
+			source:        meta.Source,
+			hostDirectory: meta.HostDirectory,
+			users:         meta.Users,
+			opts:          meta.Options,
+		}
+	}
+	return nil
+}
+
+// reconcile compares the loaded volumes with what is actually mounted on the
+// host. Mounts that are still in use (e.g. by containers that survived a
+// restart with --live-restore) are adopted and unused mounts are released.
+// Host directories the driver has no record of, left behind by earlier
+// versions that didn't persist their state, are adopted if they are still
+// mounted and removed otherwise.
+func (r *Root) reconcile() {
+	mountInfos, err := mount.GetMounts()
+	if err != nil {
+		logrus.Warnf("Unable to list mounts, not reconciling NFS volumes: %v", err)
+		return
+	}
+	mounted := make(map[string]*mount.Info)
+	for _, info := range mountInfos {
+		mounted[info.Mountpoint] = info
+	}
+
+	known := make(map[string]bool)
+	for name, v := range r.volumes {
+		known[v.hostDirectory] = true
+		if err := ensureDirectoryExists(v.hostDirectory); err != nil {
+			logrus.Warnf("Failed to recreate directory %s of NFS volume '%s': %v", v.hostDirectory, name, err)
+		}
+
+		if _, isMounted := mounted[v.hostDirectory]; !isMounted {
+			v.users = make(map[string]int)
+			v.mounted = false
+			v.save()
+			continue
+		}
+		if v.usedCount() > 0 {
+			logrus.Infof("Adopting mount of NFS volume '%s' on %s", name, v.hostDirectory)
+			v.mounted = true
+			continue
+		}
+		logrus.Infof("Releasing stale mount of NFS volume '%s' on %s", name, v.hostDirectory)
+		if err := r.unmount(v.hostDirectory); err != nil {
+			logrus.Warnf("Failed to release stale mount of NFS volume '%s': %v", name, err)
+		}
+	}
+
+	dirs, err := ioutil.ReadDir(r.mountsDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.Warnf("Unable to look for orphaned NFS mount directories: %v", err)
+		}
+		return
+	}
+	for _, d := range dirs {
+		dirName := filepath.Join(r.mountsDir, d.Name())
+		if !d.IsDir() || known[dirName] {
+			continue
+		}
+		info, isMounted := mounted[dirName]
+		if !isMounted {
+			logrus.Infof("Removing orphaned NFS mount directory %s", dirName)
+			if err := os.Remove(dirName); err != nil {
+				logrus.Warnf("Failed to remove orphaned NFS mount directory %s: %v", dirName, err)
+			}
+			continue
+		}
+		// The container that mounted it is unknown, assume a single user so
+		// that the mount is released when that container stops.
+		v := &Volume{
+			root:          r,
+			driverName:    r.Name(),
+			name:          b64.StdEncoding.EncodeToString([]byte(dirName)),
+			source:        strings.Replace(info.Source, ":/", "//", 1),
+			hostDirectory: dirName,
+			users:         map[string]int{"": 1},
+			mounted:       true,
+			opts:          &volumeOptions{},
+		}
+		logrus.Infof("Adopting orphaned mount of %s on %s as NFS volume '%s'", info.Source, dirName, v.name)
+		r.volumes[v.name] = v
+		v.save()
+	}
+}
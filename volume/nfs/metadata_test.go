@@ -0,0 +1,69 @@
+package nfsvolumedriver
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nfs-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := &Root{path: dir, volumes: make(map[string]*Volume)}
+	v := &Volume{
+		root:          r,
+		driverName:    r.Name(),
+		name:          "L3Zhci9saWIvZG9ja2VyL25mc19tb3VudHMvMTIz",
+		source:        "server//export",
+		hostDirectory: "/var/lib/docker/nfs_mounts/123",
+		users:         map[string]int{"abc": 1},
+		opts:          &volumeOptions{Access: AccessReadOnlyMany},
+	}
+	if err := v.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := &Root{path: dir, volumes: make(map[string]*Volume)}
+	if err := loaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	lv, exists := loaded.volumes[v.name]
+	if !exists {
+		t.Fatalf("expected volume to be loaded, got %v", loaded.volumes)
+	}
+	if lv.source != v.source || lv.hostDirectory != v.hostDirectory || lv.users["abc"] != 1 || lv.opts.access() != AccessReadOnlyMany {
+		t.Fatalf("unexpected loaded volume: %+v", lv)
+	}
+}
+
+func TestLoadLegacyUsedCount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nfs-volume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := &Root{path: dir, volumes: make(map[string]*Volume)}
+	b := []byte(`{"Name":"foo","Source":"server//export","HostDirectory":"/var/lib/docker/nfs_mounts/123","UsedCount":1}`)
+	if err := ioutil.WriteFile(r.metadataPath("foo"), b, metadataPermissions); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.load(); err != nil {
+		t.Fatal(err)
+	}
+	v := r.volumes["foo"]
+	if v == nil || v.usedCount() != 1 || v.opts.access() != AccessReadWriteOnce {
+		t.Fatalf("unexpected loaded volume: %+v", v)
+	}
+	// the legacy user is released by whichever container unmounts
+	if err := v.release("abc"); err != nil {
+		t.Fatal(err)
+	}
+	if v.usedCount() != 0 {
+		t.Fatalf("expected no users, got %v", v.users)
+	}
+}
@@ -1,34 +1,85 @@
 package nfsvolumedriver
 
 import (
+	"bytes"
 	b64 "encoding/base64"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/ctxexec"
 	"github.com/docker/docker/volume"
-	"github.com/opencontainers/runc/libcontainer"
+	"golang.org/x/net/context"
 )
 
 const (
-	NFS_MOUNTS_DIRECTORY             = "/var/lib/docker/nfs_mounts"
+	// NFS_MOUNTS_DIRECTORY is the directory under the daemon root where
+	// exports are mounted on the host.
+	NFS_MOUNTS_DIRECTORY             = "nfs_mounts"
 	NFS_MOUNTS_DIRECTORY_PERMISSIONS = 0755
+
+	// DefaultMountTimeout is how long mount and umount may run before they
+	// are killed.
+	DefaultMountTimeout = time.Minute
+	// mountTimeoutOpt is the daemon volume option overriding it
+	mountTimeoutOpt = "nfs.mount-timeout"
+	// statusTimeout limits how long Status waits for the usage of an export,
+	// which can't be read while the server is unreachable
+	statusTimeout = 5 * time.Second
 )
 
-func New() *Root {
-	return &Root{
-		volumes: make(map[string]*Volume),
+// New instantiates the NFS volume driver. The driver keeps the metadata of
+// its volumes in a directory under scope, the daemon root, and reloads it
+// here so that volumes and their host mounts survive daemon restarts.
+func New(scope string, opts map[string]string) (*Root, error) {
+	r := &Root{
+		path:         filepath.Join(scope, metadataDirName),
+		mountsDir:    filepath.Join(scope, NFS_MOUNTS_DIRECTORY),
+		volumes:      make(map[string]*Volume),
+		mountTimeout: DefaultMountTimeout,
+	}
+	if value, ok := opts[mountTimeoutOpt]; ok {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s %q, expected a positive duration such as 90s", mountTimeoutOpt, value)
+		}
+		r.mountTimeout = d
+	}
+	if err := os.MkdirAll(r.path, 0700); err != nil {
+		return nil, err
 	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.reconcile()
+	return r, nil
 }
 
 type Root struct {
-	m       sync.Mutex
-	volumes map[string]*Volume
+	m sync.Mutex
+	// path is the directory holding the volume metadata files
+	path string
+	// mountsDir is the directory holding the host directories of the volumes
+	mountsDir string
+	volumes   map[string]*Volume
+	// mountTimeout limits how long mount and umount may run
+	mountTimeout time.Duration
+}
+
+// mountContext returns the context mount and umount run under.
+func (r *Root) mountContext() (context.Context, context.CancelFunc) {
+	timeout := r.mountTimeout
+	if timeout <= 0 {
+		timeout = DefaultMountTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
 }
 
 func (r *Root) Name() string {
@@ -46,25 +97,37 @@ func ensureDirectoryExists(dirName string) error {
 	return os.MkdirAll(dirName, NFS_MOUNTS_DIRECTORY_PERMISSIONS)
 }
 
-func (r *Root) Create(name string, _ map[string]string) (volume.Volume, error) {
+func (r *Root) Create(name string, opts map[string]string) (volume.Volume, error) {
 	r.m.Lock()
 	defer r.m.Unlock()
 
-	err := ensureDirectoryExists(NFS_MOUNTS_DIRECTORY)
+	o, err := parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ensureDirectoryExists(r.mountsDir)
 	if err != nil {
 		return nil, err
 	}
 
-	dirName, err := ioutil.TempDir(NFS_MOUNTS_DIRECTORY, "")
+	dirName, err := ioutil.TempDir(r.mountsDir, "")
 	if err != nil {
 		return nil, err
 	}
 
 	v := &Volume{
+		root:          r,
 		driverName:    r.Name(),
 		name:          b64.StdEncoding.EncodeToString([]byte(dirName)),
 		hostDirectory: dirName,
 		source:        name,
+		users:         make(map[string]int),
+		opts:          o,
+	}
+	if err := v.save(); err != nil {
+		os.Remove(dirName)
+		return nil, err
 	}
 	r.volumes[v.name] = v
 
@@ -104,28 +167,42 @@ func (r *Root) Scope() string {
 }
 
 func (r *Root) Remove(v volume.Volume) error {
-	r.m.Lock()
-	defer r.m.Unlock()
-
 	lv, ok := v.(*Volume)
 	if !ok {
 		return errors.New("unknown volume type")
 	}
 
+	// Lock the volume before the driver, so that a hung mount of this
+	// volume doesn't block the other volumes
+	lv.m.Lock()
+	defer lv.m.Unlock()
+	r.m.Lock()
+	defer r.m.Unlock()
+
 	err := os.Remove(lv.hostDirectory)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to remove directory %s\n", lv.hostDirectory)
 	}
 
-	if lv.usedCount == 0 {
+	if lv.usedCount() == 0 {
+		if err := os.Remove(r.metadataPath(lv.name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
 		delete(r.volumes, lv.name)
 	}
 	return nil
 }
 
 type Volume struct {
-	m         sync.Mutex
-	usedCount int
+	m sync.Mutex
+	// users counts the Mount calls not yet matched by an Unmount call, per
+	// container id
+	users map[string]int
+	// mounted is whether the export is mounted on hostDirectory. It is
+	// mounted once, by the first user, and unmounted after the last one.
+	mounted bool
+	// root is the driver the volume belongs to, used to persist its state
+	root *Root
 	// volume unique identifier
 	name string
 	// driverName is the name of the driver that created the volume.
@@ -134,6 +211,8 @@ type Volume struct {
 	hostDirectory string
 	// name of the volume (src)
 	source string
+	// opts are the options the volume was created with
+	opts *volumeOptions
 }
 
 func (v *Volume) Name() string {
@@ -154,18 +233,29 @@ func (v *Volume) Mount(id string) (string, error) {
 	defer v.m.Unlock()
 
 	// Even if Mount() fails, Unmount will be called.
-	// So we increment usedCount ASAP to maintain the value
+	// So we register the user ASAP to maintain the count
 	// in a coherent way
-	if err := v.use(); err != nil {
+	err := v.use(id)
+	v.save()
+	if err != nil {
 		return "", err
 	}
 
-	// retry=0,timeo=30: Fail if NFS server can't be reached in 30 second (no retries) - aggressive, but necessary because the Docker daemon becomes unresponsive if the mount command hangs.
-	args := []string{"-o", "retry=0,timeo=30"}
-	source := strings.Replace(v.source, "//", "://", 1)
-	if err := libcontainer.DoMountCmd(v.DriverName(), source, v.hostDirectory, args); err != nil {
+	if v.mounted {
+		return v.hostDirectory, nil
+	}
+	args := []string{"-o", v.opts.mountOpts()}
+	source := v.opts.mountSource(v.source)
+	ctx, cancel := v.root.mountContext()
+	defer cancel()
+	if err := mountCmd(ctx, v.DriverName(), source, v.hostDirectory, args); err != nil {
+		if ctxexec.IsTimeout(err) {
+			// the mount may still complete after mount was killed
+			v.root.unmount(v.hostDirectory)
+		}
 		return "", err
 	}
+	v.mounted = true
 	return v.hostDirectory, nil
 }
 
@@ -173,45 +263,134 @@ func (v *Volume) Unmount(id string) error {
 	v.m.Lock()
 	defer v.m.Unlock()
 
-	if err := v.release(); err != nil {
+	if err := v.release(id); err != nil {
 		return err
 	}
+	v.save()
 
-	// Don't unmount if still being used
-	if v.usedCount > 0 {
+	// Don't unmount if still being used, or if mounting failed
+	if v.usedCount() > 0 || !v.mounted {
 		return nil
 	}
 
-	err := exec.Command("umount", "-l", v.hostDirectory).Run()
-	if err != nil {
+	if err := v.root.unmount(v.hostDirectory); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to unmount nfs device %s from %s\n", v.Name(), v.hostDirectory)
 		return err
 	}
+	v.mounted = false
 	return nil
 }
 
-func (v *Volume) Status() map[string]interface{} {
+// mountCmd runs mount(8) to mount source on dest with the given arguments,
+// killing it when ctx is done.
+func mountCmd(ctx context.Context, driverName, source, dest string, args []string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("mount", append([]string{source, dest}, args...)...)
+	cmd.Stderr = &stderr
+	if err := ctxexec.Run(ctx, cmd); err != nil {
+		if ctxexec.IsTimeout(err) {
+			logrus.Errorf("Failed to mount %s volume %s on %s with arguments %v: %v", driverName, source, dest, args, err)
+			return err
+		}
+		err = fmt.Errorf("Failed to mount %s volume %s on %s with arguments %v: %v - %s", driverName, source, dest, args, err, strings.TrimRight(stderr.String(), "\n"))
+		logrus.Error(err)
+		return err
+	}
 	return nil
 }
 
-func (v *Volume) use() error {
+// unmount lazily unmounts the NFS export mounted on the given host directory.
+func (r *Root) unmount(hostDirectory string) error {
+	ctx, cancel := r.mountContext()
+	defer cancel()
+	return ctxexec.Run(ctx, exec.Command("umount", "-l", hostDirectory))
+}
+
+// Status returns the export, the host directory it is mounted on, and the
+// users and usage of the volume, shown by `docker volume inspect`.
+func (v *Volume) Status() map[string]interface{} {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	server, export := v.opts.serverAndExport(v.source)
+	users := make(map[string]int)
+	for id, count := range v.users {
+		users[id] = count
+	}
+	status := map[string]interface{}{
+		"Server":        server,
+		"Export":        export,
+		"Access":        v.opts.access(),
+		"HostDirectory": v.hostDirectory,
+		"Mounted":       v.mounted,
+		"RefCount":      v.usedCount(),
+		"Users":         users,
+	}
+	if v.mounted {
+		if usage, err := volume.FilesystemUsage(v.hostDirectory, statusTimeout); err == nil {
+			status["Size"] = usage.Size
+			status["Used"] = usage.Used
+			status["Available"] = usage.Available
+		} else {
+			logrus.Debugf("Unable to get the usage of NFS volume '%s': %v", v.name, err)
+		}
+	}
+	return status
+}
+
+// Usage returns the usage of the export of the volume while it is mounted.
+// The export is shared with whatever else is stored on it, so the space used
+// is that of the whole export.
+func (v *Volume) Usage() (*volume.Usage, error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if !v.mounted {
+		return nil, fmt.Errorf("NFS volume '%s' is not mounted", v.name)
+	}
+	return volume.FilesystemUsage(v.hostDirectory, statusTimeout)
+}
+
+// use registers a Mount call by the container with the given id. Volumes
+// with the rwo access mode can only be used by one container at a time.
+func (v *Volume) use(id string) error {
 	// Note that the call to use() is assumed to be contained in a v.m.Lock()/Unlock() (the mutex isn't reentrant, so we can't lock it again here)
-	v.usedCount++ // If Mount() fails, Unmount() (and therefore release()) will be called, so we need to increment usedCount even if the volume is already in use
-	if v.usedCount > 1 {
-		msg := fmt.Sprintf("The NFS volume '%s' is already being used by a running container in this Docker daemon", v.Name())
-		logrus.Errorf(msg)
+	others := v.usedCount() - v.users[id]
+	v.users[id]++ // If Mount() fails, Unmount() (and therefore release()) will be called, so we need to register the user even if the volume is already in use
+	if others > 0 && !v.opts.shared() {
+		msg := fmt.Sprintf("The NFS volume '%s' is already being used by a running container in this Docker daemon, create it with -o access=%s or -o access=%s to share it", v.Name(), AccessReadOnlyMany, AccessReadWriteMany)
+		logrus.Error(msg)
 		return errors.New(msg)
 	}
 	return nil
 }
 
-func (v *Volume) release() error {
+// release unregisters a Mount call by the container with the given id.
+func (v *Volume) release(id string) error {
 	// Note that the call to release() is assumed to be contained in a v.m.Lock()/Unlock() (the mutex isn't reentrant, so we can't lock it again here)
-	if v.usedCount == 0 { // Shouldn't happen as long as Docker calls Mount()/Unmount() the way we think, but we've misunderstood the call sequence before
-		msg := fmt.Sprintf("The NFS volume '%s' is being released more times than it has been used", v.Name())
-		logrus.Errorf(msg)
+	if v.users[id] == 0 && v.users[""] > 0 {
+		// users adopted on restart, or recorded by earlier versions, are
+		// not known by id
+		id = ""
+	}
+	if v.users[id] == 0 { // Shouldn't happen as long as Docker calls Mount()/Unmount() the way we think, but we've misunderstood the call sequence before
+		msg := fmt.Sprintf("The NFS volume '%s' is being released more times than it has been used by %s", v.Name(), id)
+		logrus.Error(msg)
 		return errors.New(msg)
 	}
-	v.usedCount--
+	v.users[id]--
+	if v.users[id] == 0 {
+		delete(v.users, id)
+	}
 	return nil
 }
+
+// usedCount returns the number of Mount calls not yet matched by an Unmount
+// call.
+func (v *Volume) usedCount() int {
+	count := 0
+	for _, c := range v.users {
+		count += c
+	}
+	return count
+}
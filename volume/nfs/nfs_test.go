@@ -0,0 +1,66 @@
+package nfsvolumedriver
+
+import (
+	"testing"
+)
+
+func TestUseRelease(t *testing.T) {
+	cases := []struct {
+		access string
+		shared bool
+	}{
+		{AccessReadWriteOnce, false},
+		{AccessReadOnlyMany, true},
+		{AccessReadWriteMany, true},
+	}
+	for _, c := range cases {
+		v := &Volume{name: "foo", users: make(map[string]int), opts: &volumeOptions{Access: c.access}}
+		if err := v.use("a"); err != nil {
+			t.Fatalf("%s: %v", c.access, err)
+		}
+		// a container can mount the volume more than once
+		if err := v.use("a"); err != nil {
+			t.Fatalf("%s: %v", c.access, err)
+		}
+		err := v.use("b")
+		if c.shared && err != nil {
+			t.Fatalf("%s: expected the volume to be shared, got %v", c.access, err)
+		}
+		if !c.shared && err == nil {
+			t.Fatalf("%s: expected the volume not to be shared", c.access)
+		}
+		// Unmount is called even when Mount failed
+		if v.usedCount() != 3 {
+			t.Fatalf("%s: expected 3 users, got %v", c.access, v.users)
+		}
+		for _, id := range []string{"b", "a", "a"} {
+			if err := v.release(id); err != nil {
+				t.Fatalf("%s: %v", c.access, err)
+			}
+		}
+		if v.usedCount() != 0 {
+			t.Fatalf("%s: expected no users, got %v", c.access, v.users)
+		}
+		if err := v.release("a"); err == nil {
+			t.Fatalf("%s: expected an error releasing an unused volume", c.access)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	v := &Volume{
+		name:          "foo",
+		source:        "10.0.0.1//export",
+		hostDirectory: "/var/lib/docker/nfs_mounts/123",
+		users:         map[string]int{"a": 1, "b": 2},
+		opts:          &volumeOptions{Access: AccessReadWriteMany},
+	}
+	status := v.Status()
+	if status["Server"] != "10.0.0.1" || status["Export"] != "/export" || status["Access"] != AccessReadWriteMany ||
+		status["HostDirectory"] != v.hostDirectory || status["Mounted"] != false || status["RefCount"] != 3 {
+		t.Fatalf("unexpected status %v", status)
+	}
+	if users := status["Users"].(map[string]int); users["b"] != 2 {
+		t.Fatalf("unexpected users %v", users)
+	}
+}
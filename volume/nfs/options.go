@@ -0,0 +1,173 @@
+package nfsvolumedriver
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	// AccessReadWriteOnce allows a single container to use the volume.
+	AccessReadWriteOnce = "rwo"
+	// AccessReadOnlyMany allows any number of containers to use the volume,
+	// which is mounted read-only.
+	AccessReadOnlyMany = "rox"
+	// AccessReadWriteMany allows any number of containers to use the volume
+	// read-write.
+	AccessReadWriteMany = "rwx"
+
+	// defaultMountOpts are used unless the `opts` option is given.
+	// retry=0,timeo=30: Fail if NFS server can't be reached in 30 second (no retries) - aggressive, but necessary because the Docker daemon becomes unresponsive if the mount command hangs.
+	defaultMountOpts = "retry=0,timeo=30"
+)
+
+var (
+	validOpts = map[string]bool{
+		"access":  true, // access mode, one of rwo, rox or rwx
+		"server":  true, // NFS server, instead of the one in the volume name
+		"export":  true, // path exported by the server
+		"nfsvers": true, // NFS protocol version
+		"opts":    true, // comma separated mount options, replacing the defaults
+	}
+
+	validNfsVersions = map[string]bool{
+		"3":   true,
+		"4":   true,
+		"4.0": true,
+		"4.1": true,
+		"4.2": true,
+	}
+
+	// reservedMountOpts are set through other options
+	reservedMountOpts = map[string]string{
+		"vers":    "nfsvers",
+		"nfsvers": "nfsvers",
+		"ro":      "access",
+		"rw":      "access",
+	}
+
+	validAccessModes = map[string]bool{
+		AccessReadWriteOnce: true,
+		AccessReadOnlyMany:  true,
+		AccessReadWriteMany: true,
+	}
+)
+
+type validationError struct {
+	error
+}
+
+func (validationError) IsValidationError() bool {
+	return true
+}
+
+// volumeOptions are the options given to `docker volume create` for an NFS
+// volume. They are validated when the volume is created and persisted with
+// the rest of its metadata.
+type volumeOptions struct {
+	// Access is the access mode of the volume
+	Access string `json:",omitempty"`
+	// Server and Export locate the export, if not given by the volume name
+	Server string `json:",omitempty"`
+	Export string `json:",omitempty"`
+	// NfsVers is the NFS protocol version to mount with
+	NfsVers string `json:",omitempty"`
+	// MountOpts replaces the default mount options
+	MountOpts []string `json:",omitempty"`
+}
+
+// parseOptions validates the options given to Create.
+func parseOptions(opts map[string]string) (*volumeOptions, error) {
+	o := &volumeOptions{
+		Access: AccessReadWriteOnce,
+	}
+	for key, value := range opts {
+		if !validOpts[key] {
+			return nil, validationError{fmt.Errorf("invalid option key: %q", key)}
+		}
+		switch key {
+		case "access":
+			if !validAccessModes[value] {
+				return nil, validationError{fmt.Errorf("invalid access mode %q, expected %s, %s or %s", value, AccessReadWriteOnce, AccessReadOnlyMany, AccessReadWriteMany)}
+			}
+			o.Access = value
+		case "server":
+			if value == "" || strings.ContainsAny(value, "/, \t") {
+				return nil, validationError{fmt.Errorf("invalid NFS server %q", value)}
+			}
+			o.Server = value
+		case "export":
+			if !strings.HasPrefix(value, "/") || strings.ContainsAny(value, ", \t") {
+				return nil, validationError{fmt.Errorf("invalid NFS export %q, expected an absolute path", value)}
+			}
+			o.Export = value
+		case "nfsvers":
+			if !validNfsVersions[value] {
+				return nil, validationError{fmt.Errorf("unsupported NFS version %q, supported versions are 3, 4, 4.0, 4.1 and 4.2", value)}
+			}
+			o.NfsVers = value
+		case "opts":
+			o.MountOpts = []string{}
+			for _, opt := range strings.Split(value, ",") {
+				if opt == "" || strings.ContainsAny(opt, " \t") {
+					return nil, validationError{fmt.Errorf("invalid mount options %q", value)}
+				}
+				if replacement, ok := reservedMountOpts[strings.SplitN(opt, "=", 2)[0]]; ok {
+					return nil, validationError{fmt.Errorf("mount option %q is set with the %s option", opt, replacement)}
+				}
+				o.MountOpts = append(o.MountOpts, opt)
+			}
+		}
+	}
+	if (o.Server == "") != (o.Export == "") {
+		return nil, validationError{fmt.Errorf("the server and export options must be given together")}
+	}
+	return o, nil
+}
+
+// access returns the access mode of the volume, defaulting to rwo for
+// volumes whose metadata predates the option.
+func (o *volumeOptions) access() string {
+	if o == nil || o.Access == "" {
+		return AccessReadWriteOnce
+	}
+	return o.Access
+}
+
+// shared returns whether several containers may use the volume at once.
+func (o *volumeOptions) shared() bool {
+	return o.access() != AccessReadWriteOnce
+}
+
+// mountOpts returns the options the export is mounted on the host with.
+func (o *volumeOptions) mountOpts() string {
+	opts := []string{defaultMountOpts}
+	if o != nil && o.MountOpts != nil {
+		opts = o.MountOpts
+	}
+	if o != nil && o.NfsVers != "" {
+		opts = append(opts, "nfsvers="+o.NfsVers)
+	}
+	if o.access() == AccessReadOnlyMany {
+		opts = append(opts, "ro")
+	}
+	return strings.Join(opts, ",")
+}
+
+// mountSource returns the export to mount, "server:/path". Volumes created
+// without the server and export options name it as "server//path".
+func (o *volumeOptions) mountSource(name string) string {
+	if o != nil && o.Server != "" {
+		return o.Server + ":" + o.Export
+	}
+	return strings.Replace(name, "//", "://", 1)
+}
+
+// serverAndExport returns the server and the path of the export.
+func (o *volumeOptions) serverAndExport(name string) (string, string) {
+	parts := strings.SplitN(o.mountSource(name), ":", 2)
+	if len(parts) != 2 {
+		return "", parts[0]
+	}
+	return parts[0], path.Clean(parts[1])
+}
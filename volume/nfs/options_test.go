@@ -0,0 +1,65 @@
+package nfsvolumedriver
+
+import (
+	"testing"
+)
+
+func TestParseOptions(t *testing.T) {
+	o, err := parseOptions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.access() != AccessReadWriteOnce || o.mountOpts() != "retry=0,timeo=30" {
+		t.Fatalf("unexpected default options: %+v", o)
+	}
+	if source := o.mountSource("server//export/dir"); source != "server://export/dir" {
+		t.Fatalf("expected source from the volume name, got %q", source)
+	}
+	if server, export := o.serverAndExport("server//export/dir"); server != "server" || export != "/export/dir" {
+		t.Fatalf("unexpected server %q and export %q", server, export)
+	}
+
+	o, err = parseOptions(map[string]string{"access": "rox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.shared() || o.mountOpts() != "retry=0,timeo=30,ro" {
+		t.Fatalf("unexpected options: %+v", o)
+	}
+
+	o, err = parseOptions(map[string]string{
+		"server":  "10.0.0.1",
+		"export":  "/export/dir",
+		"nfsvers": "4.1",
+		"opts":    "hard,intr,rsize=65536",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts := o.mountOpts(); opts != "hard,intr,rsize=65536,nfsvers=4.1" {
+		t.Fatalf("unexpected mount options %q", opts)
+	}
+	if source := o.mountSource("foo"); source != "10.0.0.1:/export/dir" {
+		t.Fatalf("unexpected source %q", source)
+	}
+}
+
+func TestParseOptionsInvalid(t *testing.T) {
+	for _, opts := range []map[string]string{
+		{"access": "rw"},
+		{"foo": "bar"},
+		{"server": "10.0.0.1"},
+		{"export": "/export"},
+		{"server": "10.0.0.1/x", "export": "/export"},
+		{"server": "10.0.0.1", "export": "export"},
+		{"nfsvers": "2"},
+		{"opts": "hard,,intr"},
+		{"opts": "hard, intr"},
+		{"opts": "vers=4"},
+		{"opts": "ro"},
+	} {
+		if _, err := parseOptions(opts); err == nil {
+			t.Fatalf("expected an error for %v", opts)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package store
 import (
 	"errors"
 	"strings"
+
+	"github.com/docker/docker/volume"
 )
 
 var (
@@ -14,6 +16,8 @@ var (
 	errInvalidName = errors.New("volume name is not valid on this platform")
 	// errNameConflict is a typed error returned on create when a volume exists with the given name, but for a different driver
 	errNameConflict = errors.New("conflict: volume name must be unique")
+	// errNotSupported is a typed error returned when the volume driver does not support the requested operation
+	errNotSupported = volume.ErrNotSupported
 )
 
 // OpErr is the error type returned by functions in the store package. It describes
@@ -63,6 +67,36 @@ func IsNameConflict(err error) bool {
 	return isErr(err, errNameConflict)
 }
 
+// IsNotSupported returns a boolean indicating whether the error indicates that
+// the volume driver does not support the operation
+func IsNotSupported(err error) bool {
+	return isErr(err, errNotSupported)
+}
+
+// IsTimeout returns a boolean indicating whether the error indicates that the
+// volume driver gave up on a helper that did not complete in time
+func IsTimeout(err error) bool {
+	if pe, ok := err.(*OpErr); ok {
+		err = pe.Err
+	}
+	t, ok := err.(interface {
+		Timeout() bool
+	})
+	return ok && t.Timeout()
+}
+
+// IsValidation returns a boolean indicating whether the error indicates that
+// the volume driver rejected the parameters of the operation
+func IsValidation(err error) bool {
+	if pe, ok := err.(*OpErr); ok {
+		err = pe.Err
+	}
+	v, ok := err.(interface {
+		IsValidationError() bool
+	})
+	return ok && v.IsValidationError()
+}
+
 func isErr(err error, expected error) bool {
 	switch pe := err.(type) {
 	case nil:
@@ -23,6 +23,9 @@ const (
 type volumeMetadata struct {
 	Name   string
 	Labels map[string]string
+	// CreatedAt is when the volume was created, zero for volumes created
+	// before it was recorded.
+	CreatedAt time.Time
 }
 
 type volumeWrapper struct {
@@ -48,14 +51,24 @@ func (v volumeWrapper) CachedPath() string {
 	return v.Volume.Path()
 }
 
+// MountWithOptions passes the options the volume is mounted with on to its
+// driver, if it takes them into account.
+func (v volumeWrapper) MountWithOptions(id string, opts volume.MountOptions) (string, error) {
+	if om, ok := v.Volume.(volume.OptionsMounter); ok {
+		return om.MountWithOptions(id, opts)
+	}
+	return v.Volume.Mount(id)
+}
+
 // New initializes a VolumeStore to keep
 // reference counting of volumes in the system.
 func New(rootPath string) (*VolumeStore, error) {
 	vs := &VolumeStore{
-		locks:  &locker.Locker{},
-		names:  make(map[string]volume.Volume),
-		refs:   make(map[string][]string),
-		labels: make(map[string]map[string]string),
+		locks:   &locker.Locker{},
+		names:   make(map[string]volume.Volume),
+		refs:    make(map[string][]string),
+		labels:  make(map[string]map[string]string),
+		created: make(map[string]time.Time),
 	}
 
 	if rootPath != "" {
@@ -83,11 +96,33 @@ func New(rootPath string) (*VolumeStore, error) {
 		}); err != nil {
 			return nil, err
 		}
+		if err := vs.loadMetadata(); err != nil {
+			return nil, err
+		}
 	}
 
 	return vs, nil
 }
 
+// loadMetadata reads the labels and creation times of the volumes from the
+// metadata store.
+func (s *VolumeStore) loadMetadata() error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(volumeBucketName)).ForEach(func(name, data []byte) error {
+			var meta volumeMetadata
+			if err := json.Unmarshal(data, &meta); err != nil {
+				logrus.Errorf("Ignoring invalid metadata of volume %s: %v", name, err)
+				return nil
+			}
+			s.labels[string(name)] = meta.Labels
+			if !meta.CreatedAt.IsZero() {
+				s.created[string(name)] = meta.CreatedAt
+			}
+			return nil
+		})
+	})
+}
+
 func (s *VolumeStore) getNamed(name string) (volume.Volume, bool) {
 	s.globalLock.RLock()
 	v, exists := s.names[name]
@@ -118,6 +153,7 @@ func (s *VolumeStore) purge(name string) {
 	delete(s.names, name)
 	delete(s.refs, name)
 	delete(s.labels, name)
+	delete(s.created, name)
 	err := s.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(volumeBucketName))
 		return b.Delete([]byte(name))
@@ -142,7 +178,9 @@ type VolumeStore struct {
 	refs map[string][]string
 	// labels stores volume labels for each volume
 	labels map[string]map[string]string
-	db     *bolt.DB
+	// created stores when each volume was created
+	created map[string]time.Time
+	db      *bolt.DB
 }
 
 // List proxies to all registered volume drivers to get the full list of volumes
@@ -313,19 +351,32 @@ func (s *VolumeStore) create(name, driverName string, opts, labels map[string]st
 	if err != nil {
 		return nil, err
 	}
+	if err := s.setMetadata(name, labels); err != nil {
+		return nil, err
+	}
+
+	return volumeWrapper{v, labels, vd.Scope()}, nil
+}
+
+// setMetadata records the labels and the creation time of a newly created
+// volume and persists them.
+func (s *VolumeStore) setMetadata(name string, labels map[string]string) error {
+	now := time.Now()
 	s.globalLock.Lock()
 	s.labels[name] = labels
+	s.created[name] = now
 	s.globalLock.Unlock()
 
 	if s.db != nil {
 		metadata := &volumeMetadata{
-			Name:   name,
-			Labels: labels,
+			Name:      name,
+			Labels:    labels,
+			CreatedAt: now,
 		}
 
 		volData, err := json.Marshal(metadata)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if err := s.db.Update(func(tx *bolt.Tx) error {
@@ -333,11 +384,68 @@ func (s *VolumeStore) create(name, driverName string, opts, labels map[string]st
 			err := b.Put([]byte(name), volData)
 			return err
 		}); err != nil {
-			return nil, err
+			return err
 		}
 	}
+	return nil
+}
 
-	return volumeWrapper{v, labels, vd.Scope()}, nil
+// CreateFromSnapshot creates a volume with the given name from a snapshot of
+// the source volume, using the driver of the source volume.
+func (s *VolumeStore) CreateFromSnapshot(name, source, snapshot string, opts, labels map[string]string) (volume.Volume, error) {
+	logrus.Debugf("VolumeStore##CreateFromSnapshot %s - %s@%s - %s - %s", name, source, snapshot, opts, labels)
+	name = normaliseVolumeName(name)
+	source = normaliseVolumeName(source)
+	if name == source {
+		return nil, &OpErr{Err: errNameConflict, Name: name, Op: "create"}
+	}
+	// lock in a fixed order so that concurrent clones can't deadlock
+	first, second := source, name
+	if second < first {
+		first, second = second, first
+	}
+	s.locks.Lock(first)
+	defer s.locks.Unlock(first)
+	s.locks.Lock(second)
+	defer s.locks.Unlock(second)
+
+	valid, err := volume.IsVolumeNameValid(name)
+	if err != nil {
+		return nil, &OpErr{Err: err, Name: name, Op: "create"}
+	}
+	if !valid {
+		return nil, &OpErr{Err: errInvalidName, Name: name, Op: "create"}
+	}
+	if _, exists := s.getNamed(name); exists {
+		return nil, &OpErr{Err: errNameConflict, Name: name, Op: "create"}
+	}
+
+	src, err := s.getVolume(source)
+	if err != nil {
+		return nil, &OpErr{Err: err, Name: source, Op: "create"}
+	}
+	vd, err := volumedrivers.GetDriver(src.DriverName())
+	if err != nil {
+		return nil, &OpErr{Err: err, Name: name, Op: "create"}
+	}
+	sd, ok := vd.(volume.SnapshotDriver)
+	if !ok {
+		return nil, &OpErr{Err: errNotSupported, Name: name, Op: "create"}
+	}
+	if v, _ := vd.Get(name); v != nil {
+		return nil, &OpErr{Err: errNameConflict, Name: name, Op: "create"}
+	}
+
+	v, err := sd.CreateFromSnapshot(name, unwrapVolume(src), snapshot, opts)
+	if err != nil {
+		return nil, &OpErr{Err: err, Name: name, Op: "create"}
+	}
+	if err := s.setMetadata(name, labels); err != nil {
+		return nil, &OpErr{Err: err, Name: name, Op: "create"}
+	}
+	v = volumeWrapper{v, labels, vd.Scope()}
+	s.setNamed(v, "")
+	return v, nil
 }
 
 // GetWithRef gets a volume with the given name from the passed in driver and stores the ref
@@ -469,6 +577,176 @@ func (s *VolumeStore) Remove(v volume.Volume) error {
 	return nil
 }
 
+// RotateKey replaces the encryption key of the named volume, if its driver
+// supports encryption.
+func (s *VolumeStore) RotateKey(name string) error {
+	name = normaliseVolumeName(name)
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	v, err := s.getVolume(name)
+	if err != nil {
+		return &OpErr{Err: err, Name: name, Op: "rotate-key"}
+	}
+	kr, ok := unwrapVolume(v).(volume.KeyRotator)
+	if !ok {
+		return &OpErr{Err: errNotSupported, Name: name, Op: "rotate-key"}
+	}
+	if err := kr.RotateKey(); err != nil {
+		return &OpErr{Err: err, Name: name, Op: "rotate-key"}
+	}
+	return nil
+}
+
+// Resize changes the size of the named volume, if its driver supports it.
+func (s *VolumeStore) Resize(name string, size int64, force bool) error {
+	name = normaliseVolumeName(name)
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	v, err := s.getVolume(name)
+	if err != nil {
+		return &OpErr{Err: err, Name: name, Op: "resize"}
+	}
+	r, ok := unwrapVolume(v).(volume.Resizer)
+	if !ok {
+		return &OpErr{Err: errNotSupported, Name: name, Op: "resize"}
+	}
+	if err := r.Resize(size, force); err != nil {
+		return &OpErr{Err: err, Name: name, Op: "resize"}
+	}
+	return nil
+}
+
+// Unlock breaks the lock held on the named volume, if its driver locks
+// volumes. Locks that are still in use are only broken if force is set.
+func (s *VolumeStore) Unlock(name string, force bool) error {
+	name = normaliseVolumeName(name)
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	v, err := s.getVolume(name)
+	if err != nil {
+		return &OpErr{Err: err, Name: name, Op: "unlock"}
+	}
+	u, ok := unwrapVolume(v).(volume.Unlocker)
+	if !ok {
+		return &OpErr{Err: errNotSupported, Name: name, Op: "unlock"}
+	}
+	if err := u.Unlock(force); err != nil {
+		return &OpErr{Err: err, Name: name, Op: "unlock"}
+	}
+	return nil
+}
+
+// CreatedAt returns when the named volume was created, or the zero time if
+// it is not known, for volumes created before it was recorded.
+func (s *VolumeStore) CreatedAt(name string) time.Time {
+	s.globalLock.RLock()
+	defer s.globalLock.RUnlock()
+	return s.created[normaliseVolumeName(name)]
+}
+
+// Usage returns the space used by the volume, if its driver can report it.
+// The volume is not locked: computing its usage may take a while, and does
+// not change it.
+func (s *VolumeStore) Usage(v volume.Volume) (*volume.Usage, error) {
+	sr, ok := unwrapVolume(v).(volume.SizeReporter)
+	if !ok {
+		return nil, &OpErr{Err: errNotSupported, Name: v.Name(), Op: "usage"}
+	}
+	usage, err := sr.Usage()
+	if err != nil {
+		return nil, &OpErr{Err: err, Name: v.Name(), Op: "usage"}
+	}
+	return usage, nil
+}
+
+// snapshotter returns the named volume if its driver supports snapshots.
+// Callers must hold the lock of the volume.
+func (s *VolumeStore) snapshotter(name, op string) (volume.Snapshotter, error) {
+	v, err := s.getVolume(name)
+	if err != nil {
+		return nil, &OpErr{Err: err, Name: name, Op: op}
+	}
+	sv, ok := unwrapVolume(v).(volume.Snapshotter)
+	if !ok {
+		return nil, &OpErr{Err: errNotSupported, Name: name, Op: op}
+	}
+	return sv, nil
+}
+
+// CreateSnapshot takes a snapshot of the named volume, if its driver
+// supports snapshots. If freeze is set, the filesystem of a mounted volume is
+// frozen while the snapshot is taken.
+func (s *VolumeStore) CreateSnapshot(name, snapshot string, freeze bool) (volume.Snapshot, error) {
+	name = normaliseVolumeName(name)
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	sv, err := s.snapshotter(name, "snapshot")
+	if err != nil {
+		return volume.Snapshot{}, err
+	}
+	snap, err := sv.CreateSnapshot(snapshot, freeze)
+	if err != nil {
+		return volume.Snapshot{}, &OpErr{Err: err, Name: name, Op: "snapshot"}
+	}
+	return snap, nil
+}
+
+// Snapshots lists the snapshots of the named volume, if its driver supports
+// snapshots.
+func (s *VolumeStore) Snapshots(name string) ([]volume.Snapshot, error) {
+	name = normaliseVolumeName(name)
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	sv, err := s.snapshotter(name, "list snapshots")
+	if err != nil {
+		return nil, err
+	}
+	snaps, err := sv.Snapshots()
+	if err != nil {
+		return nil, &OpErr{Err: err, Name: name, Op: "list snapshots"}
+	}
+	return snaps, nil
+}
+
+// RemoveSnapshot removes a snapshot of the named volume, if its driver
+// supports snapshots.
+func (s *VolumeStore) RemoveSnapshot(name, snapshot string) error {
+	name = normaliseVolumeName(name)
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	sv, err := s.snapshotter(name, "remove snapshot")
+	if err != nil {
+		return err
+	}
+	if err := sv.RemoveSnapshot(snapshot); err != nil {
+		return &OpErr{Err: err, Name: name, Op: "remove snapshot"}
+	}
+	return nil
+}
+
+// RollbackSnapshot reverts the named volume to one of its snapshots, if its
+// driver supports snapshots.
+func (s *VolumeStore) RollbackSnapshot(name, snapshot string) error {
+	name = normaliseVolumeName(name)
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	sv, err := s.snapshotter(name, "rollback")
+	if err != nil {
+		return err
+	}
+	if err := sv.RollbackSnapshot(snapshot); err != nil {
+		return &OpErr{Err: err, Name: name, Op: "rollback"}
+	}
+	return nil
+}
+
 // Dereference removes the specified reference to the volume
 func (s *VolumeStore) Dereference(v volume.Volume, ref string) {
 	s.locks.Lock(v.Name())
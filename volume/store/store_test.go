@@ -6,7 +6,9 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/docker/docker/pkg/ctxexec"
 	"github.com/docker/docker/volume/drivers"
 	vt "github.com/docker/docker/volume/testutils"
 )
@@ -213,3 +215,161 @@ func TestDerefMultipleOfSameRef(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRotateKeyNotSupported(t *testing.T) {
+	volumedrivers.Register(vt.NewFakeDriver("fake"), "fake")
+	defer volumedrivers.Unregister("fake")
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create("fake1", "fake", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.RotateKey("fake1"); !IsNotSupported(err) {
+		t.Fatalf("Expected not supported error, got %v", err)
+	}
+	if err := s.RotateKey("nonexistent"); !IsNotExist(err) {
+		t.Fatalf("Expected no such volume error, got %v", err)
+	}
+}
+
+func TestResizeNotSupported(t *testing.T) {
+	volumedrivers.Register(vt.NewFakeDriver("fake"), "fake")
+	defer volumedrivers.Unregister("fake")
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create("fake1", "fake", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Resize("fake1", 1<<30, false); !IsNotSupported(err) {
+		t.Fatalf("Expected not supported error, got %v", err)
+	}
+	if err := s.Resize("nonexistent", 1<<30, false); !IsNotExist(err) {
+		t.Fatalf("Expected no such volume error, got %v", err)
+	}
+}
+
+func TestUnlockNotSupported(t *testing.T) {
+	volumedrivers.Register(vt.NewFakeDriver("fake"), "fake")
+	defer volumedrivers.Unregister("fake")
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create("fake1", "fake", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Unlock("fake1", true); !IsNotSupported(err) {
+		t.Fatalf("Expected not supported error, got %v", err)
+	}
+	if err := s.Unlock("nonexistent", false); !IsNotExist(err) {
+		t.Fatalf("Expected no such volume error, got %v", err)
+	}
+}
+
+func TestUsageNotSupported(t *testing.T) {
+	volumedrivers.Register(vt.NewFakeDriver("fake"), "fake")
+	defer volumedrivers.Unregister("fake")
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := s.Create("fake1", "fake", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Usage(v); !IsNotSupported(err) {
+		t.Fatalf("Expected not supported error, got %v", err)
+	}
+}
+
+func TestSnapshotsNotSupported(t *testing.T) {
+	volumedrivers.Register(vt.NewFakeDriver("fake"), "fake")
+	defer volumedrivers.Unregister("fake")
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create("fake1", "fake", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.CreateSnapshot("fake1", "nightly", false); !IsNotSupported(err) {
+		t.Fatalf("Expected not supported error, got %v", err)
+	}
+	if _, err := s.Snapshots("fake1"); !IsNotSupported(err) {
+		t.Fatalf("Expected not supported error, got %v", err)
+	}
+	if err := s.RemoveSnapshot("fake1", "nightly"); !IsNotSupported(err) {
+		t.Fatalf("Expected not supported error, got %v", err)
+	}
+	if err := s.RollbackSnapshot("fake1", "nightly"); !IsNotSupported(err) {
+		t.Fatalf("Expected not supported error, got %v", err)
+	}
+	if _, err := s.CreateFromSnapshot("fake2", "fake1", "nightly", nil, nil); !IsNotSupported(err) {
+		t.Fatalf("Expected not supported error, got %v", err)
+	}
+	if _, err := s.CreateFromSnapshot("fake1", "fake1", "nightly", nil, nil); !IsNameConflict(err) {
+		t.Fatalf("Expected name conflict error, got %v", err)
+	}
+	if _, err := s.CreateSnapshot("nonexistent", "nightly", false); !IsNotExist(err) {
+		t.Fatalf("Expected no such volume error, got %v", err)
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	err := &OpErr{Err: &ctxexec.TimeoutError{Cmd: "mount"}, Name: "foo", Op: "mount"}
+	if !IsTimeout(err) {
+		t.Fatalf("Expected timeout error for %v", err)
+	}
+	if IsTimeout(&OpErr{Err: errNotSupported, Name: "foo", Op: "mount"}) || IsTimeout(nil) {
+		t.Fatal("Expected errors other than timeouts not to be timeouts")
+	}
+}
+
+func TestCreatedAt(t *testing.T) {
+	volumedrivers.Register(vt.NewFakeDriver("fake"), "fake")
+	defer volumedrivers.Unregister("fake")
+	dir, err := ioutil.TempDir("", "test-created-at")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	if _, err := s.Create("fake1", "fake", nil, map[string]string{"a": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	created := s.CreatedAt("fake1")
+	if created.Before(before) || created.After(time.Now()) {
+		t.Fatalf("Expected the creation time of fake1 to be now, got %v", created)
+	}
+	if !s.CreatedAt("unknown").IsZero() {
+		t.Fatal("Expected no creation time for an unknown volume")
+	}
+
+	// the creation time survives a restart of the daemon
+	s.db.Close()
+	s, err = New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.db.Close()
+	if !s.CreatedAt("fake1").Equal(created) {
+		t.Fatalf("Expected creation time %v after reopening the store, got %v", created, s.CreatedAt("fake1"))
+	}
+	if s.labels["fake1"]["a"] != "b" {
+		t.Fatalf("Expected the labels of fake1 after reopening the store, got %v", s.labels["fake1"])
+	}
+}
@@ -0,0 +1,43 @@
+//go:build linux
+// +build linux
+
+package volume
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// FilesystemUsage returns the usage of the filesystem mounted on path. statfs
+// on a network filesystem can hang when the server is unreachable, so it
+// gives up after timeout.
+func FilesystemUsage(path string, timeout time.Duration) (*Usage, error) {
+	type result struct {
+		stat syscall.Statfs_t
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		r.err = syscall.Statfs(path, &r.stat)
+		done <- r
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		bsize := int64(r.stat.Bsize)
+		return &Usage{
+			Size:       int64(r.stat.Blocks) * bsize,
+			Used:       int64(r.stat.Blocks-r.stat.Bfree) * bsize,
+			Available:  int64(r.stat.Bavail) * bsize,
+			Inodes:     int64(r.stat.Files),
+			InodesUsed: int64(r.stat.Files - r.stat.Ffree),
+		}, nil
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("timed out getting the filesystem usage of %s", path)
+	}
+}
@@ -0,0 +1,23 @@
+// +build linux
+
+package volume
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFilesystemUsage(t *testing.T) {
+	usage, err := FilesystemUsage(os.TempDir(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Size <= 0 || usage.Used < 0 || usage.Available > usage.Size || usage.InodesUsed > usage.Inodes {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+
+	if _, err := FilesystemUsage("/nonexistent", time.Second); err == nil {
+		t.Fatal("expected an error for a missing path")
+	}
+}
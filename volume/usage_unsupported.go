@@ -0,0 +1,14 @@
+// +build !linux
+
+package volume
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// FilesystemUsage is not supported on this platform.
+func FilesystemUsage(path string, timeout time.Duration) (*Usage, error) {
+	return nil, fmt.Errorf("filesystem usage is not supported on %s", runtime.GOOS)
+}
@@ -1,10 +1,12 @@
 package volume
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/system"
@@ -22,6 +24,16 @@ const (
 	GlobalScope = "global"
 )
 
+// Access modes of volumes, advertised by volume plugins.
+const (
+	AccessReadWrite = "rw"
+	AccessReadOnly  = "ro"
+)
+
+// ErrNotSupported is returned by volumes whose driver does not support an
+// optional operation.
+var ErrNotSupported = errors.New("operation not supported by the volume driver")
+
 // Driver is for creating and removing volumes.
 type Driver interface {
 	// Name returns the name of the volume driver.
@@ -46,6 +58,21 @@ type Capability struct {
 	// A `local` scope indicates that the driver only manages volumes resources local to the host
 	// Scope is declared by the driver
 	Scope string
+	// Resize is set if the driver can change the size of its volumes.
+	Resize bool `json:",omitempty"`
+	// Snapshot is set if the driver can take snapshots of its volumes and
+	// roll them back.
+	Snapshot bool `json:",omitempty"`
+	// Clone is set if the driver can create volumes from snapshots.
+	Clone bool `json:",omitempty"`
+	// Usage is set if the driver can report the space used by its volumes.
+	Usage bool `json:",omitempty"`
+	// MountOptions is set if the driver takes the way volumes are mounted in
+	// containers into account.
+	MountOptions bool `json:",omitempty"`
+	// AccessModes are the modes volumes can be mounted in containers with,
+	// AccessReadWrite and AccessReadOnly. All modes are supported if empty.
+	AccessModes []string `json:",omitempty"`
 }
 
 // Volume is a place to store data. It is backed by a specific driver, and can be mounted.
@@ -65,6 +92,107 @@ type Volume interface {
 	Status() map[string]interface{}
 }
 
+// MountOptions describes how a volume is mounted in a container.
+type MountOptions struct {
+	// ReadOnly is set if the volume is mounted read-only.
+	ReadOnly bool
+	// ContainerID is the id of the container the volume is mounted in.
+	ContainerID string
+}
+
+// OptionsMounter is implemented by volumes whose driver takes the way they
+// are mounted in containers into account.
+type OptionsMounter interface {
+	// MountWithOptions mounts the volume like Mount does, for a container
+	// mounting it with the given options.
+	MountWithOptions(id string, opts MountOptions) (string, error)
+}
+
+// KeyRotator is implemented by volumes whose contents are encrypted and that
+// can replace their encryption key.
+type KeyRotator interface {
+	// RotateKey replaces the encryption key of the volume with a new one.
+	RotateKey() error
+}
+
+// Resizer is implemented by volumes that can change their size.
+type Resizer interface {
+	// Resize changes the size of the volume to size bytes. Volumes are only
+	// shrunk if force is set, as shrinking may destroy data.
+	Resize(size int64, force bool) error
+}
+
+// Unlocker is implemented by volumes that are locked by the host mounting
+// them, so that hosts sharing their storage don't mount them at the same
+// time.
+type Unlocker interface {
+	// Unlock breaks the lock held on the volume. Locks of hosts that still
+	// appear to use the volume are only broken if force is set.
+	Unlock(force bool) error
+}
+
+// Usage describes the space used by a volume, and the capacity of the
+// storage it is on.
+type Usage struct {
+	// Size is the size in bytes of the storage of the volume.
+	Size int64
+	// Used is the number of bytes used by the volume.
+	Used int64
+	// Available is the number of bytes available to unprivileged users.
+	Available int64
+	// Inodes is the number of inodes of the storage of the volume, 0 if
+	// unknown.
+	Inodes int64
+	// InodesUsed is the number of inodes used by the volume.
+	InodesUsed int64
+	// Reclaimable is whether removing the volume frees the space it uses.
+	// It doesn't for volumes whose data outlives them, such as NFS exports.
+	Reclaimable bool
+}
+
+// SizeReporter is implemented by volumes that can report how much space
+// they use.
+type SizeReporter interface {
+	// Usage returns the space used by the volume. Drivers may cache it, as
+	// it can be expensive to compute.
+	Usage() (*Usage, error)
+}
+
+// Snapshot is a point in time copy of a volume, kept by its driver.
+type Snapshot struct {
+	// Name is the name of the snapshot, unique among those of the volume.
+	Name string
+	// CreatedAt is when the snapshot was taken, zero if the driver does not
+	// know.
+	CreatedAt time.Time
+	// Size is the size in bytes of the volume when the snapshot was taken.
+	Size int64
+}
+
+// Snapshotter is implemented by volumes that can take snapshots of their
+// contents and be rolled back to them.
+type Snapshotter interface {
+	// CreateSnapshot takes a snapshot of the volume. If freeze is set, the
+	// filesystem of a mounted volume is frozen while the snapshot is taken
+	// so that it is consistent.
+	CreateSnapshot(name string, freeze bool) (Snapshot, error)
+	// Snapshots lists the snapshots of the volume.
+	Snapshots() ([]Snapshot, error)
+	// RemoveSnapshot removes a snapshot of the volume.
+	RemoveSnapshot(name string) error
+	// RollbackSnapshot reverts the contents of the volume to a snapshot.
+	RollbackSnapshot(name string) error
+}
+
+// SnapshotDriver is implemented by drivers that can create volumes from the
+// snapshots of their volumes.
+type SnapshotDriver interface {
+	Driver
+	// CreateFromSnapshot makes a new volume with the given name and options
+	// from the named snapshot of the source volume.
+	CreateFromSnapshot(name string, source Volume, snapshot string, opts map[string]string) (Volume, error)
+}
+
 // LabeledVolume wraps a Volume with user-defined labels
 type LabeledVolume interface {
 	Labels() map[string]string
@@ -105,12 +233,17 @@ type MountPoint struct {
 }
 
 // Setup sets up a mount point by either mounting the volume if it is
-// configured, or creating the source directory if supplied.
-func (m *MountPoint) Setup(mountLabel string) (string, error) {
+// configured, or creating the source directory if supplied. Volumes whose
+// driver supports it are told how the container with the given id mounts
+// them.
+func (m *MountPoint) Setup(mountLabel, containerID string) (string, error) {
 	if m.Volume != nil {
 		if m.ID == "" {
 			m.ID = stringid.GenerateNonCryptoID()
 		}
+		if om, ok := m.Volume.(OptionsMounter); ok {
+			return om.MountWithOptions(m.ID, MountOptions{ReadOnly: !m.RW, ContainerID: containerID})
+		}
 		return m.Volume.Mount(m.ID)
 	}
 	if len(m.Source) == 0 {